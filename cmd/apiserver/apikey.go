@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/costa92/go-protoc/internal/apiserver"
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/config"
+	"github.com/costa92/go-protoc/pkg/ratelimit"
+	flag "github.com/spf13/pflag"
+)
+
+// apikeyUsage 是 apikey 子命令的帮助信息
+const apikeyUsage = `用法:
+  apiserver apikey issue --name <name> [--scopes a,b] [--expires-in 720h] [--rate-limit-policy <policy>] [--hash sha256|argon2id]
+  apiserver apikey revoke <id>
+  apiserver apikey list
+
+管理 auth.api_key.file 中的 API Key。issue 签发新的 API Key 并只输出一次完整的密钥，文件中只保存哈希；
+revoke 吊销 API Key；list 列出所有 API Key。运行中的服务器监听文件变化，修改立即生效。
+所有命令都支持 --config 指定配置文件，未指定时使用 CONFIG_PATH 环境变量或 configs/config.yaml；--file 可以直接指定 API Key 文件。
+`
+
+// runAPIKeyCommand 执行 apikey 子命令，返回进程退出码
+func runAPIKeyCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, apikeyUsage)
+		return 2
+	}
+
+	cmd := args[0]
+	fs := flag.NewFlagSet("apikey "+cmd, flag.ContinueOnError)
+	configPath := fs.StringP("config", "c", apiserver.GetConfigPath(), "配置文件路径")
+	file := fs.String("file", "", "API Key 文件路径，为空时使用配置中的 auth.api_key.file")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, apikeyUsage)
+		fs.PrintDefaults()
+	}
+
+	var run func(cfg *config.Config, store auth.APIKeyStore) error
+	switch cmd {
+	case "issue":
+		name := fs.String("name", "", "API Key 的名称，如合作方名称（必填）")
+		scopes := fs.StringSlice("scopes", nil, "授予的权限范围，多个值用逗号分隔")
+		expiresIn := fs.Duration("expires-in", 0, "有效期，为 0 时不过期")
+		policy := fs.String("rate-limit-policy", "", "绑定的限流策略名称，为空时按路由和方法匹配限流策略")
+		hash := fs.String("hash", "", "哈希算法: sha256 或 argon2id，为空时使用配置中的 auth.api_key.hash")
+		run = func(cfg *config.Config, store auth.APIKeyStore) error {
+			return issueAPIKey(cfg, store, *name, *scopes, *expiresIn, *policy, *hash)
+		}
+	case "revoke":
+		run = func(cfg *config.Config, store auth.APIKeyStore) error {
+			if fs.NArg() != 1 {
+				return fmt.Errorf("需要指定一个 API Key ID")
+			}
+			return revokeAPIKey(store, fs.Arg(0))
+		}
+	case "list":
+		run = func(cfg *config.Config, store auth.APIKeyStore) error {
+			return listAPIKeys(store)
+		}
+	default:
+		fmt.Fprint(os.Stderr, apikeyUsage)
+		return 2
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		return 1
+	}
+	if *file == "" {
+		*file = cfg.Auth.APIKey.File
+	}
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "没有配置 auth.api_key.file，请使用 --file 指定 API Key 文件")
+		return 1
+	}
+	store, err := auth.NewFileAPIKeyStore(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := run(cfg, store); err != nil {
+		fmt.Fprintf(os.Stderr, "apikey %s: %v\n", cmd, err)
+		return 1
+	}
+	return 0
+}
+
+// issueAPIKey 签发新的 API Key 并输出完整的密钥
+func issueAPIKey(cfg *config.Config, store auth.APIKeyStore, name string, scopes []string, expiresIn time.Duration, policy, hash string) error {
+	if name == "" {
+		return fmt.Errorf("--name 不能为空")
+	}
+	if expiresIn < 0 {
+		return fmt.Errorf("--expires-in 不能为负数")
+	}
+	if policy != "" && policy != ratelimit.DefaultPolicyName &&
+		!slices.ContainsFunc(cfg.Middleware.RateLimit.Policies, func(p config.RateLimitPolicyConfig) bool { return p.Name == policy }) {
+		return fmt.Errorf("限流策略 %q 不存在", policy)
+	}
+	if hash == "" {
+		hash = cfg.Auth.APIKey.Hash
+	}
+
+	id, key, err := auth.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+	hashed, err := auth.HashAPIKey(key, hash)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	record := &auth.APIKeyRecord{
+		ID:              id,
+		Name:            name,
+		Hash:            hashed,
+		Scopes:          scopes,
+		RateLimitPolicy: policy,
+		CreatedAt:       now,
+	}
+	if expiresIn > 0 {
+		record.ExpiresAt = now.Add(expiresIn)
+	}
+	if err := store.Create(context.Background(), record); err != nil {
+		return err
+	}
+
+	fmt.Printf("ID:  %s\n", id)
+	fmt.Printf("Key: %s\n", key)
+	fmt.Fprintln(os.Stderr, "完整的密钥只显示这一次，请妥善保存")
+	return nil
+}
+
+// revokeAPIKey 吊销 API Key
+func revokeAPIKey(store auth.APIKeyStore, id string) error {
+	if err := store.Revoke(context.Background(), id, time.Now()); err != nil {
+		return fmt.Errorf("%s: %w", id, err)
+	}
+	fmt.Printf("已吊销 API Key %s\n", id)
+	return nil
+}
+
+// listAPIKeys 列出所有 API Key 及其状态
+func listAPIKeys(store auth.APIKeyStore) error {
+	records, err := store.List(context.Background())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Local().Format(time.RFC3339)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tSCOPES\tRATE LIMIT POLICY\tCREATED\tEXPIRES\tLAST USED")
+	for _, r := range records {
+		status := "active"
+		switch {
+		case !r.RevokedAt.IsZero():
+			status = "revoked"
+		case !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt):
+			status = "expired"
+		}
+		policy := r.RateLimitPolicy
+		if policy == "" {
+			policy = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Name, status, strings.Join(r.Scopes, ","), policy,
+			formatTime(r.CreatedAt), formatTime(r.ExpiresAt), formatTime(r.LastUsedAt))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/costa92/go-protoc/internal/apiserver"
+	"github.com/costa92/go-protoc/pkg/config"
+	flag "github.com/spf13/pflag"
+)
+
+// configUsage 是 config 子命令的帮助信息
+const configUsage = `用法: apiserver config validate [--config <path>]
+
+校验配置文件，输出所有错误及其配置路径。校验失败时以非零状态码退出，可用于 CI 检查部署配置。
+未指定 --config 时使用 CONFIG_PATH 环境变量或 configs/config.yaml。
+`
+
+// runConfigCommand 执行 config 子命令，返回进程退出码
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	configPath := fs.StringP("config", "c", apiserver.GetConfigPath(), "配置文件路径")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, configUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if _, err := config.LoadConfig(*configPath); err != nil {
+		var invalid config.ValidationErrors
+		if !errors.As(err, &invalid) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "%s: 发现 %d 个配置错误\n", *configPath, len(invalid))
+		for _, e := range invalid {
+			fmt.Fprintf(os.Stderr, "  %s\n", e)
+		}
+		return 1
+	}
+
+	fmt.Printf("%s: 配置校验通过\n", *configPath)
+	return 0
+}
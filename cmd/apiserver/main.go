@@ -12,6 +12,16 @@ import (
 )
 
 func main() {
+	// 处理子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(runConfigCommand(os.Args[2:]))
+		case "apikey":
+			os.Exit(runAPIKeyCommand(os.Args[2:]))
+		}
+	}
+
 	// 获取配置文件路径
 	configPath := apiserver.GetConfigPath()
 
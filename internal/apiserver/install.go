@@ -6,8 +6,6 @@ import (
 
 	"github.com/costa92/go-protoc/internal/apiserver/service"
 	"github.com/costa92/go-protoc/pkg/app"
-
-	// "github.com/costa92/go-protoc/pkg/auth"
 	"github.com/costa92/go-protoc/pkg/log"
 
 	helloworldv1 "github.com/costa92/go-protoc/pkg/api/helloworld/v1"
@@ -2,31 +2,40 @@ package apiserver
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"time"
 
 	"github.com/costa92/go-protoc/pkg/app"
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/authz"
+	"github.com/costa92/go-protoc/pkg/clientip"
 	"github.com/costa92/go-protoc/pkg/config"
 	"github.com/costa92/go-protoc/pkg/log"
 	"github.com/costa92/go-protoc/pkg/metrics"
 	grpcmiddleware "github.com/costa92/go-protoc/pkg/middleware/grpc"
 	httpmiddleware "github.com/costa92/go-protoc/pkg/middleware/http"
+	"github.com/costa92/go-protoc/pkg/ratelimit"
+	tlsutil "github.com/costa92/go-protoc/pkg/tls"
 	"github.com/costa92/go-protoc/pkg/tracing"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 )
 
 // Server 表示 API 服务器
 type Server struct {
-	app *app.App
-	tp  *sdktrace.TracerProvider
+	app     *app.App
+	tp      *sdktrace.TracerProvider
+	limiter *ratelimit.Limiter
 }
 
 // NewServer 创建一个新的 API 服务器实例
@@ -41,6 +50,8 @@ func NewServer(configPath string) (*Server, error) {
 	if initErr := log.Init(cfg.Log); initErr != nil {
 		return nil, initErr
 	}
+	// 第三方库通过 slog、grpclog 和 klog 输出的日志同样使用全局日志记录器
+	log.InstallBridges()
 
 	log.Infof("成功加载配置文件来自 %s", configPath)
 
@@ -50,17 +61,48 @@ func NewServer(configPath string) (*Server, error) {
 		return nil, err
 	}
 
+	// 配置指标直方图的桶，必须在服务器处理请求之前完成
+	metrics.SetHistogramBuckets(cfg.Observability.Metrics.HTTPBuckets, cfg.Observability.Metrics.GRPCBuckets)
+
+	// 创建配置监听器，配置文件变化或接收到 SIGHUP 时重新加载可热更新的配置
+	watcher := config.NewWatcher(configPath, cfg)
+	watcher.Subscribe(reloadLog, config.SectionLog)
+
+	// 创建 HTTP 和 gRPC 共用的中间件组件
+	shared, err := createSharedMiddlewares(cfg, watcher)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建 HTTP 服务器
-	httpServer := createHTTPServer(cfg)
+	httpServer, err := createHTTPServer(cfg, watcher, shared)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建 gRPC 服务器
-	grpcServer, err := createGRPCServer(cfg, tp)
+	grpcServer, err := createGRPCServer(cfg, watcher, tp, shared)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建应用实例，管理所有服务
-	application := app.NewApp("api-server", httpServer, grpcServer)
+	// 根据运行模式创建监听器和应用实例，管理所有服务
+	application, err := createApp(cfg, httpServer, grpcServer)
+	if err != nil {
+		return nil, err
+	}
+	application.AddServer(watcher)
+	// 接收到 SIGUSR1 时重新打开日志文件，配合 logrotate 使用
+	application.AddServer(log.NewSignalReopener())
+	// 定期把 API Key 的最近使用时间写回文件，停止时写回剩余的部分
+	if shared.apiKeys != nil {
+		application.AddServer(shared.apiKeys)
+	}
+
+	// 修改日志级别的管理接口默认关闭，需要在安装 API 组之前注册
+	if cfg.Server.Admin.Enable {
+		registerAdmin(cfg, httpServer, grpcServer)
+	}
 
 	// 安装所有已注册的 API 组
 	if err := installAPIGroups(grpcServer, httpServer); err != nil {
@@ -74,73 +116,511 @@ func NewServer(configPath string) (*Server, error) {
 	}
 
 	return &Server{
-		app: application,
-		tp:  tp,
+		app:     application,
+		tp:      tp,
+		limiter: shared.limiter,
 	}, nil
 }
 
+// reloadLog 在日志配置变化时重新初始化日志记录器，仅级别变化时原地调整级别
+func reloadLog(e config.Event) error {
+	oldOpts, newOpts := *e.Old.Log, *e.New.Log
+	oldOpts.Level = newOpts.Level
+	if reflect.DeepEqual(oldOpts, newOpts) {
+		if err := log.SetLevel(newOpts.Level); err != nil {
+			return fmt.Errorf("更新日志级别失败: %w", err)
+		}
+		log.Infow("日志级别已更新", "level", newOpts.Level)
+		return nil
+	}
+
+	if err := log.Init(e.New.Log); err != nil {
+		return fmt.Errorf("重新初始化日志记录器失败: %w", err)
+	}
+	log.Infow("日志配置已重新加载")
+	return nil
+}
+
+// corsOptions 将配置转换为 CORS 中间件的选项
+func corsOptions(cfg *config.CORSConfig) httpmiddleware.CORSOptions {
+	opts := httpmiddleware.CORSOptions{CORSPolicy: corsPolicy(&cfg.CORSPolicyConfig)}
+	for i := range cfg.Routes {
+		opts.Routes = append(opts.Routes, httpmiddleware.CORSRoute{
+			PathPrefix: cfg.Routes[i].PathPrefix,
+			CORSPolicy: corsPolicy(&cfg.Routes[i].CORSPolicyConfig),
+		})
+	}
+	return opts
+}
+
+// corsPolicy 将配置转换为跨域策略
+func corsPolicy(cfg *config.CORSPolicyConfig) httpmiddleware.CORSPolicy {
+	return httpmiddleware.CORSPolicy{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+}
+
+// timeoutOptions 将配置转换为超时中间件的选项
+func timeoutOptions(cfg *config.Config) httpmiddleware.TimeoutOptions {
+	opts := httpmiddleware.TimeoutOptions{
+		Timeout:        cfg.Middleware.Timeout,
+		StreamingPaths: cfg.Middleware.StreamingPaths,
+		SkipPaths:      cfg.Observability.SkipPaths,
+	}
+	for _, route := range cfg.Middleware.TimeoutRoutes {
+		opts.Routes = append(opts.Routes, httpmiddleware.TimeoutRoute{PathPrefix: route.PathPrefix, Timeout: route.Timeout})
+	}
+	return opts
+}
+
+// deadlineOptions 将配置转换为 gRPC 截止时间拦截器的选项
+func deadlineOptions(cfg *config.GRPCDeadlineConfig) grpcmiddleware.DeadlineOptions {
+	opts := grpcmiddleware.DeadlineOptions{Default: cfg.Default, Max: cfg.Max, SafetyMargin: cfg.SafetyMargin}
+	for _, m := range cfg.Methods {
+		opts.Methods = append(opts.Methods, grpcmiddleware.DeadlineMethod{Method: m.Method, Default: m.Default, Max: m.Max})
+	}
+	return opts
+}
+
+// rateLimitOptions 将配置转换为限流器的选项
+func rateLimitOptions(cfg *config.Config) ratelimit.Options {
+	rl := &cfg.Middleware.RateLimit
+	opts := ratelimit.Options{
+		Enable:       rl.Enable,
+		Default:      ratelimit.Policy{Key: rl.Key, Limit: rl.Limit, Burst: rl.Burst, Window: rl.Window},
+		APIKeyHeader: rl.APIKeyHeader,
+		TenantHeader: rl.TenantHeader,
+		SkipPaths:    cfg.Observability.SkipPaths,
+	}
+	for _, p := range rl.Policies {
+		opts.Policies = append(opts.Policies, ratelimit.Policy{
+			Name:    p.Name,
+			Routes:  p.Routes,
+			Methods: p.Methods,
+			Key:     p.Key,
+			Limit:   p.Limit,
+			Burst:   p.Burst,
+			Window:  p.Window,
+		})
+	}
+	return opts
+}
+
+// clientIPOptions 将配置转换为客户端 IP 解析器的选项
+func clientIPOptions(cfg *config.ClientIPConfig) clientip.Options {
+	return clientip.Options{TrustedProxies: cfg.TrustedProxies, Header: cfg.Header}
+}
+
+// ipFilterOptions 将配置转换为 IP 过滤器的选项
+func ipFilterOptions(cfg *config.IPFilterConfig) clientip.FilterOptions {
+	return clientip.FilterOptions{Allow: cfg.Allow, Deny: cfg.Deny}
+}
+
+// authOptions 将配置转换为认证的选项
+func authOptions(cfg *config.AuthConfig) auth.Options {
+	return auth.Options{Enable: cfg.Enable, PublicMethods: cfg.PublicMethods, PublicPaths: cfg.PublicPaths}
+}
+
+// authenticator 根据配置创建认证器，同时启用 JWT 和 API Key 时先尝试 JWT，没有配置任何凭证来源时返回 nil
+func authenticator(cfg *config.AuthConfig, store auth.APIKeyStore) (auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+	if jwt := &cfg.JWT; jwt.Secret != "" || jwt.JWKSFile != "" || jwt.JWKSURL != "" {
+		authn, err := auth.NewJWT(auth.JWTOptions{
+			Issuer:       jwt.Issuer,
+			Audience:     jwt.Audience,
+			ClockSkew:    jwt.ClockSkew,
+			Algorithms:   jwt.Algorithms,
+			Secret:       jwt.Secret,
+			JWKSFile:     jwt.JWKSFile,
+			JWKSURL:      jwt.JWKSURL,
+			JWKSCacheTTL: jwt.JWKSCacheTTL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authn)
+	}
+	if cfg.APIKey.Enable {
+		if store == nil {
+			return nil, fmt.Errorf("API Key 文件在启动时没有配置，需要重启才能启用 API Key 认证")
+		}
+		authn, err := auth.NewAPIKey(auth.APIKeyOptions{Header: cfg.APIKey.Header, QueryParam: cfg.APIKey.QueryParam, Store: store})
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authn)
+	}
+
+	switch len(authenticators) {
+	case 0:
+		return nil, nil
+	case 1:
+		return authenticators[0], nil
+	default:
+		return auth.Chain(authenticators...), nil
+	}
+}
+
+// authzOptions 将配置转换为授权的选项
+func authzOptions(cfg *config.AuthzConfig) authz.Options {
+	return authz.Options{Enable: cfg.Enable, DryRun: cfg.DryRun, Audit: cfg.Audit}
+}
+
+// authzPolicy 加载授权策略文件，没有配置策略文件时返回 nil
+func authzPolicy(cfg *config.AuthzConfig) (*authz.Policy, error) {
+	if cfg.PolicyFile == "" {
+		return nil, nil
+	}
+	return authz.LoadPolicyFile(cfg.PolicyFile)
+}
+
+// sharedMiddlewares 是 HTTP 和 gRPC 服务器共用的中间件组件
+type sharedMiddlewares struct {
+	ipResolver *clientip.Resolver
+	ipFilter   *clientip.Filter
+	limiter    *ratelimit.Limiter
+	guard      *auth.Guard
+	// apiKeys 是 API Key 存储，没有配置 API Key 文件时为 nil
+	apiKeys    *auth.FileAPIKeyStore
+	authorizer *authz.Authorizer
+}
+
+// createSharedMiddlewares 创建 HTTP 和 gRPC 服务器共用的中间件组件
+func createSharedMiddlewares(cfg *config.Config, watcher *config.Watcher) (*sharedMiddlewares, error) {
+	ipResolver, ipFilter, err := createClientIP(cfg, watcher)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := createRateLimiter(cfg, watcher)
+	if err != nil {
+		return nil, err
+	}
+	guard, apiKeys, err := createAuth(cfg, watcher)
+	if err != nil {
+		return nil, err
+	}
+	authorizer, err := createAuthz(cfg, watcher)
+	if err != nil {
+		return nil, err
+	}
+	return &sharedMiddlewares{ipResolver: ipResolver, ipFilter: ipFilter, limiter: limiter, guard: guard, apiKeys: apiKeys, authorizer: authorizer}, nil
+}
+
+// createAuth 创建认证守卫和 API Key 存储，并订阅对应配置段和 API Key 文件的变化，新配置无效时继续使用原来的配置
+// API Key 文件的路径修改后需要重启才能生效
+func createAuth(cfg *config.Config, watcher *config.Watcher) (*auth.Guard, *auth.FileAPIKeyStore, error) {
+	var store *auth.FileAPIKeyStore
+	if file := cfg.Auth.APIKey.File; file != "" {
+		var err error
+		if store, err = auth.NewFileAPIKeyStore(file); err != nil {
+			return nil, nil, fmt.Errorf("创建 API Key 存储失败: %w", err)
+		}
+		watcher.WatchFile(file, func() {
+			if err := store.Reload(); err != nil {
+				log.Errorw("重新加载 API Key 文件失败，继续使用原来的 API Key", "file", file, "error", err)
+				return
+			}
+			// 写回最近使用时间也会触发重新加载，因此只记录调试日志
+			log.Debugw("API Key 文件已重新加载", "file", file)
+		})
+	}
+
+	authn, err := authenticator(&cfg.Auth, apiKeyStore(store))
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建认证器失败: %w", err)
+	}
+	guard := auth.NewGuard(authOptions(&cfg.Auth), authn)
+	if cfg.Auth.Enable {
+		log.Infow("已启用认证", "public_paths", cfg.Auth.PublicPaths, "public_methods", cfg.Auth.PublicMethods, "api_key", cfg.Auth.APIKey.Enable)
+	}
+
+	watcher.Subscribe(func(e config.Event) error {
+		if e.Old.Auth.APIKey.File != e.New.Auth.APIKey.File {
+			log.Warnw("API Key 文件的路径修改后需要重启才能生效")
+		}
+		authn, err := authenticator(&e.New.Auth, apiKeyStore(store))
+		if err != nil {
+			return fmt.Errorf("更新认证配置失败: %w", err)
+		}
+		guard.Update(authOptions(&e.New.Auth), authn)
+		return nil
+	}, config.SectionAuth)
+	return guard, store, nil
+}
+
+// apiKeyStore 把 nil 的 *auth.FileAPIKeyStore 转换为 nil 接口
+func apiKeyStore(store *auth.FileAPIKeyStore) auth.APIKeyStore {
+	if store == nil {
+		return nil
+	}
+	return store
+}
+
+// rateLimitStore 根据配置创建保存令牌桶的存储
+func rateLimitStore(cfg *config.RateLimitConfig) ratelimit.Store {
+	if cfg.Backend == ratelimit.BackendRedis {
+		store := ratelimit.NewRedisStore(ratelimit.RedisOptions{
+			Addrs:     cfg.Redis.Addrs,
+			Username:  cfg.Redis.Username,
+			Password:  cfg.Redis.Password,
+			DB:        cfg.Redis.DB,
+			KeyPrefix: cfg.Redis.KeyPrefix,
+			Timeout:   cfg.Redis.Timeout,
+		})
+		// Redis 不可用时限流器放行请求，因此启动时只记录警告
+		if err := store.Ping(context.Background()); err != nil {
+			log.Warnw("连接限流 Redis 失败，Redis 恢复之前不限流", "addrs", cfg.Redis.Addrs, "error", err)
+		} else {
+			log.Infow("限流使用 Redis 存储", "addrs", cfg.Redis.Addrs)
+		}
+		return store
+	}
+	return ratelimit.NewMemoryStore(cfg.MaxKeys)
+}
+
+// createRateLimiter 创建限流器，并订阅对应配置段的变化，存储的配置修改后需要重启才能生效
+func createRateLimiter(cfg *config.Config, watcher *config.Watcher) (*ratelimit.Limiter, error) {
+	limiter, err := ratelimit.NewLimiter(rateLimitOptions(cfg), rateLimitStore(&cfg.Middleware.RateLimit))
+	if err != nil {
+		return nil, fmt.Errorf("创建限流器失败: %w", err)
+	}
+	// 旧版本的 limit 是每秒的请求数，window 不生效，沿用旧配置时 window 不是 1s 会按新的含义大幅收紧限流
+	if rl := &cfg.Middleware.RateLimit; rl.Enable && rl.Window > 0 && rl.Window != time.Second {
+		log.Warnw("限流的 limit 表示每个 window 允许的请求数，旧版本中表示每秒的请求数，从旧版本升级时请确认配置",
+			"limit", rl.Limit, "window", rl.Window)
+	}
+	watcher.Subscribe(func(e config.Event) error {
+		if err := limiter.Update(rateLimitOptions(e.New)); err != nil {
+			return fmt.Errorf("更新限流配置失败: %w", err)
+		}
+		prev, next := &e.Old.Middleware.RateLimit, &e.New.Middleware.RateLimit
+		if prev.Backend != next.Backend || prev.MaxKeys != next.MaxKeys || !reflect.DeepEqual(prev.Redis, next.Redis) {
+			log.Warnw("限流存储的配置修改后需要重启才能生效")
+		}
+		return nil
+	}, config.SectionRateLimit, config.SectionSkipPaths)
+	return limiter, nil
+}
+
+// createAuthz 创建授权器，并订阅对应配置段和策略文件的变化，新配置或策略无效时继续使用原来的配置
+func createAuthz(cfg *config.Config, watcher *config.Watcher) (*authz.Authorizer, error) {
+	policy, err := authzPolicy(&cfg.Authz)
+	if err != nil {
+		return nil, fmt.Errorf("创建授权器失败: %w", err)
+	}
+	authorizer, err := authz.NewAuthorizer(authzOptions(&cfg.Authz), policy)
+	if err != nil {
+		return nil, fmt.Errorf("创建授权器失败: %w", err)
+	}
+	if cfg.Authz.Enable {
+		log.Infow("已启用授权", "policy_file", cfg.Authz.PolicyFile, "dry_run", cfg.Authz.DryRun)
+	}
+
+	reload := func(c *config.AuthzConfig) error {
+		policy, err := authzPolicy(c)
+		if err == nil {
+			err = authorizer.Update(authzOptions(c), policy)
+		}
+		if err != nil {
+			return fmt.Errorf("更新授权配置失败: %w", err)
+		}
+		log.Infow("授权配置已更新", "policy_file", c.PolicyFile, "dry_run", c.DryRun)
+		return nil
+	}
+	watcher.Subscribe(func(e config.Event) error {
+		if e.Old.Authz.PolicyFile != e.New.Authz.PolicyFile {
+			log.Warnw("授权策略文件的路径修改后需要重启才能监听新文件的变化")
+		}
+		return reload(&e.New.Authz)
+	}, config.SectionAuthz)
+	if cfg.Authz.PolicyFile != "" {
+		watcher.WatchFile(cfg.Authz.PolicyFile, func() {
+			c := &watcher.Current().Authz
+			if err := reload(c); err != nil {
+				log.Errorw("重新加载授权策略失败，继续使用原来的策略", "policy_file", c.PolicyFile, "error", err)
+			}
+		})
+	}
+	return authorizer, nil
+}
+
+// createClientIP 创建客户端 IP 解析器和 IP 过滤器，并订阅对应配置段的变化
+func createClientIP(cfg *config.Config, watcher *config.Watcher) (*clientip.Resolver, *clientip.Filter, error) {
+	resolver, err := clientip.NewResolver(clientIPOptions(&cfg.Middleware.ClientIP))
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建客户端 IP 解析器失败: %w", err)
+	}
+	filter, err := clientip.NewFilter(ipFilterOptions(&cfg.Middleware.IPFilter))
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建 IP 过滤器失败: %w", err)
+	}
+
+	watcher.Subscribe(func(e config.Event) error {
+		if err := resolver.Update(clientIPOptions(&e.New.Middleware.ClientIP)); err != nil {
+			return fmt.Errorf("更新客户端 IP 解析配置失败: %w", err)
+		}
+		return nil
+	}, config.SectionClientIP)
+	watcher.Subscribe(func(e config.Event) error {
+		if err := filter.Update(ipFilterOptions(&e.New.Middleware.IPFilter)); err != nil {
+			return fmt.Errorf("更新 IP 过滤配置失败: %w", err)
+		}
+		return nil
+	}, config.SectionIPFilter)
+	return resolver, filter, nil
+}
+
 // createHTTPServer 创建和配置 HTTP 服务器
-func createHTTPServer(cfg *config.Config) *app.HTTPServer {
+func createHTTPServer(cfg *config.Config, watcher *config.Watcher, shared *sharedMiddlewares) (*app.HTTPServer, error) {
 	// 为 OpenTelemetry HTTP 追踪创建一个中间件
 	otelHTTPMiddleware := func(next http.Handler) http.Handler {
 		return otelhttp.NewHandler(next, "http-server")
 	}
 
+	// 创建支持热加载的中间件，并订阅对应配置段的变化
+	timeout := httpmiddleware.NewTimeout(timeoutOptions(cfg))
+	watcher.Subscribe(func(e config.Event) error {
+		timeout.Update(timeoutOptions(e.New))
+		return nil
+	}, config.SectionTimeout, config.SectionSkipPaths)
+
+	cors := httpmiddleware.NewCORS(corsOptions(&cfg.Middleware.CORS))
+	watcher.Subscribe(func(e config.Event) error {
+		cors.Update(corsOptions(&e.New.Middleware.CORS))
+		return nil
+	}, config.SectionCORS)
+
 	// 创建带中间件的 HTTP 服务器
-	return app.NewHTTPServer(
+	httpServer := app.NewHTTPServer(
 		"api-http",
 		cfg.Server.HTTP.Addr,
 		otelHTTPMiddleware,
-		httpmiddleware.LoggingMiddleware(
+		httpmiddleware.RequestIDMiddleware(),
+		httpmiddleware.ClientIPMiddleware(shared.ipResolver),
+		httpmiddleware.ClientCertMiddleware(),
+		httpmiddleware.MetricsMiddleware(
 			cfg.Observability.SkipPaths,
 		),
-		httpmiddleware.RecoveryMiddleware(),
-		httpmiddleware.TimeoutMiddleware(time.Duration(cfg.Middleware.Timeout)),
-		httpmiddleware.CORSMiddleware(
-			cfg.Middleware.CORS.AllowOrigins,
-			cfg.Middleware.CORS.AllowMethods,
-			cfg.Middleware.CORS.AllowHeaders,
-			cfg.Middleware.CORS.ExposeHeaders,
-			cfg.Middleware.CORS.AllowCredentials,
-			cfg.Middleware.CORS.MaxAge,
-		),
-		httpmiddleware.RateLimitMiddleware(
-			cfg.Middleware.RateLimit.Enable,
-			float64(cfg.Middleware.RateLimit.Limit),
-			cfg.Middleware.RateLimit.Burst,
+		httpmiddleware.LoggingMiddleware(
 			cfg.Observability.SkipPaths,
 		),
+		httpmiddleware.RecoveryMiddleware(),
+		httpmiddleware.IPFilterMiddleware(shared.ipFilter),
+		// 跨域响应头在超时中间件之外设置，超时的响应同样带有跨域响应头
+		cors.Middleware(),
+		timeout.Middleware(),
+		httpmiddleware.AuthMiddleware(shared.guard),
+		httpmiddleware.AuthzMiddleware(shared.authorizer),
+		httpmiddleware.LimiterMiddleware(shared.limiter),
 		httpmiddleware.ValidationMiddleware(),
 	)
+
+	// 配置 TLS（单端口模式下 gRPC 同样使用该配置）
+	if cfg.Server.HTTP.TLS.Enabled {
+		tlsConfig, err := tlsutil.NewConfig(&cfg.Server.HTTP.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("创建 HTTP TLS 配置失败: %w", err)
+		}
+		httpServer.TLSConfig = tlsConfig
+	}
+
+	return httpServer, nil
 }
 
-// createGRPCServer 创建和配置 gRPC 服务器
-func createGRPCServer(cfg *config.Config, tp *sdktrace.TracerProvider) (*app.GRPCServer, error) {
+// createApp 根据服务器运行模式创建监听器，并将服务添加到应用中
+func createApp(cfg *config.Config, httpServer *app.HTTPServer, grpcServer *app.GRPCServer) (*app.App, error) {
+	if cfg.Server.Mode == config.ServerModeSingle {
+		// 单端口模式：gRPC 与 HTTP 共用 HTTP 地址
+		lis, err := net.Listen("tcp", cfg.Server.HTTP.Addr)
+		if err != nil {
+			return nil, err
+		}
+		log.Infow("以单端口模式运行", "addr", cfg.Server.HTTP.Addr)
+		return app.NewApp("api-server", app.NewMuxServer("api-mux", lis, grpcServer, httpServer)), nil
+	}
+
 	// 创建 gRPC 监听器
 	lis, err := net.Listen("tcp", cfg.Server.GRPC.Addr)
 	if err != nil {
 		return nil, err
 	}
+	grpcServer.SetListener(lis)
+
+	return app.NewApp("api-server", httpServer, grpcServer), nil
+}
 
+// createGRPCServer 创建和配置 gRPC 服务器
+func createGRPCServer(cfg *config.Config, watcher *config.Watcher, tp *sdktrace.TracerProvider, shared *sharedMiddlewares) (*app.GRPCServer, error) {
 	// 创建 gRPC 统计处理器
 	otelGrpcHandler := otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tp))
 
-	// 创建带拦截器的 gRPC 服务器
-	return app.NewGRPCServer(
-		"api-grpc",
-		lis,
+	deadline := grpcmiddleware.NewDeadline(deadlineOptions(&cfg.Middleware.GRPCDeadline))
+	watcher.Subscribe(func(e config.Event) error {
+		deadline.Update(deadlineOptions(&e.New.Middleware.GRPCDeadline))
+		return nil
+	}, config.SectionGRPCDeadline)
+
+	opts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(
+			grpcmiddleware.UnaryRequestIDInterceptor(),
+			grpcmiddleware.UnaryClientIPInterceptor(shared.ipResolver),
+			grpcmiddleware.UnaryClientCertInterceptor(),
+			grpcmiddleware.UnaryErrorInterceptor(),
+			grpcmiddleware.UnaryMetricsInterceptor(),
 			grpcmiddleware.UnaryLoggingInterceptor(),
 			grpcmiddleware.UnaryRecoveryInterceptor(),
+			grpcmiddleware.UnaryDeadlineInterceptor(deadline),
+			grpcmiddleware.UnaryIPFilterInterceptor(shared.ipFilter),
+			grpcmiddleware.UnaryAuthInterceptor(shared.guard),
+			grpcmiddleware.UnaryAuthzInterceptor(shared.authorizer),
+			grpcmiddleware.UnaryRateLimitInterceptor(shared.limiter),
 			grpcmiddleware.ValidationUnaryServerInterceptor(),
 		),
 		grpc.ChainStreamInterceptor(
+			grpcmiddleware.StreamRequestIDInterceptor(),
+			grpcmiddleware.StreamClientIPInterceptor(shared.ipResolver),
+			grpcmiddleware.StreamClientCertInterceptor(),
+			grpcmiddleware.StreamErrorInterceptor(),
+			grpcmiddleware.StreamMetricsInterceptor(),
 			grpcmiddleware.StreamLoggingInterceptor(),
 			grpcmiddleware.StreamRecoveryInterceptor(),
+			grpcmiddleware.StreamDeadlineInterceptor(deadline),
+			grpcmiddleware.StreamIPFilterInterceptor(shared.ipFilter),
+			grpcmiddleware.StreamAuthInterceptor(shared.guard),
+			grpcmiddleware.StreamAuthzInterceptor(shared.authorizer),
+			grpcmiddleware.StreamRateLimitInterceptor(shared.limiter),
 			grpcmiddleware.ValidationStreamServerInterceptor(),
 		),
 		grpc.StatsHandler(otelGrpcHandler),
-	), nil
+	}
+
+	// 单端口模式下 TLS 由 HTTP 服务器终止，gRPC 服务器本身不配置证书
+	if cfg.Server.Mode != config.ServerModeSingle && cfg.Server.GRPC.TLS.Enabled {
+		tlsConfig, err := tlsutil.NewConfig(&cfg.Server.GRPC.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("创建 gRPC TLS 配置失败: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	// 创建带拦截器的 gRPC 服务器
+	return app.NewGRPCServer("api-grpc", nil, opts...), nil
+}
+
+// registerAdmin 注册 /debug/loglevel 路由和 admin.v1.Admin 服务
+// 启用认证时它们和业务接口一样需要认证和授权，配置校验保证它们不会被配置为公开
+func registerAdmin(cfg *config.Config, httpServer *app.HTTPServer, grpcServer *app.GRPCServer) {
+	httpServer.RegisterLogLevelRoute()
+	grpcServer.RegisterAdminService()
+	if !cfg.Auth.Enable {
+		log.Warnw("已启用管理接口但没有启用认证，任何可以访问服务的客户端都可以修改日志级别，应通过网络策略限制访问",
+			"path", app.LogLevelPath)
+	}
 }
 
 // installAPIGroups 安装所有已注册的 API 组
@@ -167,28 +647,39 @@ func installAPIGroups(grpcServer *app.GRPCServer, httpServer *app.HTTPServer) er
 	return nil
 }
 
-// Start 启动服务器
+// Start 启动服务器，上下文取消后优雅关闭所有服务，等待 HTTP 和 gRPC 服务器处理完剩余的请求后才返回
 func (s *Server) Start(ctx context.Context) error {
-	// 创建一个带超时的上下文，用于优雅关闭
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// 设置关闭处理
+	// 设置关闭处理，服务启动失败时同样关闭已经启动的服务
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-ctx.Done() // 等待取消信号
 		log.Infof("接收到关闭信号，开始优雅关闭...")
+		// 超时从开始关闭时计算
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
 		if err := s.app.Stop(shutdownCtx); err != nil {
 			log.Errorf("关闭服务器时发生错误: %v", err)
 		}
 	}()
 
 	// 启动应用
-	return s.app.Start(ctx)
+	err := s.app.Start(ctx)
+	cancel()
+	<-stopped
+	return err
 }
 
-// Stop 停止服务器
+// Stop 在 Start 返回之后释放限流存储和追踪器等资源
+// Start 返回时所有服务都已经关闭，关闭限流存储不会影响仍在处理的请求
 func (s *Server) Stop(ctx context.Context) error {
 	log.Infof("开始关闭服务器...")
+	if err := s.limiter.Close(); err != nil {
+		log.Errorw("关闭限流存储失败", "error", err)
+	}
 	if s.tp != nil {
 		if err := s.tp.Shutdown(ctx); err != nil {
 			log.Errorf("关闭追踪器失败: %v", err)
@@ -0,0 +1,92 @@
+package apiserver
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	adminv1 "github.com/costa92/go-protoc/pkg/api/admin/v1"
+	"github.com/costa92/go-protoc/pkg/app"
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/authz"
+	"github.com/costa92/go-protoc/pkg/config"
+	"github.com/costa92/go-protoc/pkg/ratelimit"
+)
+
+// 在初始化时注册配置校验规则，apiserver config validate、启动和热加载都会使用
+func init() {
+	config.RegisterRule(validateConfig)
+}
+
+// ruleErrors 收集校验规则发现的错误
+type ruleErrors config.ValidationErrors
+
+// addf 记录一个配置项错误
+func (e *ruleErrors) addf(path, format string, args ...any) {
+	*e = append(*e, &config.FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateConfig 校验由 auth、authz、ratelimit 和 app 包定义可选值的配置项
+func validateConfig(c *config.Config) config.ValidationErrors {
+	var errs ruleErrors
+
+	for i, alg := range c.Auth.JWT.Algorithms {
+		if !slices.Contains(auth.JWTAlgorithms(), alg) {
+			errs.addf(fmt.Sprintf("auth.jwt.algorithms[%d]", i), "不支持的签名算法 %q，可选值: %s", alg, strings.Join(auth.JWTAlgorithms(), ", "))
+		}
+	}
+	if hash := c.Auth.APIKey.Hash; hash != "" && !slices.Contains(auth.HashAlgorithms(), hash) {
+		errs.addf("auth.api_key.hash", "不支持的哈希算法 %q，可选值: %s", hash, strings.Join(auth.HashAlgorithms(), ", "))
+	}
+	if audit := c.Authz.Audit; audit != "" && !slices.Contains(authz.AuditLevels(), audit) {
+		errs.addf("authz.audit", "不支持的审计日志级别 %q，可选值: %s", audit, strings.Join(authz.AuditLevels(), ", "))
+	}
+
+	rl := &c.Middleware.RateLimit
+	switch rl.Backend {
+	case "", ratelimit.BackendMemory:
+	case ratelimit.BackendRedis:
+		if len(rl.Redis.Addrs) == 0 {
+			errs.addf("middleware.rate_limit.redis.addrs", "使用 redis 存储时不能为空")
+		}
+	default:
+		errs.addf("middleware.rate_limit.backend", "不支持的存储 %q，可选值: %s, %s", rl.Backend, ratelimit.BackendMemory, ratelimit.BackendRedis)
+	}
+	errs.rateLimitKey("middleware.rate_limit.key", rl.Key)
+	for i, p := range rl.Policies {
+		path := fmt.Sprintf("middleware.rate_limit.policies[%d]", i)
+		if p.Name == ratelimit.DefaultPolicyName {
+			errs.addf(path+".name", "策略名称 %q 保留给默认策略", p.Name)
+		}
+		errs.rateLimitKey(path+".key", p.Key)
+	}
+
+	errs.admin(c)
+	return config.ValidationErrors(errs)
+}
+
+// rateLimitKey 校验限流键，空值表示使用默认值，自定义的键需要在加载配置之前通过 ratelimit.RegisterKey 注册
+func (e *ruleErrors) rateLimitKey(path, key string) {
+	if key != "" && !ratelimit.KeyRegistered(key) {
+		e.addf(path, "不支持的限流键 %q，可选值: %s", key, strings.Join(ratelimit.KeyNames(), ", "))
+	}
+}
+
+// admin 校验启用认证时管理接口没有被配置为公开
+func (e *ruleErrors) admin(c *config.Config) {
+	if !c.Server.Admin.Enable || !c.Auth.Enable {
+		return
+	}
+	for i, prefix := range c.Auth.PublicPaths {
+		if strings.HasPrefix(app.LogLevelPath, prefix) {
+			e.addf(fmt.Sprintf("auth.public_paths[%d]", i), "启用 server.admin 时不能公开管理接口 %s", app.LogLevelPath)
+		}
+	}
+	service := "/" + adminv1.Admin_ServiceDesc.ServiceName + "/"
+	for i, m := range c.Auth.PublicMethods {
+		prefix, wildcard := strings.CutSuffix(m, "*")
+		if strings.HasPrefix(m, service) || wildcard && strings.HasPrefix(service, prefix) {
+			e.addf(fmt.Sprintf("auth.public_methods[%d]", i), "启用 server.admin 时不能公开管理服务 %s", adminv1.Admin_ServiceDesc.ServiceName)
+		}
+	}
+}
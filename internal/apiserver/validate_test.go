@@ -0,0 +1,90 @@
+package apiserver
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/costa92/go-protoc/pkg/config"
+	"github.com/costa92/go-protoc/pkg/ratelimit"
+)
+
+func TestValidateConfig(t *testing.T) {
+	ratelimit.RegisterKey("region", func(req ratelimit.Request) string { return "" })
+
+	// 定义测试用例
+	testCases := []struct {
+		name   string
+		modify func(c *config.Config)
+		paths  []string
+	}{
+		{
+			name:   "默认配置",
+			modify: func(c *config.Config) {},
+		},
+		{
+			name: "认证和授权",
+			modify: func(c *config.Config) {
+				c.Auth.JWT.Algorithms = []string{"RS256", "none"}
+				c.Auth.APIKey.Hash = "md5"
+				c.Authz.Audit = "allow"
+			},
+			paths: []string{"auth.jwt.algorithms[1]", "auth.api_key.hash", "authz.audit"},
+		},
+		{
+			name: "限流",
+			modify: func(c *config.Config) {
+				c.Middleware.RateLimit.Key = "session"
+				c.Middleware.RateLimit.Backend = "redis"
+				c.Middleware.RateLimit.Policies = []config.RateLimitPolicyConfig{
+					{Name: "default", Routes: []string{"/v1/hello"}, Key: "user", Limit: 10},
+					{Name: "region", Routes: []string{"/v1/login"}, Key: "region", Limit: 5},
+					{Name: "login", Routes: []string{"/v1/login"}, Key: "session", Limit: 5},
+				}
+			},
+			paths: []string{
+				"middleware.rate_limit.redis.addrs",
+				"middleware.rate_limit.key",
+				"middleware.rate_limit.policies[0].name",
+				"middleware.rate_limit.policies[2].key",
+			},
+		},
+		{
+			name: "未知的存储",
+			modify: func(c *config.Config) {
+				c.Middleware.RateLimit.Backend = "memcached"
+			},
+			paths: []string{"middleware.rate_limit.backend"},
+		},
+		{
+			name: "管理接口不能公开",
+			modify: func(c *config.Config) {
+				c.Server.Admin.Enable = true
+				c.Auth.Enable = true
+				c.Auth.JWT.Secret = "secret"
+				c.Auth.PublicPaths = []string{"/livez", "/debug/"}
+				c.Auth.PublicMethods = []string{"/admin.v1.Admin/SetLogLevel", "/admin.*", "/helloworld.v1.Greeter/*"}
+			},
+			paths: []string{"auth.public_paths[1]", "auth.public_methods[0]", "auth.public_methods[1]"},
+		},
+	}
+
+	// 执行测试，通过 Config.Validate 确认规则已经注册
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			tc.modify(cfg)
+
+			var paths []string
+			var invalid config.ValidationErrors
+			if errors.As(cfg.Validate(), &invalid) {
+				for _, e := range invalid {
+					paths = append(paths, e.Path)
+				}
+			}
+			if !slices.Equal(paths, tc.paths) {
+				t.Errorf("错误路径不匹配: 期望=%v, 实际=%v", tc.paths, paths)
+			}
+		})
+	}
+}
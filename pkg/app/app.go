@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/costa92/go-protoc/pkg/health"
 	"github.com/costa92/go-protoc/pkg/log"
 	"golang.org/x/sync/errgroup"
 )
@@ -20,6 +21,7 @@ type Server interface {
 type App struct {
 	servers []Server
 	name    string
+	health  *health.Registry
 }
 
 // NewApp 创建一个新的 App 实例
@@ -27,9 +29,15 @@ func NewApp(name string, servers ...Server) *App {
 	return &App{
 		servers: servers,
 		name:    name,
+		health:  health.Default(),
 	}
 }
 
+// Health 返回应用使用的健康检查注册表
+func (a *App) Health() *health.Registry {
+	return a.health
+}
+
 // AddServer 向 App 添加一个服务
 func (a *App) AddServer(server Server) {
 	a.servers = append(a.servers, server)
@@ -59,6 +67,9 @@ func (a *App) Start(ctx context.Context) error {
 func (a *App) Stop(ctx context.Context) error {
 	log.Infof("正在关闭应用 %s", a.name)
 
+	// 在停止任何服务之前先将就绪状态切换为 NOT_SERVING，使负载均衡器尽快摘除流量
+	a.health.Shutdown()
+
 	// 创建一个错误组，用于并发管理服务关闭
 	g, ctx := errgroup.WithContext(ctx)
 
@@ -2,11 +2,15 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 
+	"github.com/costa92/go-protoc/pkg/health"
 	"github.com/costa92/go-protoc/pkg/log"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 // GRPCServer 是对 grpc.Server 的包装，实现了 Server 接口
@@ -14,15 +18,33 @@ type GRPCServer struct {
 	server   *grpc.Server
 	listener net.Listener
 	name     string
+	health   *health.Registry
+	serving  atomic.Bool // 标记服务器是否正在提供服务
 }
 
 // NewGRPCServer 创建一个新的 GRPCServer 实例
+// 服务器会自动注册标准的 grpc.health.v1.Health 服务，并向默认健康检查注册表注册自身的就绪检查
+// admin.v1.Admin 管理服务需要通过 RegisterAdminService 显式注册
 func NewGRPCServer(name string, listener net.Listener, opts ...grpc.ServerOption) *GRPCServer {
-	return &GRPCServer{
+	s := &GRPCServer{
 		server:   grpc.NewServer(opts...),
 		listener: listener,
 		name:     name,
+		health:   health.Default(),
 	}
+
+	// 注册 grpc.health.v1.Health 服务
+	healthpb.RegisterHealthServer(s.server, health.NewGRPCService(s.health, s.hasService))
+
+	// 注册自身的就绪检查
+	s.health.RegisterReadiness(name, health.CheckerFunc(func(context.Context) error {
+		if !s.serving.Load() {
+			return errors.New("gRPC 服务器未在运行")
+		}
+		return nil
+	}))
+
+	return s
 }
 
 // Server 返回底层的 grpc.Server 实例
@@ -30,15 +52,36 @@ func (s *GRPCServer) Server() *grpc.Server {
 	return s.server
 }
 
+// SetListener 设置 gRPC 服务器的监听器，必须在 Start 之前调用
+func (s *GRPCServer) SetListener(listener net.Listener) {
+	s.listener = listener
+}
+
+// Health 返回服务器使用的健康检查注册表
+func (s *GRPCServer) Health() *health.Registry {
+	return s.health
+}
+
+// hasService 判断指定的服务是否已注册到 gRPC 服务器
+func (s *GRPCServer) hasService(service string) bool {
+	_, ok := s.server.GetServiceInfo()[service]
+	return ok
+}
+
 // Start 实现 Server 接口的 Start 方法
 func (s *GRPCServer) Start(ctx context.Context) error {
+	if s.listener == nil {
+		return fmt.Errorf("gRPC 服务器 %s 未设置监听器", s.name)
+	}
 	log.Infof("gRPC 服务器 %s 正在监听 %s", s.name, s.listener.Addr().String())
 
 	// 创建一个 channel 用于接收服务器退出信号
 	errCh := make(chan error, 1)
 
 	// 在后台启动 gRPC 服务器
+	s.serving.Store(true)
 	go func() {
+		defer s.serving.Store(false)
 		if err := s.server.Serve(s.listener); err != nil {
 			errCh <- fmt.Errorf("gRPC 服务器 %s 失败: %v", s.name, err)
 		}
@@ -57,6 +100,7 @@ func (s *GRPCServer) Start(ctx context.Context) error {
 // Stop 实现 Server 接口的 Stop 方法
 func (s *GRPCServer) Stop(ctx context.Context) error {
 	log.Infof("正在关闭 gRPC 服务器 %s", s.name)
+	s.serving.Store(false)
 
 	// 创建一个通道来跟踪 GracefulStop 的完成
 	done := make(chan struct{})
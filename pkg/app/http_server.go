@@ -7,9 +7,12 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/costa92/go-protoc/pkg/health"
 	"github.com/costa92/go-protoc/pkg/log"
+	httpmiddleware "github.com/costa92/go-protoc/pkg/middleware/http"
 	"github.com/costa92/go-protoc/pkg/response"
 	"github.com/gorilla/mux"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -23,6 +26,8 @@ type HTTPServer struct {
 	name         string
 	mu           sync.Mutex // 保护路由注册的并发安全
 	gatewayAdded bool       // 标记是否已添加 gRPC-Gateway 作为默认处理器
+	health       *health.Registry
+	serving      atomic.Bool // 标记服务器是否正在提供服务
 }
 
 // NewHTTPServer 创建一个新的 HTTPServer 实例
@@ -34,8 +39,19 @@ func NewHTTPServer(name, addr string, middlewares ...mux.MiddlewareFunc) *HTTPSe
 		router.Use(mw)
 	}
 
-	// 创建 gRPC-Gateway mux
-	gwmux := runtime.NewServeMux()
+	// 创建 gRPC-Gateway mux，请求在转发给服务实现之前先按 AuthMiddleware 放入上下文的 guard 认证，
+	// 再按 AuthzMiddleware 放入上下文的授权器授权，按 LimiterMiddleware 放入上下文的限流器限流，
+	// 最后按 proto 中的校验规则校验，请求 ID 作为元数据转发
+	gwmux := runtime.NewServeMux(append(
+		httpmiddleware.GatewayRouteOptions(),
+		runtime.WithMiddlewares(
+			httpmiddleware.GatewayAuthMiddleware(),
+			httpmiddleware.GatewayAuthzMiddleware(),
+			httpmiddleware.GatewayRateLimitMiddleware(),
+			httpmiddleware.GatewayValidationMiddleware(),
+		),
+		httpmiddleware.GatewayRequestIDMetadata(),
+	)...)
 	response.Setup(gwmux)
 
 	httpServer := &HTTPServer{
@@ -48,8 +64,17 @@ func NewHTTPServer(name, addr string, middlewares ...mux.MiddlewareFunc) *HTTPSe
 		gatewayMux:   gwmux,
 		name:         name,
 		gatewayAdded: false,
+		health:       health.Default(),
 	}
 
+	// 注册自身的就绪检查
+	httpServer.health.RegisterReadiness(name, health.CheckerFunc(func(context.Context) error {
+		if !httpServer.serving.Load() {
+			return errors.New("HTTP 服务器未在运行")
+		}
+		return nil
+	}))
+
 	// 注册健康检查和调试路由
 	httpServer.registerDebugHandlers()
 
@@ -66,6 +91,11 @@ func (s *HTTPServer) GatewayMux() *runtime.ServeMux {
 	return s.gatewayMux
 }
 
+// Health 返回服务器使用的健康检查注册表
+func (s *HTTPServer) Health() *health.Registry {
+	return s.health
+}
+
 // AddRoute 添加一个新的 HTTP 路由
 // 此方法确保路由在 gRPC-Gateway 的 catch-all 路由之前添加
 // path: 路由路径
@@ -98,7 +128,7 @@ func (s *HTTPServer) FinalizeRoutes() {
 
 	if !s.gatewayAdded {
 		// 注册 gRPC-Gateway 路由作为默认处理器（始终放在最后）
-		s.router.PathPrefix("/").Handler(s.gatewayMux)
+		s.router.PathPrefix("/").Handler(s.gatewayMux).Name(httpmiddleware.GatewayRouteName)
 		s.gatewayAdded = true
 		log.Infow("已注册 gRPC-Gateway 作为默认处理器")
 	} else {
@@ -113,11 +143,13 @@ func (s *HTTPServer) Start(ctx context.Context) error {
 		s.FinalizeRoutes()
 	}
 
-	log.Infof("HTTP 服务器 %s 正在监听 %s", s.name, s.Addr)
+	log.Infof("HTTP 服务器 %s 正在监听 %s (TLS: %t)", s.name, s.Addr, s.TLSConfig != nil)
 
 	// 在后台启动 HTTP 服务器
+	s.serving.Store(true)
 	go func() {
-		if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		defer s.serving.Store(false)
+		if err := s.listenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Errorf("HTTP 服务器 %s 失败: %v", s.name, err)
 		}
 	}()
@@ -127,9 +159,19 @@ func (s *HTTPServer) Start(ctx context.Context) error {
 	return ctx.Err()
 }
 
+// listenAndServe 根据是否设置了 TLSConfig 选择以 HTTPS 或 HTTP 方式提供服务
+// 证书由 TLSConfig 提供，因此不需要传入证书文件路径
+func (s *HTTPServer) listenAndServe() error {
+	if s.TLSConfig != nil {
+		return s.ListenAndServeTLS("", "")
+	}
+	return s.ListenAndServe()
+}
+
 // Stop 实现 Server 接口的 Stop 方法
 func (s *HTTPServer) Stop(ctx context.Context) error {
 	log.Infof("正在关闭 HTTP 服务器 %s", s.name)
+	s.serving.Store(false)
 	if err := s.Shutdown(ctx); err != nil {
 		return fmt.Errorf("HTTP 服务器 %s 关闭失败: %v", s.name, err)
 	}
@@ -140,7 +182,11 @@ func (s *HTTPServer) Stop(ctx context.Context) error {
 // registerDebugHandlers 注册调试处理器
 func (s *HTTPServer) registerDebugHandlers() {
 	// 注册健康检查路由
-	s.AddRoute("/healthz", s.handleHealthCheck, "GET")
+	// /healthz 为兼容保留，语义与 /livez 相同
+	s.router.Handle("/livez", s.health.Handler(health.Liveness)).Methods("GET")
+	s.router.Handle("/readyz", s.health.Handler(health.Readiness)).Methods("GET")
+	s.router.Handle("/healthz", s.health.Handler(health.Liveness)).Methods("GET")
+	log.Infow("已注册健康检查路由", "paths", []string{"/livez", "/readyz", "/healthz"})
 
 	// 注册 pprof 路由
 	// 注意：使用 gorilla/mux 注册 pprof 路由需要单独为每个处理器注册路由
@@ -171,10 +217,3 @@ func (s *HTTPServer) registerDebugHandlers() {
 
 	log.Infow("已注册 pprof 调试路由", "path", "/debug/pprof/")
 }
-
-// handleHealthCheck 处理健康检查请求
-func (s *HTTPServer) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	log.Infow("health check")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
-}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	adminv1 "github.com/costa92/go-protoc/pkg/api/admin/v1"
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/response"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// LogLevelPath 是查看和修改日志级别的调试路由
+const LogLevelPath = "/debug/loglevel"
+
+// RegisterLogLevelRoute 注册 GET/PUT /debug/loglevel 路由，需要在 FinalizeRoutes 之前调用
+// 该路由可以修改服务的日志级别，只应在启用认证或者通过网络策略限制访问时注册
+func (s *HTTPServer) RegisterLogLevelRoute() {
+	s.AddRoute(LogLevelPath, handleLogLevel, http.MethodGet, http.MethodPut)
+}
+
+// RegisterAdminService 注册 admin.v1.Admin 服务，需要在 Start 之前调用
+// 该服务可以修改服务的日志级别，只应在启用认证或者通过网络策略限制访问时注册
+func (s *GRPCServer) RegisterAdminService() {
+	adminv1.RegisterAdminServer(s.server, &adminService{})
+	log.Infow("已注册管理服务", "server", s.name, "service", adminv1.Admin_ServiceDesc.ServiceName)
+}
+
+// setLogLevelRequest 是 PUT /debug/loglevel 的请求体
+type setLogLevelRequest struct {
+	// Logger 是日志记录器名称，为空时修改全局级别
+	Logger string `json:"logger"`
+	// Level 是日志级别，为空时移除该日志记录器的级别覆盖
+	Level string `json:"level"`
+	// TTL 是临时设置的有效期，如 10m，为空表示永久设置
+	TTL string `json:"ttl"`
+}
+
+// handleLogLevel 处理 GET/PUT /debug/loglevel
+// GET 返回当前的日志级别，PUT 修改全局级别或命名日志记录器的级别，返回修改后的日志级别
+func handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		response.WriteSuccess(w, log.CurrentLevels(), "")
+		return
+	}
+
+	var req setLogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.WriteBadRequest(w, "请求体格式错误", err)
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			response.WriteBadRequest(w, "无效的 TTL", err)
+			return
+		}
+	}
+	if err := log.SetLoggerLevel(req.Logger, req.Level, ttl); err != nil {
+		response.WriteBadRequest(w, err.Error(), err)
+		return
+	}
+
+	log.Infow("日志级别已修改", "logger", req.Logger, "level", req.Level, "ttl", ttl)
+	response.WriteSuccess(w, log.CurrentLevels(), "")
+}
+
+// adminService 实现 admin.v1.Admin 服务
+type adminService struct {
+	adminv1.UnimplementedAdminServer
+}
+
+// GetLogLevel 返回当前的日志级别
+func (s *adminService) GetLogLevel(context.Context, *adminv1.GetLogLevelRequest) (*adminv1.LogLevels, error) {
+	return toLogLevels(log.CurrentLevels()), nil
+}
+
+// SetLogLevel 修改全局级别或命名日志记录器的级别
+func (s *adminService) SetLogLevel(ctx context.Context, req *adminv1.SetLogLevelRequest) (*adminv1.LogLevels, error) {
+	var ttl time.Duration
+	if req.GetTtl() != nil {
+		ttl = req.GetTtl().AsDuration()
+	}
+	if err := log.SetLoggerLevel(req.GetLogger(), req.GetLevel(), ttl); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.FromContext(ctx).Infow("日志级别已修改", "logger", req.GetLogger(), "level", req.GetLevel(), "ttl", ttl)
+	return toLogLevels(log.CurrentLevels()), nil
+}
+
+// toLogLevels 将日志级别状态转换为 proto 消息
+func toLogLevels(st log.LevelStatus) *adminv1.LogLevels {
+	out := &adminv1.LogLevels{
+		Level:     st.Level,
+		ExpiresAt: toTimestamp(st.ExpiresAt),
+	}
+	for _, l := range st.Loggers {
+		out.Loggers = append(out.Loggers, &adminv1.LoggerLevel{
+			Name:      l.Name,
+			Level:     l.Level,
+			ExpiresAt: toTimestamp(l.ExpiresAt),
+		})
+	}
+	return out
+}
+
+// toTimestamp 将可选的时间转换为 Timestamp
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
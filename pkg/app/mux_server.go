@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+)
+
+// MuxServer 在单个监听器上同时提供 gRPC、gRPC-Gateway 和普通 HTTP 服务，实现了 Server 接口
+//
+// Content-Type 为 application/grpc 的 HTTP/2 请求（包括明文 h2c）会被路由到 grpc.Server，
+// 其余请求交给 HTTPServer 的路由器处理。gRPC 请求通过 grpc.Server.ServeHTTP 处理，
+// 因此拦截器和统计处理器与独立端口模式下的行为一致。
+type MuxServer struct {
+	server     *http.Server
+	listener   net.Listener
+	grpcServer *GRPCServer
+	httpServer *HTTPServer
+	name       string
+}
+
+// NewMuxServer 创建一个新的 MuxServer 实例
+// 传入的 grpcServer 和 httpServer 不应再单独添加到 App 中
+func NewMuxServer(name string, listener net.Listener, grpcServer *GRPCServer, httpServer *HTTPServer) *MuxServer {
+	s := &MuxServer{
+		listener:   listener,
+		grpcServer: grpcServer,
+		httpServer: httpServer,
+		name:       name,
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 60 * time.Second,
+		Protocols:         protocols,
+	}
+	return s
+}
+
+// ServeHTTP 根据请求类型将请求分发给 gRPC 服务器或 HTTP 路由器
+func (s *MuxServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isGRPCRequest(r) {
+		s.grpcServer.Server().ServeHTTP(w, r)
+		return
+	}
+	s.httpServer.Handler.ServeHTTP(w, r)
+}
+
+// isGRPCRequest 判断请求是否为 gRPC 请求
+func isGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// Start 实现 Server 接口的 Start 方法
+func (s *MuxServer) Start(ctx context.Context) error {
+	// 确保在启动前 gRPC-Gateway 已注册为默认处理器
+	if !s.httpServer.gatewayAdded {
+		s.httpServer.FinalizeRoutes()
+	}
+
+	// 单端口模式复用 HTTPServer 的 TLS 配置，gRPC 请求同样通过 TLS 提供服务
+	s.server.TLSConfig = s.httpServer.TLSConfig
+
+	log.Infof("单端口服务器 %s 正在监听 %s (gRPC + HTTP, TLS: %t)", s.name, s.listener.Addr().String(), s.server.TLSConfig != nil)
+
+	// 创建一个 channel 用于接收服务器退出信号
+	errCh := make(chan error, 1)
+
+	// 在后台启动服务器
+	s.markServing(true)
+	go func() {
+		defer s.markServing(false)
+		if err := s.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("单端口服务器 %s 失败: %v", s.name, err)
+		}
+		close(errCh)
+	}()
+
+	// 等待上下文取消或服务器错误
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serve 根据是否设置了 TLSConfig 选择以 HTTPS 或 HTTP 方式提供服务
+func (s *MuxServer) serve() error {
+	if s.server.TLSConfig != nil {
+		return s.server.ServeTLS(s.listener, "", "")
+	}
+	return s.server.Serve(s.listener)
+}
+
+// Stop 实现 Server 接口的 Stop 方法
+// 先停止接收新连接并等待进行中的 HTTP 和 gRPC 请求完成，超时后强制关闭所有连接
+func (s *MuxServer) Stop(ctx context.Context) error {
+	log.Infof("正在关闭单端口服务器 %s", s.name)
+	s.markServing(false)
+
+	// grpc.Server.GracefulStop 不支持通过 ServeHTTP 建立的连接，
+	// 因此由 http.Server.Shutdown 负责优雅关闭，之后再释放 gRPC 服务器的资源
+	if err := s.server.Shutdown(ctx); err != nil {
+		log.Warnf("单端口服务器 %s 优雅关闭超时，强制停止", s.name)
+		s.grpcServer.Server().Stop()
+		_ = s.server.Close()
+		return fmt.Errorf("单端口服务器 %s 关闭失败: %v", s.name, err)
+	}
+	s.grpcServer.Server().Stop()
+
+	log.Infof("单端口服务器 %s 已成功关闭", s.name)
+	return nil
+}
+
+// markServing 同步更新底层服务器的运行状态，供就绪检查使用
+func (s *MuxServer) markServing(serving bool) {
+	s.grpcServer.serving.Store(serving)
+	s.httpServer.serving.Store(serving)
+}
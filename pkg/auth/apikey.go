@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/clientip"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/log"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// TypeAPIKey 是 API Key 认证的认证方式
+	TypeAPIKey = "api_key"
+	// DefaultAPIKeyHeader 是默认读取 API Key 的请求头
+	DefaultAPIKeyHeader = "X-API-Key"
+	// apiKeyPrefix 是 API Key 的前缀，便于在日志和代码仓库中识别泄露的密钥
+	apiKeyPrefix = "gpk"
+	// apiKeyIDLen 是 API Key ID 的长度（十六进制字符数）
+	apiKeyIDLen = 16
+	// apiKeyFailureBackoff 是客户端 IP 校验 argon2id 哈希失败后不再计算 argon2id 的时间
+	// 认证失败的请求不经过限流，限制失败的慢哈希计算，避免用错误的密钥消耗 CPU
+	apiKeyFailureBackoff = time.Second
+	// maxAPIKeyFailures 是记录的校验失败的客户端 IP 数量上限，超过时清理已过期的记录
+	maxAPIKeyFailures = 1024
+	// maxConcurrentArgon2 是同时计算 argon2id 的数量上限，超过时等待
+	maxConcurrentArgon2 = 2
+)
+
+// API Key 的哈希算法
+const (
+	// HashSHA256 适合随机生成的高熵密钥，校验开销很小
+	HashSHA256 = "sha256"
+	// HashArgon2id 是抗暴力破解的慢哈希，校验通过后在内存中缓存，不会每次请求都重新计算
+	HashArgon2id = "argon2id"
+)
+
+// argon2id 的参数，使用 OWASP 推荐的配置
+const (
+	argon2Time    = 2
+	argon2Memory  = 19 * 1024
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// HashAlgorithms 返回支持的 API Key 哈希算法
+func HashAlgorithms() []string {
+	return []string{HashSHA256, HashArgon2id}
+}
+
+// GenerateAPIKey 生成新的 API Key，返回 ID 和完整的密钥
+// 完整的密钥形如 gpk_<id>_<secret>，只应在签发时展示一次，保存时使用 HashAPIKey 的结果
+func GenerateAPIKey() (id, key string, err error) {
+	idBytes := make([]byte, apiKeyIDLen/2)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("生成 API Key 失败: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("生成 API Key 失败: %w", err)
+	}
+	id = hex.EncodeToString(idBytes)
+	return id, apiKeyPrefix + "_" + id + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// parseAPIKey 返回 API Key 中的 ID
+func parseAPIKey(key string) (string, bool) {
+	prefix, rest, ok := strings.Cut(key, "_")
+	if !ok || prefix != apiKeyPrefix {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(id) != apiKeyIDLen || secret == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// HashAPIKey 使用 algorithm 计算 API Key 的哈希，结果是带有算法和参数的 PHC 格式字符串，algorithm 为空时使用 sha256
+func HashAPIKey(key, algorithm string) (string, error) {
+	b64 := base64.RawStdEncoding.EncodeToString
+	switch algorithm {
+	case HashSHA256, "":
+		sum := sha256.Sum256([]byte(key))
+		return "$" + HashSHA256 + "$" + hex.EncodeToString(sum[:]), nil
+	case HashArgon2id:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("生成盐值失败: %w", err)
+		}
+		hash := argon2.IDKey([]byte(key), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			HashArgon2id, argon2.Version, argon2Memory, argon2Time, argon2Threads, b64(salt), b64(hash)), nil
+	default:
+		return "", fmt.Errorf("不支持的哈希算法 %q", algorithm)
+	}
+}
+
+// verifyAPIKeyHash 判断 API Key 是否与 HashAPIKey 的结果匹配
+func verifyAPIKeyHash(key, hash string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	switch {
+	case len(parts) == 3 && parts[1] == HashSHA256:
+		want, err := hex.DecodeString(parts[2])
+		if err != nil {
+			return false, fmt.Errorf("无效的 sha256 哈希: %w", err)
+		}
+		sum := sha256.Sum256([]byte(key))
+		return subtle.ConstantTimeCompare(sum[:], want) == 1, nil
+	case len(parts) == 6 && parts[1] == HashArgon2id:
+		var version int
+		var memory, iterations uint32
+		var threads uint8
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return false, fmt.Errorf("不支持的 argon2id 版本 %q", parts[2])
+		}
+		if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+			return false, fmt.Errorf("无效的 argon2id 参数 %q", parts[3])
+		}
+		salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+		if err != nil {
+			return false, fmt.Errorf("无效的 argon2id 盐值: %w", err)
+		}
+		want, err := base64.RawStdEncoding.DecodeString(parts[5])
+		if err != nil || len(want) == 0 {
+			return false, fmt.Errorf("无效的 argon2id 哈希")
+		}
+		got := argon2.IDKey([]byte(key), salt, iterations, memory, threads, uint32(len(want)))
+		return subtle.ConstantTimeCompare(got, want) == 1, nil
+	default:
+		return false, fmt.Errorf("不支持的哈希格式")
+	}
+}
+
+// APIKeyOptions 定义 API Key 认证的配置
+type APIKeyOptions struct {
+	// Header 是读取 API Key 的请求头（gRPC 为元数据），为空时使用 DefaultAPIKeyHeader
+	Header string
+	// QueryParam 是读取 API Key 的 URL 查询参数，为空时不从查询参数读取
+	// 查询参数可能出现在代理和浏览器的日志中，只应在客户端无法设置请求头时使用
+	QueryParam string
+	// Store 是保存 API Key 的存储
+	Store APIKeyStore
+}
+
+// APIKey 是校验请求头或查询参数中 API Key 的认证器
+type APIKey struct {
+	opts APIKeyOptions
+	// verified 缓存校验通过的 API Key 的 sha256 摘要，键为 ID，避免每次请求都计算 argon2id
+	verified sync.Map
+	// argon2Slots 限制同时计算 argon2id 的数量
+	argon2Slots chan struct{}
+	// failuresMu 保护 failures
+	failuresMu sync.Mutex
+	// failures 记录客户端 IP 最近一次 argon2id 校验失败的时间，不记录无法确定客户端 IP 的请求
+	failures map[netip.Addr]time.Time
+	// now 返回当前时间，测试时可以替换
+	now func() time.Time
+}
+
+// verifiedKey 是校验通过的 API Key
+type verifiedKey struct {
+	// hash 是校验时存储中的哈希，哈希变化后缓存失效
+	hash   string
+	digest [sha256.Size]byte
+}
+
+// NewAPIKey 创建一个新的 API Key 认证器
+func NewAPIKey(opts APIKeyOptions) (*APIKey, error) {
+	if opts.Store == nil {
+		return nil, fmt.Errorf("API Key 存储不能为空")
+	}
+	if opts.Header == "" {
+		opts.Header = DefaultAPIKeyHeader
+	}
+	return &APIKey{
+		opts:        opts,
+		argon2Slots: make(chan struct{}, maxConcurrentArgon2),
+		failures:    make(map[netip.Addr]time.Time),
+		now:         time.Now,
+	}, nil
+}
+
+// credential 返回请求携带的 API Key，请求头优先于查询参数
+func (a *APIKey) credential(req Request) string {
+	if req.Header != nil {
+		for _, v := range req.Header(a.opts.Header) {
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		}
+	}
+	if a.opts.QueryParam != "" && req.Query != nil {
+		for _, v := range req.Query(a.opts.QueryParam) {
+			if v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// Authenticate 实现 Authenticator 接口，校验通过后记录 API Key 的最近使用时间
+func (a *APIKey) Authenticate(ctx context.Context, req Request) (*Principal, error) {
+	key := a.credential(req)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	id, ok := parseAPIKey(key)
+	if !ok {
+		return nil, invalidToken("API Key 格式错误")
+	}
+
+	record, err := a.opts.Store.Get(ctx, id)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, invalidToken("API Key %s 不存在", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: 查询 API Key 失败: %v", apierrors.ErrUnauthorized, err)
+	}
+	// 已吊销的 API Key 不需要计算哈希，返回的错误与校验失败相同
+	if !record.RevokedAt.IsZero() {
+		return nil, invalidToken("API Key %s 已于 %s 吊销", id, record.RevokedAt.Format(time.RFC3339))
+	}
+	if err := a.verify(ctx, id, key, record.Hash); err != nil {
+		return nil, invalidToken("API Key %s 校验失败: %v", id, err)
+	}
+
+	now := a.now()
+	if !record.ExpiresAt.IsZero() && !now.Before(record.ExpiresAt) {
+		return nil, fmt.Errorf("%w: API Key %s 已于 %s 过期", apierrors.ErrTokenExpired, id, record.ExpiresAt.Format(time.RFC3339))
+	}
+	if err := a.opts.Store.Touch(ctx, id, now); err != nil {
+		log.FromContextNamed(ctx, loggerName).Warnw("记录 API Key 最近使用时间失败", "id", id, "error", err)
+	}
+
+	return &Principal{
+		Subject:         id,
+		Type:            TypeAPIKey,
+		Scopes:          record.Scopes,
+		ExpiresAt:       record.ExpiresAt,
+		Claims:          map[string]any{"name": record.Name},
+		RateLimitPolicy: record.RateLimitPolicy,
+	}, nil
+}
+
+// verify 校验 API Key 与存储中的哈希是否匹配，校验通过的结果按 ID 缓存
+// 同时计算的 argon2id 不超过 maxConcurrentArgon2 个，客户端 IP 在 apiKeyFailureBackoff 内校验 argon2id 失败过时不再计算，直接返回错误
+func (a *APIKey) verify(ctx context.Context, id, key, hash string) error {
+	digest := sha256.Sum256([]byte(key))
+	if v, ok := a.verified.Load(id); ok {
+		cached := v.(verifiedKey)
+		if cached.hash == hash && subtle.ConstantTimeCompare(cached.digest[:], digest[:]) == 1 {
+			return nil
+		}
+	}
+
+	slow := strings.HasPrefix(hash, "$"+HashArgon2id+"$")
+	addr, _ := clientip.FromContext(ctx)
+	if slow {
+		select {
+		case a.argon2Slots <- struct{}{}:
+			defer func() { <-a.argon2Slots }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		// 等待期间同一客户端的其他请求可能已经校验失败
+		if a.backingOff(addr) {
+			return fmt.Errorf("客户端 %s 最近校验失败，%s 内不再计算 argon2id", addr, apiKeyFailureBackoff)
+		}
+	}
+	ok, err := verifyAPIKeyHash(key, hash)
+	if err == nil && !ok {
+		err = errors.New("密钥不匹配")
+	}
+	if err != nil {
+		if slow {
+			a.recordFailure(addr)
+		}
+		return err
+	}
+	a.verified.Store(id, verifiedKey{hash: hash, digest: digest})
+	return nil
+}
+
+// backingOff 判断客户端 IP 是否在 apiKeyFailureBackoff 内校验 argon2id 哈希失败过
+// 无法确定客户端 IP 时（如 Unix 套接字或上下文中没有客户端 IP）不等待，避免这些请求共享同一个计数而互相影响
+func (a *APIKey) backingOff(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	a.failuresMu.Lock()
+	defer a.failuresMu.Unlock()
+	at, ok := a.failures[addr]
+	return ok && a.now().Sub(at) < apiKeyFailureBackoff
+}
+
+// recordFailure 记录客户端 IP 校验 argon2id 哈希失败的时间
+func (a *APIKey) recordFailure(addr netip.Addr) {
+	if !addr.IsValid() {
+		return
+	}
+	a.failuresMu.Lock()
+	defer a.failuresMu.Unlock()
+	now := a.now()
+	if len(a.failures) >= maxAPIKeyFailures {
+		for k, at := range a.failures {
+			if now.Sub(at) >= apiKeyFailureBackoff {
+				delete(a.failures, k)
+			}
+		}
+	}
+	a.failures[addr] = now
+}
+
+var _ Authenticator = (*APIKey)(nil)
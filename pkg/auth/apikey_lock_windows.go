@@ -0,0 +1,8 @@
+//go:build windows
+
+package auth
+
+// lockFile 在 Windows 上不加锁，同一进程内的写入仍由 FileAPIKeyStore 的互斥锁保护
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLastUsedFlushInterval 是 FileAPIKeyStore 把最近使用时间写回文件的默认间隔
+const DefaultLastUsedFlushInterval = time.Minute
+
+// ErrAPIKeyNotFound 表示 API Key 不存在
+var ErrAPIKeyNotFound = errors.New("API Key 不存在")
+
+// APIKeyRecord 是保存的 API Key，只保存哈希，不保存原始密钥
+type APIKeyRecord struct {
+	ID string `yaml:"id"`
+	// Name 是 API Key 的名称，如合作方名称
+	Name string `yaml:"name"`
+	// Hash 是 HashAPIKey 的结果
+	Hash string `yaml:"hash"`
+	// Scopes 是 API Key 授予的权限范围
+	Scopes []string `yaml:"scopes,omitempty"`
+	// RateLimitPolicy 是绑定的限流策略名称，为空时按路由和方法匹配限流策略
+	RateLimitPolicy string    `yaml:"rate_limit_policy,omitempty"`
+	CreatedAt       time.Time `yaml:"created_at"`
+	// ExpiresAt 是过期时间，为零值时不过期
+	ExpiresAt  time.Time `yaml:"expires_at,omitempty"`
+	RevokedAt  time.Time `yaml:"revoked_at,omitempty"`
+	LastUsedAt time.Time `yaml:"last_used_at,omitempty"`
+}
+
+// APIKeyStore 是保存 API Key 的存储
+type APIKeyStore interface {
+	// Get 返回 ID 对应的 API Key，不存在时返回 ErrAPIKeyNotFound
+	Get(ctx context.Context, id string) (*APIKeyRecord, error)
+	// List 返回所有 API Key，包括已吊销和已过期的
+	List(ctx context.Context) ([]*APIKeyRecord, error)
+	// Create 保存新的 API Key
+	Create(ctx context.Context, record *APIKeyRecord) error
+	// Revoke 吊销 API Key，不存在时返回 ErrAPIKeyNotFound
+	Revoke(ctx context.Context, id string, at time.Time) error
+	// Touch 记录 API Key 的最近使用时间，存储可以延迟持久化
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// apiKeyFile 是 API Key 文件的格式
+type apiKeyFile struct {
+	Keys []*APIKeyRecord `yaml:"keys"`
+}
+
+// FileAPIKeyStore 是保存在 YAML 文件中的 API Key 存储
+// 修改 API Key 时持有 <path>.lock 文件的建议锁，重新读取文件后再写回，
+// 因此可以与 apiserver apikey 命令同时修改同一个文件，不会覆盖对方的修改
+// 最近使用时间先记录在内存中，每隔 DefaultLastUsedFlushInterval 写回文件
+// FileAPIKeyStore 实现了 app.Server 接口，可以直接添加到 App 中定期写回最近使用时间
+type FileAPIKeyStore struct {
+	path string
+
+	mu   sync.RWMutex
+	keys map[string]*APIKeyRecord
+	// lastUsed 是还没有写回文件的最近使用时间
+	lastUsed map[string]time.Time
+
+	// writeMu 保证同一进程内同一时间只有一次文件写入，进程之间由文件锁保证
+	writeMu sync.Mutex
+	// beforeWrite 在读取文件之后、写回之前调用，用于测试并发修改
+	beforeWrite func()
+}
+
+// NewFileAPIKeyStore 创建一个新的 FileAPIKeyStore 实例并加载文件，文件不存在时在第一次写入时创建
+func NewFileAPIKeyStore(path string) (*FileAPIKeyStore, error) {
+	s := &FileAPIKeyStore{path: path, lastUsed: make(map[string]time.Time)}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload 重新加载文件，文件无效时保持原来的内容并返回错误
+func (s *FileAPIKeyStore) Reload() error {
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.keys = indexAPIKeys(f.Keys)
+	s.mu.Unlock()
+	return nil
+}
+
+// read 读取并解析文件
+func (s *FileAPIKeyStore) read() (*apiKeyFile, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &apiKeyFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 API Key 文件失败: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var f apiKeyFile
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("解析 API Key 文件失败: %w", err)
+	}
+	seen := make(map[string]bool, len(f.Keys))
+	for i, k := range f.Keys {
+		if k == nil || k.ID == "" || k.Hash == "" {
+			return nil, fmt.Errorf("API Key 文件的 keys[%d] 缺少 id 或 hash", i)
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("API Key 文件中的 ID %q 重复", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return &f, nil
+}
+
+// index 按 ID 索引 API Key
+func indexAPIKeys(records []*APIKeyRecord) map[string]*APIKeyRecord {
+	keys := make(map[string]*APIKeyRecord, len(records))
+	for _, r := range records {
+		keys[r.ID] = r
+	}
+	return keys
+}
+
+// Get 实现 APIKeyStore 接口
+func (s *FileAPIKeyStore) Get(ctx context.Context, id string) (*APIKeyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return s.withLastUsed(r), nil
+}
+
+// withLastUsed 返回合并了内存中最近使用时间的副本，调用方需要持有读锁
+func (s *FileAPIKeyStore) withLastUsed(r *APIKeyRecord) *APIKeyRecord {
+	c := *r
+	if t, ok := s.lastUsed[r.ID]; ok && t.After(c.LastUsedAt) {
+		c.LastUsedAt = t
+	}
+	return &c
+}
+
+// List 实现 APIKeyStore 接口，按创建时间排序
+func (s *FileAPIKeyStore) List(ctx context.Context) ([]*APIKeyRecord, error) {
+	s.mu.RLock()
+	records := make([]*APIKeyRecord, 0, len(s.keys))
+	for _, r := range s.keys {
+		records = append(records, s.withLastUsed(r))
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(records, func(a, b *APIKeyRecord) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return records, nil
+}
+
+// Create 实现 APIKeyStore 接口
+func (s *FileAPIKeyStore) Create(ctx context.Context, record *APIKeyRecord) error {
+	return s.update(func(f *apiKeyFile) error {
+		if slices.ContainsFunc(f.Keys, func(r *APIKeyRecord) bool { return r.ID == record.ID }) {
+			return fmt.Errorf("API Key ID %q 已存在", record.ID)
+		}
+		c := *record
+		f.Keys = append(f.Keys, &c)
+		return nil
+	})
+}
+
+// Revoke 实现 APIKeyStore 接口，已吊销的 API Key 保留原来的吊销时间
+func (s *FileAPIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	return s.update(func(f *apiKeyFile) error {
+		i := slices.IndexFunc(f.Keys, func(r *APIKeyRecord) bool { return r.ID == id })
+		if i < 0 {
+			return ErrAPIKeyNotFound
+		}
+		if f.Keys[i].RevokedAt.IsZero() {
+			f.Keys[i].RevokedAt = at.UTC()
+		}
+		return nil
+	})
+}
+
+// Touch 实现 APIKeyStore 接口，最近使用时间在 Flush 时写回文件
+func (s *FileAPIKeyStore) Touch(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.lastUsed[id]; !ok || at.After(t) {
+		s.lastUsed[id] = at.UTC()
+	}
+	return nil
+}
+
+// Flush 把内存中的最近使用时间写回文件
+func (s *FileAPIKeyStore) Flush() error {
+	s.mu.RLock()
+	pending := len(s.lastUsed)
+	s.mu.RUnlock()
+	if pending == 0 {
+		return nil
+	}
+	return s.update(func(f *apiKeyFile) error { return nil })
+}
+
+// update 在文件锁内重新读取文件，合并内存中的最近使用时间后调用 fn 修改，然后原子地写回文件
+func (s *FileAPIKeyStore) update(fn func(f *apiKeyFile) error) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("锁定 API Key 文件失败: %w", err)
+	}
+	defer unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	flushed := make(map[string]time.Time, len(s.lastUsed))
+	for id, t := range s.lastUsed {
+		flushed[id] = t
+	}
+	s.mu.RUnlock()
+	for _, r := range f.Keys {
+		if t, ok := flushed[r.ID]; ok && t.After(r.LastUsedAt) {
+			r.LastUsedAt = t
+		}
+	}
+
+	if err := fn(f); err != nil {
+		return err
+	}
+	if s.beforeWrite != nil {
+		s.beforeWrite()
+	}
+	if err := s.write(f); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = indexAPIKeys(f.Keys)
+	for id, t := range flushed {
+		// 写回期间更新的最近使用时间留到下一次写回
+		if !s.lastUsed[id].After(t) {
+			delete(s.lastUsed, id)
+		}
+	}
+	return nil
+}
+
+// write 先写入同一目录下的临时文件再重命名，避免读取到写了一半的文件
+func (s *FileAPIKeyStore) write(f *apiKeyFile) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("编码 API Key 文件失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("写入 API Key 文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 API Key 文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 API Key 文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("写入 API Key 文件失败: %w", err)
+	}
+	return nil
+}
+
+// Start 实现 app.Server 接口，定期把最近使用时间写回文件，阻塞直到上下文取消
+func (s *FileAPIKeyStore) Start(ctx context.Context) error {
+	ticker := time.NewTicker(DefaultLastUsedFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Named(loggerName).Warnw("写回 API Key 最近使用时间失败", "path", s.path, "error", err)
+			}
+		}
+	}
+}
+
+// Stop 实现 app.Server 接口，写回还没有保存的最近使用时间
+func (s *FileAPIKeyStore) Stop(ctx context.Context) error {
+	return s.Flush()
+}
+
+var _ APIKeyStore = (*FileAPIKeyStore)(nil)
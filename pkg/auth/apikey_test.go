@@ -0,0 +1,337 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/clientip"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+)
+
+// issueKey 签发测试用的 API Key 并保存到 store，modify 可以修改保存的记录
+func issueKey(t *testing.T, store APIKeyStore, hash string, modify func(r *APIKeyRecord)) (string, string) {
+	t.Helper()
+	id, key, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("生成 API Key 失败: %v", err)
+	}
+	hashed, err := HashAPIKey(key, hash)
+	if err != nil {
+		t.Fatalf("计算 API Key 哈希失败: %v", err)
+	}
+	record := &APIKeyRecord{ID: id, Name: "partner", Hash: hashed, Scopes: []string{"greeter:read"}, RateLimitPolicy: "partner", CreatedAt: time.Now()}
+	if modify != nil {
+		modify(record)
+	}
+	if err := store.Create(context.Background(), record); err != nil {
+		t.Fatalf("保存 API Key 失败: %v", err)
+	}
+	return id, key
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	store, err := NewFileAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.yaml"))
+	if err != nil {
+		t.Fatalf("创建 API Key 存储失败: %v", err)
+	}
+	authn, err := NewAPIKey(APIKeyOptions{QueryParam: "api_key", Store: store})
+	if err != nil {
+		t.Fatalf("创建 API Key 认证器失败: %v", err)
+	}
+
+	sha256ID, sha256Key := issueKey(t, store, HashSHA256, nil)
+	argonID, argonKey := issueKey(t, store, HashArgon2id, nil)
+	_, revokedKey := issueKey(t, store, HashSHA256, func(r *APIKeyRecord) { r.RevokedAt = time.Now() })
+	_, expiredKey := issueKey(t, store, HashSHA256, func(r *APIKeyRecord) { r.ExpiresAt = time.Now().Add(-time.Minute) })
+	_, unknownKey, _ := GenerateAPIKey()
+
+	// 定义测试用例
+	testCases := []struct {
+		name    string
+		header  string
+		query   string
+		subject string
+		err     error
+	}{
+		{name: "请求头 sha256", header: sha256Key, subject: sha256ID},
+		{name: "请求头 argon2id", header: argonKey, subject: argonID},
+		{name: "argon2id 使用缓存", header: argonKey, subject: argonID},
+		{name: "查询参数", query: sha256Key, subject: sha256ID},
+		{name: "没有凭证", err: ErrNoCredentials},
+		{name: "格式错误", header: "not-a-key", err: apierrors.ErrInvalidToken},
+		{name: "不存在", header: unknownKey, err: apierrors.ErrInvalidToken},
+		{name: "密钥错误", header: sha256Key[:len(sha256Key)-4] + "AAAA", err: apierrors.ErrInvalidToken},
+		{name: "ID 与密钥不匹配", header: strings.Replace(sha256Key, sha256ID, argonID, 1), err: apierrors.ErrInvalidToken},
+		{name: "已吊销", header: revokedKey, err: apierrors.ErrInvalidToken},
+		{name: "已过期", header: expiredKey, err: apierrors.ErrTokenExpired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := Request{
+				Header: func(name string) []string {
+					if name == DefaultAPIKeyHeader && tc.header != "" {
+						return []string{tc.header}
+					}
+					return nil
+				},
+				Query: func(name string) []string {
+					if name == "api_key" && tc.query != "" {
+						return []string{tc.query}
+					}
+					return nil
+				},
+			}
+			p, err := authn.Authenticate(context.Background(), req)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("错误 = %v，期望 %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("认证失败: %v", err)
+			}
+			if p.Subject != tc.subject || p.Type != TypeAPIKey || !p.HasScope("greeter:read") || p.RateLimitPolicy != "partner" {
+				t.Errorf("调用方 = %+v", p)
+			}
+		})
+	}
+}
+
+func TestFileAPIKeyStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "api_keys.yaml")
+	store, err := NewFileAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("创建 API Key 存储失败: %v", err)
+	}
+	id, _ := issueKey(t, store, HashSHA256, nil)
+
+	// 最近使用时间在写回之前只保存在内存中
+	used := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := store.Touch(ctx, id, used); err != nil {
+		t.Fatalf("记录最近使用时间失败: %v", err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "last_used_at") {
+		t.Fatalf("写回之前文件中已有最近使用时间:\n%s", data)
+	}
+	if r, _ := store.Get(ctx, id); !r.LastUsedAt.Equal(used) {
+		t.Errorf("最近使用时间 = %v，期望 %v", r.LastUsedAt, used)
+	}
+
+	// 另一个进程（如 apiserver apikey 命令）修改文件后写回不会覆盖它的修改
+	other, err := NewFileAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("创建 API Key 存储失败: %v", err)
+	}
+	otherID, _ := issueKey(t, other, HashSHA256, nil)
+	if err := store.Flush(); err != nil {
+		t.Fatalf("写回最近使用时间失败: %v", err)
+	}
+	reloaded, err := NewFileAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("重新加载 API Key 文件失败: %v", err)
+	}
+	if r, err := reloaded.Get(ctx, id); err != nil || !r.LastUsedAt.Equal(used) {
+		t.Errorf("写回后的最近使用时间 = %v（%v），期望 %v", r, err, used)
+	}
+	if _, err := reloaded.Get(ctx, otherID); err != nil {
+		t.Errorf("另一个进程签发的 API Key 丢失: %v", err)
+	}
+
+	// 吊销后重新加载生效，不存在的 ID 返回 ErrAPIKeyNotFound
+	if err := other.Revoke(ctx, id, time.Now()); err != nil {
+		t.Fatalf("吊销 API Key 失败: %v", err)
+	}
+	if err := other.Revoke(ctx, "missing", time.Now()); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("吊销不存在的 API Key 错误 = %v，期望 ErrAPIKeyNotFound", err)
+	}
+	if err := store.Reload(); err != nil {
+		t.Fatalf("重新加载 API Key 文件失败: %v", err)
+	}
+	if r, _ := store.Get(ctx, id); r.RevokedAt.IsZero() {
+		t.Error("重新加载后 API Key 没有吊销")
+	}
+
+	// 文件无效时保持原来的内容
+	if err := os.WriteFile(path, []byte("keys:\n  - id: x\n    unknown: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err == nil {
+		t.Error("无效的 API Key 文件没有返回错误")
+	}
+	if records, _ := store.List(ctx); len(records) != 2 {
+		t.Errorf("API Key 数量 = %d，期望 2", len(records))
+	}
+}
+
+func TestChain(t *testing.T) {
+	store, err := NewFileAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.yaml"))
+	if err != nil {
+		t.Fatalf("创建 API Key 存储失败: %v", err)
+	}
+	apiKey, err := NewAPIKey(APIKeyOptions{Store: store})
+	if err != nil {
+		t.Fatalf("创建 API Key 认证器失败: %v", err)
+	}
+	secret := []byte("secret")
+	jwt, err := NewJWT(JWTOptions{Secret: string(secret)})
+	if err != nil {
+		t.Fatalf("创建 JWT 认证器失败: %v", err)
+	}
+	authn := Chain(jwt, apiKey)
+	id, key := issueKey(t, store, HashSHA256, nil)
+	token := signToken(t, "HS256", "", secret, map[string]any{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+
+	// 定义测试用例
+	testCases := []struct {
+		name    string
+		headers map[string]string
+		subject string
+		err     error
+	}{
+		{name: "JWT", headers: map[string]string{"Authorization": "Bearer " + token}, subject: "alice"},
+		{name: "API Key", headers: map[string]string{DefaultAPIKeyHeader: key}, subject: id},
+		{name: "JWT 无效时不尝试 API Key", headers: map[string]string{"Authorization": "Bearer x.y.z", DefaultAPIKeyHeader: key}, err: apierrors.ErrInvalidToken},
+		{name: "没有凭证", err: ErrNoCredentials},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := Request{Header: func(name string) []string {
+				if v, ok := tc.headers[name]; ok {
+					return []string{v}
+				}
+				return nil
+			}}
+			p, err := authn.Authenticate(context.Background(), req)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("错误 = %v，期望 %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("认证失败: %v", err)
+			}
+			if p.Subject != tc.subject {
+				t.Errorf("Subject = %q，期望 %q", p.Subject, tc.subject)
+			}
+		})
+	}
+}
+
+func TestFileAPIKeyStoreConcurrentRevoke(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "api_keys.yaml")
+	server, err := NewFileAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("创建 API Key 存储失败: %v", err)
+	}
+	id, _ := issueKey(t, server, HashSHA256, nil)
+	cli, err := NewFileAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("创建 API Key 存储失败: %v", err)
+	}
+
+	// 服务器读取文件之后、写回之前，apiserver apikey revoke 吊销同一个 API Key
+	revoked := make(chan error, 1)
+	server.beforeWrite = func() {
+		server.beforeWrite = nil
+		go func() { revoked <- cli.Revoke(ctx, id, time.Now()) }()
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err := server.Touch(ctx, id, time.Now()); err != nil {
+		t.Fatalf("记录最近使用时间失败: %v", err)
+	}
+	if err := server.Flush(); err != nil {
+		t.Fatalf("写回最近使用时间失败: %v", err)
+	}
+	if err := <-revoked; err != nil {
+		t.Fatalf("吊销 API Key 失败: %v", err)
+	}
+
+	reloaded, err := NewFileAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("重新加载 API Key 文件失败: %v", err)
+	}
+	r, err := reloaded.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("获取 API Key 失败: %v", err)
+	}
+	if r.RevokedAt.IsZero() {
+		t.Error("写回最近使用时间覆盖了吊销")
+	}
+	if r.LastUsedAt.IsZero() {
+		t.Error("吊销覆盖了最近使用时间")
+	}
+}
+
+func TestAPIKeyFailureBackoff(t *testing.T) {
+	store, err := NewFileAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.yaml"))
+	if err != nil {
+		t.Fatalf("创建 API Key 存储失败: %v", err)
+	}
+	authn, err := NewAPIKey(APIKeyOptions{Store: store})
+	if err != nil {
+		t.Fatalf("创建 API Key 认证器失败: %v", err)
+	}
+	now := time.Now()
+	authn.now = func() time.Time { return now }
+
+	idA, keyA := issueKey(t, store, HashArgon2id, nil)
+	idB, keyB := issueKey(t, store, HashArgon2id, nil)
+	idC, keyC := issueKey(t, store, HashArgon2id, nil)
+	idD, keyD := issueKey(t, store, HashArgon2id, nil)
+	_, revokedKey := issueKey(t, store, HashArgon2id, func(r *APIKeyRecord) { r.RevokedAt = now })
+
+	// 定义测试步骤，按顺序执行
+	steps := []struct {
+		name    string
+		ip      string
+		key     string
+		advance time.Duration
+		subject string
+	}{
+		{name: "已吊销的 API Key 不计算哈希", ip: "192.0.2.1", key: revokedKey},
+		{name: "吊销不触发等待", ip: "192.0.2.1", key: keyA, subject: idA},
+		{name: "密钥错误", ip: "192.0.2.2", key: keyB[:len(keyB)-4] + "AAAA"},
+		{name: "失败后同一客户端不再计算哈希", ip: "192.0.2.2", key: keyB, advance: apiKeyFailureBackoff / 2},
+		{name: "已缓存的密钥不受影响", ip: "192.0.2.2", key: keyA, subject: idA},
+		{name: "其他客户端不受影响", ip: "192.0.2.3", key: keyC, subject: idC},
+		{name: "无法确定客户端 IP 时密钥错误", key: keyD[:len(keyD)-4] + "AAAA"},
+		{name: "无法确定客户端 IP 时不等待", key: keyD, subject: idD},
+		{name: "等待结束后重新计算哈希", ip: "192.0.2.2", key: keyB, advance: apiKeyFailureBackoff / 2, subject: idB},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			now = now.Add(step.advance)
+			ctx := context.Background()
+			if step.ip != "" {
+				ctx = clientip.NewContext(ctx, netip.MustParseAddr(step.ip))
+			}
+			req := Request{Header: func(name string) []string { return []string{step.key} }}
+			p, err := authn.Authenticate(ctx, req)
+			if step.subject == "" {
+				if !errors.Is(err, apierrors.ErrInvalidToken) {
+					t.Fatalf("错误 = %v，期望 ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("认证失败: %v", err)
+			}
+			if p.Subject != step.subject {
+				t.Errorf("Subject = %q，期望 %q", p.Subject, step.subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package auth 提供请求认证：Authenticator 根据请求携带的凭证确认调用方身份，
+// Guard 决定哪些请求需要认证，HTTP 中间件和 gRPC 拦截器把认证得到的 Principal 放入请求上下文
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	authv1 "github.com/costa92/go-protoc/pkg/api/auth/v1"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/health"
+	"github.com/costa92/go-protoc/pkg/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// loggerName 是本包日志记录器的名称
+const loggerName = "auth"
+
+// ErrNoCredentials 表示请求没有携带认证器支持的凭证，对应 errors.ErrUnauthorized
+var ErrNoCredentials = fmt.Errorf("%w: 请求没有携带凭证", apierrors.ErrUnauthorized)
+
+// Principal 是通过认证的调用方
+type Principal struct {
+	// Subject 是调用方的唯一标识，如 JWT 的 sub
+	Subject string
+	// Type 是认证方式，如 jwt
+	Type string
+	// Issuer 是凭证的签发者
+	Issuer string
+	// Audience 是凭证的受众
+	Audience []string
+	// Scopes 是凭证授予的权限范围
+	Scopes []string
+	// ExpiresAt 是凭证的过期时间，为零值时不过期
+	ExpiresAt time.Time
+	// Claims 是凭证中的全部声明
+	Claims map[string]any
+	// RateLimitPolicy 是凭证绑定的限流策略名称，为空时按路由和方法匹配限流策略
+	RateLimitPolicy string
+}
+
+// HasScope 判断调用方是否拥有 scope 权限范围
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// principalKey 是存放 Principal 的上下文键
+type principalKey struct{}
+
+// NewContext 返回携带 p 的新上下文，同时把 p.Subject 作为日志的 subject 字段
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalKey{}, p)
+	return log.ContextWithSubject(ctx, p.Subject)
+}
+
+// FromContext 返回上下文中通过认证的调用方
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Request 是认证需要的请求信息
+type Request struct {
+	// Header 返回请求头（gRPC 为元数据）的值，名称不区分大小写
+	Header func(name string) []string
+	// Query 返回 URL 查询参数的值，gRPC 调用为 nil
+	Query func(name string) []string
+}
+
+// BearerToken 返回 Authorization 请求头中的 Bearer 令牌，没有时返回空字符串
+func BearerToken(req Request) string {
+	if req.Header == nil {
+		return ""
+	}
+	for _, v := range req.Header("Authorization") {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(v), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// Authenticator 根据请求携带的凭证确认调用方身份
+type Authenticator interface {
+	// Authenticate 返回通过认证的调用方
+	// 请求没有携带支持的凭证时返回 ErrNoCredentials，凭证无效时返回包装了 errors.ErrInvalidToken 等认证错误的错误
+	Authenticate(ctx context.Context, req Request) (*Principal, error)
+}
+
+// Chain 返回依次尝试 authenticators 的认证器
+// 认证器返回 ErrNoCredentials 时尝试下一个，所有认证器都没有找到凭证时返回 ErrNoCredentials
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+// chain 是依次尝试的认证器
+type chain []Authenticator
+
+// Authenticate 实现 Authenticator 接口
+func (c chain) Authenticate(ctx context.Context, req Request) (*Principal, error) {
+	for _, authenticator := range c {
+		p, err := authenticator.Authenticate(ctx, req)
+		if !errors.Is(err, ErrNoCredentials) {
+			return p, err
+		}
+	}
+	return nil, ErrNoCredentials
+}
+
+// Options 定义哪些请求需要认证
+type Options struct {
+	Enable bool
+	// PublicMethods 是不需要认证的 gRPC 全方法名，只支持末尾的 *
+	// 方法也可以通过 (auth.v1.public) 选项标记为公开，gRPC-Gateway 路由使用对应的 gRPC 方法判断
+	PublicMethods []string
+	// PublicPaths 是不需要认证的 HTTP 路径前缀，如健康检查和指标接口
+	PublicPaths []string
+}
+
+// guardState 是 Guard 的配置
+type guardState struct {
+	opts          Options
+	authenticator Authenticator
+}
+
+// Guard 决定请求是否需要认证并使用 Authenticator 认证，支持在运行时更新配置
+type Guard struct {
+	state atomic.Pointer[guardState]
+}
+
+// NewGuard 创建一个新的 Guard 实例，authenticator 为 nil 时所有需要认证的请求都会被拒绝
+func NewGuard(opts Options, authenticator Authenticator) *Guard {
+	g := &Guard{}
+	g.Update(opts, authenticator)
+	return g
+}
+
+// Update 更新配置，对之后的请求生效
+func (g *Guard) Update(opts Options, authenticator Authenticator) {
+	g.state.Store(&guardState{opts: opts, authenticator: authenticator})
+}
+
+// Enabled 返回是否启用认证
+func (g *Guard) Enabled() bool {
+	return g.state.Load().opts.Enable
+}
+
+// PublicPath 判断 HTTP 路径是否不需要认证
+func (g *Guard) PublicPath(path string) bool {
+	for _, prefix := range g.state.Load().opts.PublicPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicMethod 判断 gRPC 方法是否不需要认证，grpc.health.v1.Health 服务始终公开
+func (g *Guard) PublicMethod(method string) bool {
+	if health.IsGRPCMethod(method) {
+		return true
+	}
+	for _, m := range g.state.Load().opts.PublicMethods {
+		if prefix, ok := strings.CutSuffix(m, "*"); ok {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		} else if m == method {
+			return true
+		}
+	}
+	return publicOption(method)
+}
+
+// Authenticate 认证请求，失败时返回 *errors.Error 形式的认证错误，原始错误记录在调试日志中
+func (g *Guard) Authenticate(ctx context.Context, req Request) (*Principal, error) {
+	authenticator := g.state.Load().authenticator
+	if authenticator == nil {
+		return nil, apierrors.ErrUnauthorized
+	}
+
+	p, err := authenticator.Authenticate(ctx, req)
+	if err == nil {
+		return p, nil
+	}
+	log.FromContextNamed(ctx, loggerName).Debugw("认证失败", "error", err)
+	var e *apierrors.Error
+	if errors.As(err, &e) {
+		return nil, e
+	}
+	return nil, apierrors.ErrUnauthorized
+}
+
+// publicMethods 缓存 gRPC 方法的 (auth.v1.public) 选项，描述符在运行时不会变化
+var publicMethods sync.Map
+
+// publicOption 返回 gRPC 方法是否通过 (auth.v1.public) 选项标记为公开
+func publicOption(method string) bool {
+	if v, ok := publicMethods.Load(method); ok {
+		return v.(bool)
+	}
+
+	public := false
+	service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if ok {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+		if sd, isService := desc.(protoreflect.ServiceDescriptor); err == nil && isService {
+			if md := sd.Methods().ByName(protoreflect.Name(name)); md != nil {
+				public, _ = proto.GetExtension(md.Options(), authv1.E_Public).(bool)
+			}
+		}
+	}
+	publicMethods.Store(method, public)
+	return public
+}
@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+)
+
+const (
+	// DefaultJWKSCacheTTL 是从 URL 获取的 JWKS 默认的缓存时间
+	DefaultJWKSCacheTTL = 5 * time.Minute
+	// jwksMinRefreshInterval 是重新获取 JWKS 的最短间隔，避免伪造的 kid 或不可用的 JWKS 地址导致频繁请求
+	jwksMinRefreshInterval = 30 * time.Second
+	// jwksFetchTimeout 是获取 JWKS 的超时时间
+	jwksFetchTimeout = 10 * time.Second
+	// jwksMaxSize 是 JWKS 响应的最大字节数
+	jwksMaxSize = 1 << 20
+)
+
+// jwk 是解析后的 JSON Web Key
+type jwk struct {
+	kid string
+	alg string
+	// key 是 []byte（oct）、*rsa.PublicKey 或 *ecdsa.PublicKey
+	key any
+}
+
+// rawJWK 是 JSON Web Key 的 JSON 形式
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// oct
+	K string `json:"k"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS 解析 JWKS，跳过不用于签名的密钥
+func parseJWKS(data []byte) ([]jwk, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("解析 JWKS 失败: %w", err)
+	}
+
+	keys := make([]jwk, 0, len(set.Keys))
+	for i, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("解析 JWKS 的第 %d 个密钥（kid=%q）失败: %w", i, raw.Kid, err)
+		}
+		keys = append(keys, jwk{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	return keys, nil
+}
+
+// publicKey 返回验证签名使用的密钥
+func (k *rawJWK) publicKey() (any, error) {
+	switch k.Kty {
+	case "oct":
+		secret, err := decodeSegment(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("无效的 k")
+		}
+		return secret, nil
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, fmt.Errorf("无效的 n")
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("无效的 e")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线 %q", k.Crv)
+		}
+		x, errX := decodeSegment(k.X)
+		y, errY := decodeSegment(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("无效的 x 或 y")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("公钥不在曲线 %s 上", k.Crv)
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型 %q", k.Kty)
+	}
+}
+
+// decodeSegment 解码 base64url 编码的数据，兼容带填充的形式
+func decodeSegment(s string) ([]byte, error) {
+	if l := len(s) % 4; l > 0 {
+		s += "===="[l:]
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
+
+// loadJWKSFile 从文件加载 JWKS
+func loadJWKSFile(path string) ([]jwk, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 JWKS 文件失败: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// remoteJWKS 是从 URL 获取并缓存的 JWKS
+// 缓存过期或遇到未知 kid 时在后台重新获取，两次获取至少间隔 minInterval，同一时间只有一次获取
+// 获取期间和获取失败时继续使用已缓存的密钥，只有还没有密钥或遇到未知 kid 的请求等待获取完成
+type remoteJWKS struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	// minInterval 是两次获取之间的最短间隔，测试时可以修改
+	minInterval time.Duration
+
+	mu          sync.Mutex
+	keys        []jwk
+	err         error
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing 在获取期间不为 nil，获取完成后关闭
+	refreshing chan struct{}
+}
+
+// newRemoteJWKS 创建一个新的 remoteJWKS 实例，JWKS 在第一次使用时获取
+func newRemoteJWKS(url string, ttl time.Duration, client *http.Client) *remoteJWKS {
+	if ttl <= 0 {
+		ttl = DefaultJWKSCacheTTL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: jwksFetchTimeout}
+	}
+	return &remoteJWKS{url: url, ttl: ttl, client: client, minInterval: jwksMinRefreshInterval}
+}
+
+// get 返回缓存的密钥，必要时重新获取
+func (r *remoteJWKS) get(ctx context.Context, kid string) ([]jwk, error) {
+	r.mu.Lock()
+	now := time.Now()
+	keys, lastErr := r.keys, r.err
+	usable := keys != nil && (kid == "" || hasKid(keys, kid))
+	if usable && now.Sub(r.fetchedAt) <= r.ttl {
+		r.mu.Unlock()
+		return keys, nil
+	}
+	done := r.refreshing
+	if done == nil && now.Sub(r.attemptedAt) > r.minInterval {
+		r.attemptedAt = now
+		done = make(chan struct{})
+		r.refreshing = done
+		go r.refresh(done)
+	}
+	r.mu.Unlock()
+
+	// 缓存过期但有可用的密钥，或者还在最短间隔内时不等待
+	if usable || done == nil {
+		if keys == nil {
+			return nil, lastErr
+		}
+		return keys, nil
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if keys == nil {
+			return nil, ctx.Err()
+		}
+		return keys, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys == nil {
+		return nil, r.err
+	}
+	return r.keys, nil
+}
+
+// refresh 获取 JWKS 并更新缓存，完成后关闭 done，获取失败时保留已缓存的密钥
+func (r *remoteJWKS) refresh(done chan struct{}) {
+	defer close(done)
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	keys, err := r.fetch(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// 最短间隔从获取结束时开始计算，获取很慢时也不会连续获取
+	r.refreshing, r.attemptedAt = nil, time.Now()
+	if err != nil {
+		r.err = err
+		if r.keys != nil {
+			log.Named(loggerName).Warnw("获取 JWKS 失败，继续使用已缓存的密钥", "url", r.url, "error", err)
+		}
+		return
+	}
+	r.keys, r.err, r.fetchedAt = keys, nil, time.Now()
+}
+
+// fetch 从 URL 获取 JWKS
+func (r *remoteJWKS) fetch(ctx context.Context) ([]jwk, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建 JWKS 请求失败: %w", err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取 JWKS 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取 JWKS 失败: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取 JWKS 失败: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// hasKid 判断密钥中是否有 kid
+func hasKid(keys []jwk, kid string) bool {
+	for _, k := range keys {
+		if k.kid == kid {
+			return true
+		}
+	}
+	return false
+}
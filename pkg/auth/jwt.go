@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // 注册 SHA-256
+	_ "crypto/sha512" // 注册 SHA-384 和 SHA-512
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+)
+
+// TypeJWT 是 JWT 认证的 Principal.Type
+const TypeJWT = "jwt"
+
+// jwtAlgorithm 描述一种 JWT 签名算法
+type jwtAlgorithm struct {
+	hash crypto.Hash
+	// family 是算法族: HS、RS、PS、ES
+	family string
+	// curveBits 是 ES 算法要求的曲线位数
+	curveBits int
+}
+
+// jwtAlgorithms 是支持的 JWT 签名算法
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"HS256": {hash: crypto.SHA256, family: "HS"},
+	"HS384": {hash: crypto.SHA384, family: "HS"},
+	"HS512": {hash: crypto.SHA512, family: "HS"},
+	"RS256": {hash: crypto.SHA256, family: "RS"},
+	"RS384": {hash: crypto.SHA384, family: "RS"},
+	"RS512": {hash: crypto.SHA512, family: "RS"},
+	"PS256": {hash: crypto.SHA256, family: "PS"},
+	"PS384": {hash: crypto.SHA384, family: "PS"},
+	"PS512": {hash: crypto.SHA512, family: "PS"},
+	"ES256": {hash: crypto.SHA256, family: "ES", curveBits: 256},
+	"ES384": {hash: crypto.SHA384, family: "ES", curveBits: 384},
+	"ES512": {hash: crypto.SHA512, family: "ES", curveBits: 521},
+}
+
+// JWTAlgorithms 返回支持的 JWT 签名算法名称
+func JWTAlgorithms() []string {
+	names := make([]string, 0, len(jwtAlgorithms))
+	for name := range jwtAlgorithms {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// JWTOptions 定义 JWT 认证的配置，Secret、JWKSFile 和 JWKSURL 至少需要设置一个
+type JWTOptions struct {
+	// Issuer 是要求的签发者（iss），为空时不校验
+	Issuer string
+	// Audience 是接受的受众，令牌的 aud 包含其中任意一个即可，为空时不校验
+	Audience []string
+	// ClockSkew 是校验 exp 和 nbf 时允许的时钟偏差
+	ClockSkew time.Duration
+	// Algorithms 是允许的签名算法，为空时允许所有支持的算法
+	Algorithms []string
+	// Secret 是 HS 算法使用的共享密钥
+	Secret string
+	// JWKSFile 是本地 JWKS 文件的路径
+	JWKSFile string
+	// JWKSURL 是 JWKS 的地址，获取的密钥缓存 JWKSCacheTTL，遇到未知的 kid 时提前刷新
+	JWKSURL string
+	// JWKSCacheTTL 是 JWKS 的缓存时间，为 0 时使用 DefaultJWKSCacheTTL
+	JWKSCacheTTL time.Duration
+	// HTTPClient 是获取 JWKS 使用的客户端，为 nil 时使用超时 10 秒的客户端
+	HTTPClient *http.Client
+}
+
+// JWT 是校验 Authorization: Bearer 请求头中 JWT 的认证器
+type JWT struct {
+	opts   JWTOptions
+	static []jwk
+	remote *remoteJWKS
+	// now 返回当前时间，测试时可以替换
+	now func() time.Time
+}
+
+// NewJWT 创建一个新的 JWT 认证器，JWKSFile 在创建时加载，JWKSURL 在第一次使用时获取
+func NewJWT(opts JWTOptions) (*JWT, error) {
+	if opts.Secret == "" && opts.JWKSFile == "" && opts.JWKSURL == "" {
+		return nil, fmt.Errorf("secret、jwks_file 和 jwks_url 至少需要设置一个")
+	}
+	for _, alg := range opts.Algorithms {
+		if _, ok := jwtAlgorithms[alg]; !ok {
+			return nil, fmt.Errorf("不支持的签名算法 %q", alg)
+		}
+	}
+
+	j := &JWT{opts: opts, now: time.Now}
+	if opts.Secret != "" {
+		j.static = append(j.static, jwk{key: []byte(opts.Secret)})
+	}
+	if opts.JWKSFile != "" {
+		keys, err := loadJWKSFile(opts.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		j.static = append(j.static, keys...)
+	}
+	if opts.JWKSURL != "" {
+		j.remote = newRemoteJWKS(opts.JWKSURL, opts.JWKSCacheTTL, opts.HTTPClient)
+	}
+	return j, nil
+}
+
+// Authenticate 实现 Authenticator 接口
+func (j *JWT) Authenticate(ctx context.Context, req Request) (*Principal, error) {
+	token := BearerToken(req)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	return j.Verify(ctx, token)
+}
+
+// jwtHeader 是 JWT 的头部
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// invalidToken 返回包装了 errors.ErrInvalidToken 的错误，reason 只记录在日志中，不返回给客户端
+func invalidToken(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", apierrors.ErrInvalidToken, fmt.Sprintf(format, args...))
+}
+
+// Verify 校验 JWT 的签名和声明，返回令牌对应的调用方
+func (j *JWT) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("令牌格式错误")
+	}
+
+	var header jwtHeader
+	if err := decodeJSONSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("解析头部失败: %v", err)
+	}
+	alg, ok := jwtAlgorithms[header.Alg]
+	if !ok || (len(j.opts.Algorithms) > 0 && !slices.Contains(j.opts.Algorithms, header.Alg)) {
+		return nil, invalidToken("不允许的签名算法 %q", header.Alg)
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, invalidToken("解码签名失败: %v", err)
+	}
+
+	keys, err := j.keys(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range keys {
+		// 没有 kid 的密钥（如 Secret）可以校验任意 kid 的令牌
+		if (header.Kid != "" && k.kid != "" && k.kid != header.Kid) || (k.alg != "" && k.alg != header.Alg) {
+			continue
+		}
+		if verifySignature(alg, k.key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, invalidToken("签名校验失败（alg=%s, kid=%q）", header.Alg, header.Kid)
+	}
+
+	claims := map[string]any{}
+	if err := decodeJSONSegment(parts[1], &claims); err != nil {
+		return nil, invalidToken("解析声明失败: %v", err)
+	}
+	return j.principal(claims)
+}
+
+// keys 返回可以用于校验的密钥
+func (j *JWT) keys(ctx context.Context, kid string) ([]jwk, error) {
+	if j.remote == nil || (kid != "" && hasKid(j.static, kid)) {
+		return j.static, nil
+	}
+	remote, err := j.remote.get(ctx, kid)
+	if err != nil {
+		if len(j.static) > 0 {
+			return j.static, nil
+		}
+		return nil, fmt.Errorf("%w: %v", apierrors.ErrUnauthorized, err)
+	}
+	return append(slices.Clip(j.static), remote...), nil
+}
+
+// principal 校验声明并返回调用方
+func (j *JWT) principal(claims map[string]any) (*Principal, error) {
+	now := j.now()
+	exp, ok, err := numericDate(claims, "exp")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, invalidToken("缺少 exp")
+	}
+	if !now.Before(exp.Add(j.opts.ClockSkew)) {
+		return nil, fmt.Errorf("%w: 令牌已于 %s 过期", apierrors.ErrTokenExpired, exp.Format(time.RFC3339))
+	}
+	nbf, ok, err := numericDate(claims, "nbf")
+	if err != nil {
+		return nil, err
+	}
+	if ok && now.Add(j.opts.ClockSkew).Before(nbf) {
+		return nil, invalidToken("令牌在 %s 之前无效", nbf.Format(time.RFC3339))
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if j.opts.Issuer != "" && issuer != j.opts.Issuer {
+		return nil, invalidToken("签发者 %q 不匹配", issuer)
+	}
+	audience, err := stringList(claims, "aud")
+	if err != nil {
+		return nil, err
+	}
+	if len(j.opts.Audience) > 0 && !slices.ContainsFunc(audience, func(aud string) bool {
+		return slices.Contains(j.opts.Audience, aud)
+	}) {
+		return nil, invalidToken("受众 %v 不匹配", audience)
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, invalidToken("缺少 sub")
+	}
+
+	// scope 是 RFC 8693 的空格分隔形式，scp 是部分签发者使用的数组形式
+	var scopes []string
+	if scope, ok := claims["scope"].(string); ok {
+		scopes = strings.Fields(scope)
+	} else if scopes, err = stringList(claims, "scp"); err != nil {
+		return nil, err
+	}
+
+	return &Principal{
+		Subject:   subject,
+		Type:      TypeJWT,
+		Issuer:    issuer,
+		Audience:  audience,
+		Scopes:    scopes,
+		ExpiresAt: exp,
+		Claims:    claims,
+	}, nil
+}
+
+// verifySignature 使用 key 校验签名，密钥类型与算法不匹配时返回 false
+func verifySignature(alg jwtAlgorithm, key any, signed, signature []byte) bool {
+	if alg.family == "HS" {
+		secret, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(alg.hash.New, secret)
+		mac.Write(signed)
+		return hmac.Equal(signature, mac.Sum(nil))
+	}
+
+	h := alg.hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch alg.family {
+		case "RS":
+			return rsa.VerifyPKCS1v15(pub, alg.hash, digest, signature) == nil
+		case "PS":
+			return rsa.VerifyPSS(pub, alg.hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		bits := pub.Curve.Params().BitSize
+		size := (bits + 7) / 8
+		if alg.family != "ES" || bits != alg.curveBits || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+// decodeJSONSegment 解码 base64url 编码的 JSON
+func decodeJSONSegment(segment string, v any) error {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// numericDate 返回声明中的时间，声明不存在时返回 false
+func numericDate(claims map[string]any, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false, invalidToken("%s 不是数字", name)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false, invalidToken("%s 不是数字", name)
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true, nil
+}
+
+// stringList 返回字符串或字符串数组形式的声明
+func stringList(claims map[string]any, name string) ([]string, error) {
+	switch v := claims[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, invalidToken("%s 必须是字符串数组", name)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	default:
+		return nil, invalidToken("%s 必须是字符串或字符串数组", name)
+	}
+}
+
+// 确保 JWT 实现了 Authenticator 接口
+var _ Authenticator = (*JWT)(nil)
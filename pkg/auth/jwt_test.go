@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+)
+
+// signToken 使用 alg 和 key 签发测试用的 JWT，key 是 []byte、*rsa.PrivateKey 或 *ecdsa.PrivateKey
+func signToken(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("编码 JWT 失败: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("RSA 签名失败: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("ECDSA 签名失败: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// testJWKS 返回包含 RSA 和 EC 公钥的 JWKS
+func testJWKS(rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) []byte {
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	data, _ := json.Marshal(map[string]any{"keys": []map[string]any{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "invalid"},
+	}})
+	return data
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 ECDSA 密钥失败: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 ECDSA 密钥失败: %v", err)
+	}
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, testJWKS(rsaKey, ecKey), 0o600); err != nil {
+		t.Fatalf("写入 JWKS 文件失败: %v", err)
+	}
+	secret := []byte("test-secret")
+
+	authn, err := NewJWT(JWTOptions{
+		Issuer:    "https://issuer.example.com",
+		Audience:  []string{"go-protoc"},
+		ClockSkew: time.Minute,
+		Secret:    string(secret),
+		JWKSFile:  jwksFile,
+	})
+	if err != nil {
+		t.Fatalf("创建 JWT 认证器失败: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	authn.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":   "alice",
+			"iss":   "https://issuer.example.com",
+			"aud":   "go-protoc",
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "greeter:read greeter:write",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	// 定义测试用例，err 为 nil 表示认证成功
+	testCases := []struct {
+		name  string
+		token string
+		err   *apierrors.Error
+	}{
+		{name: "HS256", token: signToken(t, "HS256", "", secret, claims(nil))},
+		{name: "RS256", token: signToken(t, "RS256", "rsa", rsaKey, claims(nil))},
+		{name: "ES256", token: signToken(t, "ES256", "ec", ecKey, claims(map[string]any{"aud": []string{"other", "go-protoc"}}))},
+		{name: "时钟偏差内过期", token: signToken(t, "HS256", "", secret, claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}))},
+		{name: "已过期", token: signToken(t, "HS256", "", secret, claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), err: apierrors.ErrTokenExpired},
+		{name: "缺少 exp", token: signToken(t, "HS256", "", secret, claims(map[string]any{"exp": nil})), err: apierrors.ErrInvalidToken},
+		{name: "尚未生效", token: signToken(t, "HS256", "", secret, claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), err: apierrors.ErrInvalidToken},
+		{name: "签发者不匹配", token: signToken(t, "HS256", "", secret, claims(map[string]any{"iss": "https://evil.example.com"})), err: apierrors.ErrInvalidToken},
+		{name: "受众不匹配", token: signToken(t, "HS256", "", secret, claims(map[string]any{"aud": "other"})), err: apierrors.ErrInvalidToken},
+		{name: "签名错误", token: signToken(t, "ES256", "ec", otherKey, claims(nil)), err: apierrors.ErrInvalidToken},
+		{name: "错误的密钥", token: signToken(t, "HS256", "", []byte("wrong"), claims(nil)), err: apierrors.ErrInvalidToken},
+		{name: "未知的 kid", token: signToken(t, "RS256", "unknown", rsaKey, claims(nil)), err: apierrors.ErrInvalidToken},
+		{name: "alg 为 none", token: signToken(t, "none", "", nil, claims(nil)), err: apierrors.ErrInvalidToken},
+		{name: "格式错误", token: "not-a-jwt", err: apierrors.ErrInvalidToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := Request{Header: func(name string) []string {
+				if name == "Authorization" {
+					return []string{"Bearer " + tc.token}
+				}
+				return nil
+			}}
+			p, err := authn.Authenticate(context.Background(), req)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("错误 = %v，期望 %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("认证失败: %v", err)
+			}
+			if p.Subject != "alice" || p.Type != TypeJWT || !p.HasScope("greeter:write") {
+				t.Errorf("调用方 = %+v", p)
+			}
+		})
+	}
+
+	if _, err := authn.Authenticate(context.Background(), Request{}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("没有凭证时错误 = %v，期望 ErrNoCredentials", err)
+	}
+}
+
+func TestJWTRemoteJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 ECDSA 密钥失败: %v", err)
+	}
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(testJWKS(rsaKey, ecKey))
+	}))
+	defer server.Close()
+
+	authn, err := NewJWT(JWTOptions{JWKSURL: server.URL, Algorithms: []string{"RS256"}})
+	if err != nil {
+		t.Fatalf("创建 JWT 认证器失败: %v", err)
+	}
+	claims := map[string]any{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()}
+
+	for i := 0; i < 3; i++ {
+		if _, err := authn.Verify(context.Background(), signToken(t, "RS256", "rsa", rsaKey, claims)); err != nil {
+			t.Fatalf("认证失败: %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("JWKS 获取次数 = %d，期望缓存后只获取 1 次", fetches.Load())
+	}
+
+	// 不在 Algorithms 中的算法即使密钥存在也被拒绝
+	if _, err := authn.Verify(context.Background(), signToken(t, "ES256", "ec", ecKey, claims)); !errors.Is(err, apierrors.ErrInvalidToken) {
+		t.Errorf("不允许的算法错误 = %v，期望 ErrInvalidToken", err)
+	}
+
+	// 未知 kid 触发的刷新受最短间隔限制
+	for i := 0; i < 3; i++ {
+		if _, err := authn.Verify(context.Background(), signToken(t, "RS256", "forged", rsaKey, claims)); !errors.Is(err, apierrors.ErrInvalidToken) {
+			t.Errorf("未知 kid 错误 = %v，期望 ErrInvalidToken", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("JWKS 获取次数 = %d，未知 kid 不应在最短间隔内重新获取", fetches.Load())
+	}
+}
+
+func TestRemoteJWKSUnavailable(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 ECDSA 密钥失败: %v", err)
+	}
+
+	// 第一次获取之后 JWKS 地址变慢并返回错误
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			time.Sleep(300 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(testJWKS(rsaKey, ecKey))
+	}))
+	defer server.Close()
+
+	remote := newRemoteJWKS(server.URL, time.Millisecond, nil)
+	remote.minInterval = 500 * time.Millisecond
+	if _, err := remote.get(context.Background(), "rsa"); err != nil {
+		t.Fatalf("获取 JWKS 失败: %v", err)
+	}
+	time.Sleep(510 * time.Millisecond)
+
+	// 缓存过期后不等待重新获取，继续使用已缓存的密钥
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		keys, err := remote.get(context.Background(), "rsa")
+		if err != nil || !hasKid(keys, "rsa") {
+			t.Fatalf("缓存过期后获取密钥失败: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("缓存过期后获取密钥耗时 %v，期望不等待重新获取", elapsed)
+	}
+
+	// 重新获取失败后在最短间隔内不再重试
+	time.Sleep(400 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		if _, err := remote.get(context.Background(), "rsa"); err != nil {
+			t.Fatalf("获取密钥失败: %v", err)
+		}
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("JWKS 获取次数 = %d，期望 2", fetches.Load())
+	}
+}
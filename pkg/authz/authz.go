@@ -0,0 +1,148 @@
+// Package authz 提供请求授权：授权策略声明角色拥有的 gRPC 方法和 HTTP 路由权限，
+// 并按调用方的 Subject、认证方式、权限范围和声明把角色授予调用方（RBAC 与 ABAC 结合）
+package authz
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/metrics"
+)
+
+// loggerName 是授权审计日志记录器的名称
+const loggerName = "authz"
+
+// 审计日志记录的决策
+const (
+	// AuditAll 记录所有决策
+	AuditAll = "all"
+	// AuditDeny 只记录拒绝决策
+	AuditDeny = "deny"
+	// AuditNone 不记录决策，试运行模式下的拒绝决策仍然记录
+	AuditNone = "none"
+)
+
+// AuditLevels 返回支持的审计日志级别
+func AuditLevels() []string {
+	return []string{AuditAll, AuditDeny, AuditNone}
+}
+
+// Resource 是被访问的资源
+type Resource struct {
+	// Method 是 gRPC 全方法名，gRPC-Gateway 路由为对应的 gRPC 方法，自定义 HTTP 路由为空
+	Method string
+	// HTTPMethod 是 HTTP 请求方法，gRPC 调用为空
+	HTTPMethod string
+	// Route 是匹配到的 HTTP 路由模板，如 /v1/hello/{name}，gRPC 调用为空
+	Route string
+}
+
+// Decision 是授权决策
+type Decision struct {
+	Allowed bool
+	// Roles 是调用方获得的角色
+	Roles []string
+	// Role 是允许访问的角色，拒绝时为空
+	Role string
+}
+
+// Options 定义授权的行为
+type Options struct {
+	Enable bool
+	// DryRun 为 true 时只记录拒绝决策而不拒绝请求，用于上线新策略前观察影响
+	DryRun bool
+	// Audit 是审计日志记录的决策：AuditAll、AuditDeny 或 AuditNone，为空时使用 AuditDeny
+	Audit string
+}
+
+// state 是授权器的配置
+type state struct {
+	opts   Options
+	policy *policy
+}
+
+// Authorizer 按授权策略决定调用方能否访问资源，支持在运行时更新配置和策略
+type Authorizer struct {
+	state atomic.Pointer[state]
+}
+
+// NewAuthorizer 创建一个新的 Authorizer 实例，policy 为 nil 时拒绝所有请求
+func NewAuthorizer(opts Options, p *Policy) (*Authorizer, error) {
+	a := &Authorizer{}
+	if err := a.Update(opts, p); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Update 更新配置和策略，对之后的请求生效，策略无效时保持原来的配置并返回错误
+func (a *Authorizer) Update(opts Options, p *Policy) error {
+	if p == nil {
+		p = &Policy{}
+	}
+	compiled, err := compile(p)
+	if err != nil {
+		return err
+	}
+	if opts.Audit == "" {
+		opts.Audit = AuditDeny
+	}
+	a.state.Store(&state{opts: opts, policy: compiled})
+	return nil
+}
+
+// Enabled 返回是否启用授权
+func (a *Authorizer) Enabled() bool {
+	return a.state.Load().opts.Enable
+}
+
+// Evaluate 返回调用方访问资源的授权决策，不记录审计日志
+func (a *Authorizer) Evaluate(p *auth.Principal, res Resource) Decision {
+	return a.state.Load().policy.evaluate(p, res)
+}
+
+// Authorize 判断调用方能否访问资源并记录审计日志，拒绝时返回 errors.ErrPermissionDenied
+// 未启用授权或试运行模式下总是返回 nil
+func (a *Authorizer) Authorize(ctx context.Context, p *auth.Principal, res Resource) error {
+	s := a.state.Load()
+	if !s.opts.Enable {
+		return nil
+	}
+
+	d := s.policy.evaluate(p, res)
+	decision := "allow"
+	if !d.Allowed {
+		decision = "deny"
+		if s.opts.DryRun {
+			decision = "dry_run_deny"
+		}
+	}
+	metrics.AuthzDecisions.WithLabelValues(decision).Inc()
+
+	if s.opts.Audit == AuditAll || (!d.Allowed && (s.opts.Audit == AuditDeny || s.opts.DryRun)) {
+		// 调用方的 subject 已经由认证中间件放入日志上下文
+		logger := log.FromContextNamed(ctx, loggerName)
+		keysAndValues := []interface{}{
+			"decision", decision,
+			"type", p.Type,
+			"grpc_method", res.Method,
+			"http_method", res.HTTPMethod,
+			"route", res.Route,
+			"roles", d.Roles,
+			"role", d.Role,
+		}
+		if d.Allowed {
+			logger.Infow("授权通过", keysAndValues...)
+		} else {
+			logger.Warnw("授权拒绝", keysAndValues...)
+		}
+	}
+
+	if d.Allowed || s.opts.DryRun {
+		return nil
+	}
+	return apierrors.ErrPermissionDenied
+}
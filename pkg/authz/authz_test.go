@@ -0,0 +1,165 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testPolicy = `
+roles:
+  - name: greeter
+    permissions:
+      - methods: [/helloworld.v1.Greeter/*]
+  - name: greeter-admin
+    permissions:
+      - methods: [/helloworld.v2.Greeter/SayHello]
+        routes: ["GET /v2/hello/{name}", "/users/*"]
+  - name: admin
+    permissions:
+      - methods: ["*"]
+        routes: ["*"]
+bindings:
+  - roles: [greeter]
+    subjects: ["*"]
+  - roles: [greeter-admin]
+    scopes: [greeter:admin]
+  - roles: [admin]
+    types: [jwt]
+    claims:
+      groups: [admins]
+`
+
+func TestAuthorizerEvaluate(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("解析授权策略失败: %v", err)
+	}
+	authorizer, err := NewAuthorizer(Options{Enable: true}, policy)
+	if err != nil {
+		t.Fatalf("创建授权器失败: %v", err)
+	}
+
+	alice := &auth.Principal{Subject: "alice", Type: auth.TypeJWT}
+	bob := &auth.Principal{Subject: "bob", Type: auth.TypeJWT, Scopes: []string{"greeter:admin"}}
+	root := &auth.Principal{Subject: "root", Type: auth.TypeJWT, Claims: map[string]any{"groups": []any{"dev", "admins"}}}
+
+	// 定义测试用例，role 为空表示拒绝
+	testCases := []struct {
+		name      string
+		principal *auth.Principal
+		res       Resource
+		role      string
+	}{
+		{name: "按方法前缀允许", principal: alice, res: Resource{Method: "/helloworld.v1.Greeter/SayHello"}, role: "greeter"},
+		{name: "没有权限的方法", principal: alice, res: Resource{Method: "/helloworld.v2.Greeter/SayHello"}},
+		{name: "按权限范围获得角色", principal: bob, res: Resource{Method: "/helloworld.v2.Greeter/SayHello"}, role: "greeter-admin"},
+		{name: "按 HTTP 方法和路由模板允许", principal: bob, res: Resource{HTTPMethod: "GET", Route: "/v2/hello/{name}"}, role: "greeter-admin"},
+		{name: "HTTP 方法不匹配", principal: bob, res: Resource{HTTPMethod: "DELETE", Route: "/v2/hello/{name}"}},
+		{name: "省略 HTTP 方法的路由前缀", principal: bob, res: Resource{HTTPMethod: "DELETE", Route: "/users/{id}"}, role: "greeter-admin"},
+		{name: "按声明获得角色", principal: root, res: Resource{HTTPMethod: "POST", Route: "/admin/users"}, role: "admin"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := authorizer.Evaluate(tc.principal, tc.res)
+			if d.Allowed != (tc.role != "") || d.Role != tc.role {
+				t.Errorf("决策 = %+v，期望角色 %q", d, tc.role)
+			}
+		})
+	}
+}
+
+func TestAuthorizerAuthorize(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("解析授权策略失败: %v", err)
+	}
+	authorizer, err := NewAuthorizer(Options{Enable: true}, policy)
+	if err != nil {
+		t.Fatalf("创建授权器失败: %v", err)
+	}
+	alice := &auth.Principal{Subject: "alice"}
+	denied := Resource{Method: "/helloworld.v2.Greeter/SayHello"}
+
+	if err := authorizer.Authorize(context.Background(), alice, denied); !errors.Is(err, apierrors.ErrPermissionDenied) {
+		t.Errorf("错误 = %v，期望 ErrPermissionDenied", err)
+	}
+
+	// 试运行模式下只记录拒绝决策
+	before := testutil.ToFloat64(metrics.AuthzDecisions.WithLabelValues("dry_run_deny"))
+	if err := authorizer.Update(Options{Enable: true, DryRun: true}, policy); err != nil {
+		t.Fatalf("更新授权配置失败: %v", err)
+	}
+	if err := authorizer.Authorize(context.Background(), alice, denied); err != nil {
+		t.Errorf("试运行模式不应拒绝请求: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.AuthzDecisions.WithLabelValues("dry_run_deny")) - before; got != 1 {
+		t.Errorf("dry_run_deny 决策数增加了 %v，期望 1", got)
+	}
+
+	// 无效的策略不会生效
+	if err := authorizer.Update(Options{Enable: true}, &Policy{Bindings: []Binding{{Roles: []string{"missing"}}}}); err == nil {
+		t.Error("期望无效的策略返回错误")
+	}
+	if err := authorizer.Authorize(context.Background(), alice, denied); err != nil {
+		t.Errorf("无效的策略不应替换当前配置: %v", err)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	_, err := ParsePolicy([]byte(`
+roles:
+  - name: greeter
+    permissions:
+      - methods: [helloworld.v1.Greeter/SayHello]
+        routes: ["FETCH /v1/hello", "v1/hello"]
+      - {}
+  - name: greeter
+bindings:
+  - roles: [missing]
+  - roles: [greeter]
+    subject: [alice]
+`))
+	if err == nil {
+		t.Fatal("期望无效的策略返回错误")
+	}
+	// 未知字段在解码时报告，其他错误一起报告
+	if !strings.Contains(err.Error(), "subject") {
+		t.Errorf("错误应该包含未知字段: %v", err)
+	}
+
+	_, err = ParsePolicy([]byte(`
+roles:
+  - name: greeter
+    permissions:
+      - methods: [helloworld.v1.Greeter/SayHello]
+        routes: ["FETCH /v1/hello", "v1/hello"]
+      - {}
+  - name: greeter
+bindings:
+  - roles: [missing]
+`))
+	if err == nil {
+		t.Fatal("期望无效的策略返回错误")
+	}
+	for _, path := range []string{
+		"roles[0].permissions[0].methods[0]",
+		"roles[0].permissions[0].routes[0]",
+		"roles[0].permissions[0].routes[1]",
+		"roles[0].permissions[1]",
+		"roles[1].name",
+		"bindings[0].roles[0]",
+		"bindings[0]:",
+	} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("错误应该包含 %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package authz
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy 是授权策略，声明角色拥有的权限以及哪些调用方获得角色
+type Policy struct {
+	// Roles 是角色及其权限
+	Roles []Role `yaml:"roles"`
+	// Bindings 把角色授予满足条件的调用方
+	Bindings []Binding `yaml:"bindings"`
+}
+
+// Role 是一组权限
+type Role struct {
+	Name        string       `yaml:"name"`
+	Permissions []Permission `yaml:"permissions"`
+}
+
+// Permission 允许调用匹配的 gRPC 方法或 HTTP 路由
+type Permission struct {
+	// Methods 是 gRPC 全方法名，只支持末尾的 *，gRPC-Gateway 路由同时按对应的 gRPC 方法匹配
+	Methods []string `yaml:"methods"`
+	// Routes 是 "HTTP 方法 路由模板" 形式的 HTTP 路由，如 "GET /v1/hello/{name}"
+	// 省略 HTTP 方法时匹配所有方法，路由模板只支持末尾的 *
+	Routes []string `yaml:"routes"`
+}
+
+// Binding 把角色授予满足所有条件的调用方，同一条件的多个值满足任意一个即可，至少需要设置一个条件
+type Binding struct {
+	Roles []string `yaml:"roles"`
+	// Subjects 是调用方的 Subject，* 匹配所有通过认证的调用方
+	Subjects []string `yaml:"subjects"`
+	// Types 是认证方式，如 jwt
+	Types []string `yaml:"types"`
+	// Scopes 是调用方拥有的权限范围
+	Scopes []string `yaml:"scopes"`
+	// Claims 是凭证中的声明，声明的值（或数组中的任意一个元素）等于给定的任意一个值即可
+	Claims map[string][]string `yaml:"claims"`
+}
+
+// ParsePolicy 解析 YAML 格式的授权策略，未知字段、重复的角色、引用不存在的角色等错误一起返回
+func ParsePolicy(data []byte) (*Policy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var p Policy
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("解析授权策略失败: %w", err)
+	}
+	if _, err := compile(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LoadPolicyFile 从文件加载授权策略
+func LoadPolicyFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取授权策略文件失败: %w", err)
+	}
+	return ParsePolicy(data)
+}
+
+// route 是预处理后的 HTTP 路由
+type route struct {
+	// method 为空时匹配所有 HTTP 方法
+	method   string
+	template string
+}
+
+// permission 是预处理后的权限
+type permission struct {
+	methods []string
+	routes  []route
+}
+
+// policy 是预处理后的授权策略
+type policy struct {
+	roles    map[string][]permission
+	bindings []Binding
+}
+
+// compile 校验并预处理授权策略，返回的错误包含所有问题
+func compile(p *Policy) (*policy, error) {
+	var errs []error
+	addf := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	compiled := &policy{roles: make(map[string][]permission, len(p.Roles)), bindings: p.Bindings}
+	for i, role := range p.Roles {
+		if role.Name == "" {
+			addf("roles[%d].name: 不能为空", i)
+			continue
+		}
+		if _, ok := compiled.roles[role.Name]; ok {
+			addf("roles[%d].name: 角色 %q 重复", i, role.Name)
+			continue
+		}
+
+		perms := make([]permission, 0, len(role.Permissions))
+		for j, perm := range role.Permissions {
+			path := fmt.Sprintf("roles[%d].permissions[%d]", i, j)
+			if len(perm.Methods) == 0 && len(perm.Routes) == 0 {
+				addf("%s: methods 和 routes 至少需要设置一个", path)
+			}
+			for k, m := range perm.Methods {
+				if m != "*" && !strings.HasPrefix(m, "/") {
+					addf("%s.methods[%d]: gRPC 方法 %q 必须以 / 开头，或使用 * 匹配所有方法", path, k, m)
+				}
+			}
+			compiledPerm := permission{methods: perm.Methods}
+			for k, r := range perm.Routes {
+				parsed, err := parseRoute(r)
+				if err != nil {
+					addf("%s.routes[%d]: %v", path, k, err)
+					continue
+				}
+				compiledPerm.routes = append(compiledPerm.routes, parsed)
+			}
+			perms = append(perms, compiledPerm)
+		}
+		compiled.roles[role.Name] = perms
+	}
+
+	for i, b := range p.Bindings {
+		if len(b.Roles) == 0 {
+			addf("bindings[%d].roles: 不能为空", i)
+		}
+		for j, name := range b.Roles {
+			if _, ok := compiled.roles[name]; !ok {
+				addf("bindings[%d].roles[%d]: 角色 %q 不存在", i, j, name)
+			}
+		}
+		if len(b.Subjects) == 0 && len(b.Types) == 0 && len(b.Scopes) == 0 && len(b.Claims) == 0 {
+			addf("bindings[%d]: subjects、types、scopes 和 claims 至少需要设置一个，使用 subjects: [\"*\"] 匹配所有调用方", i)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("授权策略无效: %w", errors.Join(errs...))
+	}
+	return compiled, nil
+}
+
+// parseRoute 解析 "HTTP 方法 路由模板" 形式的 HTTP 路由
+func parseRoute(s string) (route, error) {
+	method, template, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		method, template = "", method
+	}
+	template = strings.TrimSpace(template)
+	if template != "*" && !strings.HasPrefix(template, "/") {
+		return route{}, fmt.Errorf("路由模板 %q 必须以 / 开头，或使用 * 匹配所有路由", template)
+	}
+	if method != "" && !slices.Contains(httpMethods, method) {
+		return route{}, fmt.Errorf("不支持的 HTTP 方法 %q", method)
+	}
+	return route{method: method, template: template}, nil
+}
+
+// httpMethods 是权限中可以使用的 HTTP 方法
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// matchPattern 判断 value 是否匹配 pattern，pattern 只支持末尾的 *
+func matchPattern(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+	return pattern == value
+}
+
+// match 判断权限是否允许访问资源
+func (p *permission) match(res Resource) bool {
+	if res.Method != "" {
+		for _, m := range p.methods {
+			if matchPattern(m, res.Method) {
+				return true
+			}
+		}
+	}
+	if res.Route != "" {
+		for _, r := range p.routes {
+			if (r.method == "" || r.method == res.HTTPMethod) && matchPattern(r.template, res.Route) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match 判断调用方是否满足绑定的所有条件
+func match(b *Binding, p *auth.Principal) bool {
+	if len(b.Subjects) > 0 && !slices.ContainsFunc(b.Subjects, func(s string) bool { return s == "*" || s == p.Subject }) {
+		return false
+	}
+	if len(b.Types) > 0 && !slices.Contains(b.Types, p.Type) {
+		return false
+	}
+	if len(b.Scopes) > 0 && !slices.ContainsFunc(b.Scopes, p.HasScope) {
+		return false
+	}
+	for name, values := range b.Claims {
+		if !slices.ContainsFunc(claimValues(p.Claims[name]), func(v string) bool { return slices.Contains(values, v) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// claimValues 把声明的值转换为字符串列表，数组展开为其中的每个元素
+func claimValues(v any) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// evaluate 计算调用方访问资源的授权决策
+func (p *policy) evaluate(principal *auth.Principal, res Resource) Decision {
+	var d Decision
+	for i := range p.bindings {
+		if !match(&p.bindings[i], principal) {
+			continue
+		}
+		for _, name := range p.bindings[i].Roles {
+			if !slices.Contains(d.Roles, name) {
+				d.Roles = append(d.Roles, name)
+			}
+		}
+	}
+	for _, name := range d.Roles {
+		for _, perm := range p.roles[name] {
+			if perm.match(res) {
+				d.Allowed, d.Role = true, name
+				return d
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,205 @@
+// Package clientip 解析请求的真实客户端 IP。
+// 只有直接连接的对端属于受信任的代理时，才会从配置的转发请求头（默认 X-Forwarded-For）中解析客户端 IP，
+// 解析结果通过 NewContext 放入上下文，供限流、日志、追踪和 IP 过滤使用。
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// HeaderForwarded 是 RFC 7239 定义的 Forwarded 请求头
+	HeaderForwarded = "Forwarded"
+	// HeaderXForwardedFor 是 X-Forwarded-For 请求头
+	HeaderXForwardedFor = "X-Forwarded-For"
+	// HeaderXRealIP 是 X-Real-IP 请求头
+	HeaderXRealIP = "X-Real-IP"
+	// Attribute 是追踪 span 中记录客户端 IP 的属性名
+	Attribute = "client.address"
+)
+
+// DefaultHeader 是默认读取的转发请求头
+const DefaultHeader = HeaderXForwardedFor
+
+// Options 定义客户端 IP 解析的配置
+type Options struct {
+	// TrustedProxies 是受信任的代理，支持 CIDR（如 10.0.0.0/8）和单个 IP，为空时不信任任何代理，直接使用对端地址
+	TrustedProxies []string
+	// Header 是受信任的代理设置的转发请求头，为空时使用 DefaultHeader
+	// 只读取这一个请求头：代理通常只追加自己设置的请求头并原样转发其他请求头，
+	// 如果依次检查多个请求头，客户端可以伪造代理不处理的请求头来指定自己的 IP
+	// Forwarded 按 RFC 7239 解析 for 参数，其他请求头按逗号分隔的地址列表解析
+	Header string
+}
+
+// resolverState 是预处理后的解析配置
+type resolverState struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+// Resolver 是支持在运行时更新配置的客户端 IP 解析器
+type Resolver struct {
+	state atomic.Pointer[resolverState]
+}
+
+// NewResolver 创建一个新的 Resolver 实例
+func NewResolver(opts Options) (*Resolver, error) {
+	r := &Resolver{}
+	if err := r.Update(opts); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update 更新解析配置，对之后的请求生效，配置无效时保持原配置并返回错误
+func (r *Resolver) Update(opts Options) error {
+	trusted, err := ParsePrefixes(opts.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	header := opts.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	r.state.Store(&resolverState{trusted: trusted, header: header})
+	return nil
+}
+
+// ParsePrefixes 解析 CIDR 或单个 IP 列表，单个 IP 转换为只包含该地址的网段
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("无效的网段 %q: %w", v, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 IP %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// Trusted 判断地址是否属于受信任的代理
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+	return contains(r.state.Load().trusted, addr)
+}
+
+// contains 判断地址是否属于任意一个网段
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromRequest 解析 HTTP 请求的客户端 IP
+func (r *Resolver) FromRequest(req *http.Request) netip.Addr {
+	return r.Resolve(req.RemoteAddr, req.Header.Values)
+}
+
+// Resolve 根据对端地址和请求头解析客户端 IP，values 返回指定请求头的所有值
+// 对端不是受信任的代理或没有配置的转发请求头时直接返回对端地址；否则从右向左遍历转发链，跳过受信任的代理，
+// 返回第一个不受信任的地址，遇到无法解析的地址时返回它右侧的地址，全部受信任时返回最左侧的地址
+// 对端地址无法解析（如 Unix 套接字）时返回无效地址
+func (r *Resolver) Resolve(remoteAddr string, values func(name string) []string) netip.Addr {
+	peer, ok := ParseAddr(remoteAddr)
+	if !ok {
+		return netip.Addr{}
+	}
+
+	state := r.state.Load()
+	if !contains(state.trusted, peer) {
+		return peer
+	}
+
+	chain := forwardChain(state.header, values(state.header))
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := ParseAddr(chain[i])
+		if !ok {
+			return client
+		}
+		client = addr
+		if !contains(state.trusted, addr) {
+			return client
+		}
+	}
+	return client
+}
+
+// forwardChain 返回请求头中按从客户端到最近代理顺序排列的地址
+func forwardChain(name string, values []string) []string {
+	var chain []string
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			elem = strings.TrimSpace(elem)
+			if elem == "" {
+				continue
+			}
+			if strings.EqualFold(name, HeaderForwarded) {
+				elem = forwardedFor(elem)
+			}
+			chain = append(chain, elem)
+		}
+	}
+	return chain
+}
+
+// forwardedFor 返回 Forwarded 请求头中一个元素的 for 参数，如 for="[2001:db8::1]:4711";proto=https
+func forwardedFor(elem string) string {
+	for _, pair := range strings.Split(elem, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "for") {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// ParseAddr 解析 IP、IP:端口、[IPv6] 或 [IPv6]:端口 形式的地址，IPv4 映射的 IPv6 地址转换为 IPv4
+func ParseAddr(s string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap().WithZone(""), true
+	}
+	host, _, err := net.SplitHostPort(s)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// contextKey 是存放客户端 IP 的上下文键
+type contextKey struct{}
+
+// NewContext 返回一个携带客户端 IP 的新上下文
+func NewContext(ctx context.Context, addr netip.Addr) context.Context {
+	return context.WithValue(ctx, contextKey{}, addr)
+}
+
+// FromContext 返回上下文中的客户端 IP
+func FromContext(ctx context.Context) (netip.Addr, bool) {
+	addr, ok := ctx.Value(contextKey{}).(netip.Addr)
+	return addr, ok && addr.IsValid()
+}
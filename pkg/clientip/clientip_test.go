@@ -0,0 +1,164 @@
+package clientip
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	r, err := NewResolver(Options{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"}})
+	if err != nil {
+		t.Fatalf("创建解析器失败: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "不受信任的对端忽略转发头",
+			remoteAddr: "203.0.113.9:5000",
+			headers:    map[string][]string{HeaderXForwardedFor: {"1.1.1.1"}},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "跳过受信任的代理",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{HeaderXForwardedFor: {"1.1.1.1, 198.51.100.7", "10.1.1.1"}},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "伪造的最左侧地址不会被使用",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{HeaderXForwardedFor: {"127.0.0.1, 198.51.100.7"}},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "全部受信任时使用最左侧的地址",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{HeaderXForwardedFor: {"10.9.9.9, 192.0.2.1"}},
+			want:       "10.9.9.9",
+		},
+		{
+			name:       "无法解析的地址之后停止",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{HeaderXForwardedFor: {"1.1.1.1, garbage, 10.3.3.3"}},
+			want:       "10.3.3.3",
+		},
+		{
+			name:       "客户端伪造的其他转发头被忽略",
+			remoteAddr: "[2001:db8::1]:443",
+			headers: map[string][]string{
+				HeaderForwarded:     {"for=1.2.3.4"},
+				HeaderXRealIP:       {"1.2.3.4"},
+				HeaderXForwardedFor: {"198.51.100.8"},
+			},
+			want: "198.51.100.8",
+		},
+		{
+			name:       "只有其他转发头时使用对端地址",
+			remoteAddr: "192.0.2.1:80",
+			headers:    map[string][]string{HeaderForwarded: {"for=1.2.3.4"}},
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "没有转发头时使用对端地址",
+			remoteAddr: "[::ffff:10.0.0.2]:5000",
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "无法解析的对端地址",
+			remoteAddr: "@",
+			want:       "invalid IP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: http.Header{}}
+			for k, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(k, v)
+				}
+			}
+			if got := r.FromRequest(req).String(); got != tt.want {
+				t.Errorf("客户端 IP = %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveHeader(t *testing.T) {
+	// 定义测试用例，代理只设置 header 请求头
+	tests := []struct {
+		name    string
+		header  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "Forwarded",
+			header:  HeaderForwarded,
+			headers: map[string]string{HeaderForwarded: `for="[2001:db8:cafe::17]:4711";proto=https, for=198.51.100.8`, HeaderXForwardedFor: "1.2.3.4"},
+			want:    "198.51.100.8",
+		},
+		{
+			name:    "X-Real-IP",
+			header:  HeaderXRealIP,
+			headers: map[string]string{HeaderXRealIP: "198.51.100.9", HeaderXForwardedFor: "1.2.3.4"},
+			want:    "198.51.100.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewResolver(Options{TrustedProxies: []string{"10.0.0.0/8"}, Header: tt.header})
+			if err != nil {
+				t.Fatalf("创建解析器失败: %v", err)
+			}
+			req := &http.Request{RemoteAddr: "10.0.0.2:5000", Header: http.Header{}}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := r.FromRequest(req).String(); got != tt.want {
+				t.Errorf("客户端 IP = %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FilterOptions
+		addr string
+		want bool
+	}{
+		{name: "没有规则", opts: FilterOptions{}, addr: "1.1.1.1", want: true},
+		{name: "不在允许列表中", opts: FilterOptions{Allow: []string{"10.0.0.0/8"}}, addr: "1.1.1.1", want: false},
+		{name: "在允许列表中", opts: FilterOptions{Allow: []string{"10.0.0.0/8"}}, addr: "10.1.2.3", want: true},
+		{name: "禁止优先于允许", opts: FilterOptions{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.1.0.0/16"}}, addr: "10.1.2.3", want: false},
+		{name: "只有禁止列表", opts: FilterOptions{Deny: []string{"1.1.1.1"}}, addr: "1.1.1.2", want: true},
+		{name: "有规则时无效地址不允许访问", opts: FilterOptions{Deny: []string{"1.1.1.1"}}, addr: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.opts)
+			if err != nil {
+				t.Fatalf("创建过滤器失败: %v", err)
+			}
+			addr, _ := netip.ParseAddr(tt.addr)
+			if got := f.Allowed(addr); got != tt.want {
+				t.Errorf("Allowed(%q) = %v，期望 %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewFilter(FilterOptions{Allow: []string{"10.0.0.0/33"}}); err == nil {
+		t.Error("无效的网段应该返回错误")
+	}
+}
@@ -0,0 +1,66 @@
+package clientip
+
+import (
+	"net/netip"
+	"sync/atomic"
+)
+
+// FilterOptions 定义 IP 过滤的配置，网段支持 CIDR 和单个 IP
+type FilterOptions struct {
+	// Allow 是允许访问的网段，为空时允许所有不在 Deny 中的地址
+	Allow []string
+	// Deny 是禁止访问的网段，优先于 Allow
+	Deny []string
+}
+
+// filterState 是预处理后的过滤配置
+type filterState struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// Filter 是支持在运行时更新配置的 IP 过滤器，按客户端 IP 判断是否允许访问
+type Filter struct {
+	state atomic.Pointer[filterState]
+}
+
+// NewFilter 创建一个新的 Filter 实例
+func NewFilter(opts FilterOptions) (*Filter, error) {
+	f := &Filter{}
+	if err := f.Update(opts); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Update 更新过滤配置，对之后的请求生效，配置无效时保持原配置并返回错误
+func (f *Filter) Update(opts FilterOptions) error {
+	allow, err := ParsePrefixes(opts.Allow)
+	if err != nil {
+		return err
+	}
+	deny, err := ParsePrefixes(opts.Deny)
+	if err != nil {
+		return err
+	}
+	f.state.Store(&filterState{allow: allow, deny: deny})
+	return nil
+}
+
+// Enabled 判断是否配置了过滤规则
+func (f *Filter) Enabled() bool {
+	s := f.state.Load()
+	return len(s.allow) > 0 || len(s.deny) > 0
+}
+
+// Allowed 判断地址是否允许访问，配置了过滤规则时无效地址不允许访问
+func (f *Filter) Allowed(addr netip.Addr) bool {
+	s := f.state.Load()
+	if len(s.allow) == 0 && len(s.deny) == 0 {
+		return true
+	}
+	if !addr.IsValid() || contains(s.deny, addr) {
+		return false
+	}
+	return len(s.allow) == 0 || contains(s.allow, addr)
+}
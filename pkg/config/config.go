@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -14,25 +16,66 @@ type Config struct {
 	Server        ServerConfig        `mapstructure:"server"`
 	Observability ObservabilityConfig `mapstructure:"observability"`
 	Middleware    MiddlewareConfig    `mapstructure:"middleware"`
+	Auth          AuthConfig          `mapstructure:"auth"`
+	Authz         AuthzConfig         `mapstructure:"authz"`
 	Log           *log.Options        `mapstructure:"log"`
 }
 
+// 服务器运行模式
+const (
+	// ServerModeSeparate HTTP 和 gRPC 分别监听 server.http.addr 和 server.grpc.addr
+	ServerModeSeparate = "separate"
+	// ServerModeSingle HTTP、gRPC-Gateway 和 gRPC 共用 server.http.addr 一个端口
+	ServerModeSingle = "single"
+)
+
 // ServerConfig 包含服务器相关配置
 type ServerConfig struct {
-	HTTP HTTPConfig `mapstructure:"http"`
-	GRPC GRPCConfig `mapstructure:"grpc"`
+	// Mode 是服务器运行模式: separate, single
+	Mode  string      `mapstructure:"mode"`
+	HTTP  HTTPConfig  `mapstructure:"http"`
+	GRPC  GRPCConfig  `mapstructure:"grpc"`
+	Admin AdminConfig `mapstructure:"admin"`
+}
+
+// AdminConfig 包含运行时管理接口的配置
+// 管理接口包括 HTTP 的 /debug/loglevel 和 gRPC 的 admin.v1.Admin 服务，可以修改服务的日志级别
+type AdminConfig struct {
+	// Enable 为 true 时注册管理接口，默认关闭
+	// 启用认证时管理接口需要认证和授权，不能配置为公开路径或公开方法
+	Enable bool `mapstructure:"enable"`
 }
 
 // HTTPConfig 包含HTTP服务相关配置
 type HTTPConfig struct {
-	Addr    string `mapstructure:"addr"`
-	Timeout int    `mapstructure:"timeout"`
+	Addr    string    `mapstructure:"addr"`
+	Timeout int       `mapstructure:"timeout"`
+	TLS     TLSConfig `mapstructure:"tls"`
 }
 
 // GRPCConfig 包含gRPC服务相关配置
 type GRPCConfig struct {
-	Addr            string `mapstructure:"addr"`
-	ShutdownTimeout int    `mapstructure:"shutdown_timeout"`
+	Addr            string    `mapstructure:"addr"`
+	ShutdownTimeout int       `mapstructure:"shutdown_timeout"`
+	TLS             TLSConfig `mapstructure:"tls"`
+}
+
+// TLSConfig 定义 TLS 及双向认证配置
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CertFile 和 KeyFile 是服务端证书和私钥路径
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile 是用于校验客户端证书的 CA 路径
+	CAFile string `mapstructure:"ca_file"`
+	// MinVersion 是允许的最低 TLS 版本: 1.0, 1.1, 1.2, 1.3
+	MinVersion string `mapstructure:"min_version"`
+	// CipherSuites 是允许的加密套件名称，为空时使用 Go 的默认值
+	CipherSuites []string `mapstructure:"cipher_suites"`
+	// ClientAuth 是客户端认证模式: none, request, require, verify_if_given, require_and_verify
+	ClientAuth string `mapstructure:"client_auth"`
+	// ReloadInterval 是检查证书文件变化的周期
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
 }
 
 // ObservabilityConfig 包含可观测性相关配置
@@ -42,10 +85,18 @@ type ObservabilityConfig struct {
 	SkipPaths []string      `mapstructure:"skip_paths"`
 }
 
+// 支持的链路追踪导出器
+const (
+	TracingExporterStdout = "stdout"
+	TracingExporterJaeger = "jaeger"
+	TracingExporterOTLP   = "otlp"
+)
+
 // TracingConfig 包含链路追踪相关配置
 type TracingConfig struct {
-	ServiceName  string `mapstructure:"service_name"`
-	Enabled      bool   `mapstructure:"enabled"`
+	ServiceName string `mapstructure:"service_name"`
+	Enabled     bool   `mapstructure:"enabled"`
+	// Exporter 是追踪数据导出器: stdout, jaeger, otlp
 	Exporter     string `mapstructure:"exporter"`
 	OTLPEndpoint string `mapstructure:"otlp_endpoint"`
 }
@@ -54,17 +105,138 @@ type TracingConfig struct {
 type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
 	Path    string `mapstructure:"path"`
+	// HTTPBuckets 是 HTTP 请求耗时直方图的桶（秒），为空时使用默认值
+	HTTPBuckets []float64 `mapstructure:"http_buckets"`
+	// GRPCBuckets 是 gRPC 请求耗时直方图的桶（秒），为空时使用默认值
+	GRPCBuckets []float64 `mapstructure:"grpc_buckets"`
 }
 
 // MiddlewareConfig 包含中间件相关配置
 type MiddlewareConfig struct {
-	Timeout   time.Duration   `mapstructure:"timeout"`
-	CORS      CORSConfig      `mapstructure:"cors"`
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	// Timeout 是请求的默认超时时间
+	Timeout time.Duration `mapstructure:"timeout"`
+	// TimeoutRoutes 是按路径前缀覆盖的超时时间，多个前缀匹配时使用最长的前缀
+	TimeoutRoutes []TimeoutRouteConfig `mapstructure:"timeout_routes"`
+	// StreamingPaths 是流式响应的路径前缀，不受超时限制，SSE、WebSocket 和 gRPC 请求总是不受超时限制
+	StreamingPaths []string `mapstructure:"streaming_paths"`
+
+	// GRPCDeadline 是 gRPC 请求的截止时间配置
+	GRPCDeadline GRPCDeadlineConfig `mapstructure:"grpc_deadline"`
+	CORS         CORSConfig         `mapstructure:"cors"`
+	RateLimit    RateLimitConfig    `mapstructure:"rate_limit"`
+	ClientIP     ClientIPConfig     `mapstructure:"client_ip"`
+	IPFilter     IPFilterConfig     `mapstructure:"ip_filter"`
+}
+
+// TimeoutRouteConfig 定义按路径前缀覆盖的超时时间
+type TimeoutRouteConfig struct {
+	PathPrefix string        `mapstructure:"path_prefix"`
+	Timeout    time.Duration `mapstructure:"timeout"`
+}
+
+// GRPCDeadlineConfig 定义 gRPC 请求的截止时间配置
+// default 和 max 只作用于一元调用，流式调用只使用 methods 中匹配的规则
+type GRPCDeadlineConfig struct {
+	// Default 是客户端没有设置截止时间（grpc-timeout）时使用的超时时间，为 0 时不设置
+	Default time.Duration `mapstructure:"default"`
+	// Max 是允许的最长超时时间，客户端设置的截止时间更晚时会被缩短，为 0 时不限制
+	Max time.Duration `mapstructure:"max"`
+	// SafetyMargin 是向下游发起调用时从剩余时间中预留的余量
+	SafetyMargin time.Duration `mapstructure:"safety_margin"`
+	// Methods 是按 gRPC 方法覆盖的规则，匹配的调用完全使用该规则，多个规则匹配时使用第一个
+	Methods []GRPCDeadlineMethodConfig `mapstructure:"methods"`
+}
+
+// GRPCDeadlineMethodConfig 定义按 gRPC 方法覆盖的截止时间规则
+type GRPCDeadlineMethodConfig struct {
+	// Method 是 gRPC 全方法名，只支持末尾的 *，如 /helloworld.v1.Greeter/*
+	Method  string        `mapstructure:"method"`
+	Default time.Duration `mapstructure:"default"`
+	Max     time.Duration `mapstructure:"max"`
+}
+
+// AuthConfig 定义认证配置
+type AuthConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// PublicPaths 是不需要认证的 HTTP 路径前缀
+	PublicPaths []string `mapstructure:"public_paths"`
+	// PublicMethods 是不需要认证的 gRPC 全方法名，只支持末尾的 *，gRPC-Gateway 路由同样适用
+	// 方法也可以在 proto 中通过 option (auth.v1.public) = true 标记为公开
+	PublicMethods []string     `mapstructure:"public_methods"`
+	JWT           JWTConfig    `mapstructure:"jwt"`
+	APIKey        APIKeyConfig `mapstructure:"api_key"`
+}
+
+// JWTConfig 定义 JWT 认证配置，secret、jwks_file 和 jwks_url 至少需要设置一个
+type JWTConfig struct {
+	// Issuer 是要求的签发者，为空时不校验
+	Issuer string `mapstructure:"issuer"`
+	// Audience 是接受的受众，令牌的 aud 包含其中任意一个即可，为空时不校验
+	Audience []string `mapstructure:"audience"`
+	// ClockSkew 是校验 exp 和 nbf 时允许的时钟偏差
+	ClockSkew time.Duration `mapstructure:"clock_skew"`
+	// Algorithms 是允许的签名算法，为空时允许 HS、RS、PS 和 ES 的所有算法
+	Algorithms []string `mapstructure:"algorithms"`
+	// Secret 是 HS 算法使用的共享密钥，建议通过环境变量 GO_PROTOC_AUTH_JWT_SECRET 设置
+	Secret string `mapstructure:"secret"`
+	// JWKSFile 是本地 JWKS 文件的路径，在加载配置时读取
+	JWKSFile string `mapstructure:"jwks_file"`
+	// JWKSURL 是 JWKS 的地址，获取的密钥缓存 JWKSCacheTTL，遇到未知的 kid 时提前刷新
+	JWKSURL string `mapstructure:"jwks_url"`
+	// JWKSCacheTTL 是 JWKS 的缓存时间，为 0 时为 5 分钟
+	JWKSCacheTTL time.Duration `mapstructure:"jwks_cache_ttl"`
+}
+
+// APIKeyConfig 定义 API Key 认证配置，API Key 通过 apiserver apikey 命令签发和吊销
+type APIKeyConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// Header 是读取 API Key 的请求头（gRPC 为元数据），为空时为 X-API-Key
+	Header string `mapstructure:"header"`
+	// QueryParam 是读取 API Key 的 URL 查询参数，为空时不从查询参数读取
+	QueryParam string `mapstructure:"query_param"`
+	// File 是保存 API Key 哈希的 YAML 文件，文件变化时自动重新加载，路径修改后需要重启
+	File string `mapstructure:"file"`
+	// Hash 是签发 API Key 时使用的哈希算法：sha256 或 argon2id，为空时为 sha256
+	Hash string `mapstructure:"hash"`
+}
+
+// AuthzConfig 定义授权配置，启用授权时需要同时启用认证
+type AuthzConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// DryRun 为 true 时只记录拒绝决策而不拒绝请求
+	DryRun bool `mapstructure:"dry_run"`
+	// PolicyFile 是授权策略文件的路径，文件变化时自动重新加载，路径修改后需要重启才能监听新文件
+	PolicyFile string `mapstructure:"policy_file"`
+	// Audit 是审计日志记录的决策：all、deny 或 none，为空时为 deny
+	Audit string `mapstructure:"audit"`
+}
+
+// ClientIPConfig 定义客户端 IP 解析配置
+type ClientIPConfig struct {
+	// TrustedProxies 是受信任的代理，支持 CIDR 和单个 IP，只有对端属于受信任的代理时才读取转发请求头
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Header 是受信任的代理设置的转发请求头，只读取这一个请求头，为空时为 X-Forwarded-For
+	Header string `mapstructure:"header"`
+}
+
+// IPFilterConfig 定义按客户端 IP 过滤请求的配置，网段支持 CIDR 和单个 IP
+type IPFilterConfig struct {
+	// Allow 是允许访问的网段，为空时允许所有不在 Deny 中的地址
+	Allow []string `mapstructure:"allow"`
+	// Deny 是禁止访问的网段，优先于 Allow
+	Deny []string `mapstructure:"deny"`
 }
 
 // CORSConfig 定义跨域配置
 type CORSConfig struct {
+	CORSPolicyConfig `mapstructure:",squash"`
+	// Routes 是按路径前缀覆盖的跨域策略，匹配的请求完全使用该策略，多个前缀匹配时使用最长的前缀
+	Routes []CORSRouteConfig `mapstructure:"routes"`
+}
+
+// CORSPolicyConfig 定义一组跨域策略
+type CORSPolicyConfig struct {
+	// AllowOrigins 支持精确匹配、子域名通配（https://*.example.com）、正则表达式（regex:^https://...$）和 *
 	AllowOrigins     []string      `mapstructure:"allow_origins"`
 	AllowMethods     []string      `mapstructure:"allow_methods"`
 	AllowHeaders     []string      `mapstructure:"allow_headers"`
@@ -73,12 +245,62 @@ type CORSConfig struct {
 	MaxAge           time.Duration `mapstructure:"max_age"`
 }
 
+// CORSRouteConfig 定义按路径前缀覆盖的跨域策略
+type CORSRouteConfig struct {
+	PathPrefix       string `mapstructure:"path_prefix"`
+	CORSPolicyConfig `mapstructure:",squash"`
+}
+
 // RateLimitConfig 定义限流配置
+// limit、burst、window 和 key 是没有匹配任何策略的请求使用的默认策略
 type RateLimitConfig struct {
-	Enable bool          `mapstructure:"enable"`
-	Limit  int           `mapstructure:"limit"`
-	Burst  int           `mapstructure:"burst"`
+	Enable bool `mapstructure:"enable"`
+	// Limit 是每个 Window 允许的请求数
+	Limit int `mapstructure:"limit"`
+	// Burst 是令牌桶的容量，即最多可以连续发出的请求数
+	Burst int `mapstructure:"burst"`
+	// Window 是 Limit 对应的时间窗口，为 0 时为 1 秒
 	Window time.Duration `mapstructure:"window"`
+	// Key 是限流键：ip、api_key、user、tenant 或通过 ratelimit.RegisterKey 注册的键，为空时为 ip
+	Key string `mapstructure:"key"`
+	// APIKeyHeader 是 api_key 键读取的请求头，为空时为 X-API-Key
+	APIKeyHeader string `mapstructure:"api_key_header"`
+	// TenantHeader 是 tenant 键读取的请求头，为空时为 X-Tenant-ID
+	TenantHeader string `mapstructure:"tenant_header"`
+	// Policies 是按路由模板或 gRPC 方法匹配的策略，使用第一个匹配的策略
+	Policies []RateLimitPolicyConfig `mapstructure:"policies"`
+	// Backend 是保存令牌桶的存储：memory（默认，每个副本单独计数）或 redis（所有副本共享计数），修改后需要重启
+	Backend string `mapstructure:"backend"`
+	// MaxKeys 是 memory 存储最多保存的令牌桶数量，超出时丢弃最久未使用的，为 0 时为 100000
+	MaxKeys int `mapstructure:"max_keys"`
+	// Redis 是 redis 存储的配置
+	Redis RateLimitRedisConfig `mapstructure:"redis"`
+}
+
+// RateLimitRedisConfig 定义限流使用的 Redis 配置
+type RateLimitRedisConfig struct {
+	// Addrs 是 Redis 地址，多个地址时使用集群模式
+	Addrs    []string `mapstructure:"addrs"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	DB       int      `mapstructure:"db"`
+	// KeyPrefix 是令牌桶键的前缀，为空时为 ratelimit:
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// Timeout 是单次操作的超时，为 0 时为 100ms，超时或 Redis 不可用时放行请求
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// RateLimitPolicyConfig 定义一个限流策略，key 和 window 为空时使用默认策略的值，burst 为 0 时等于 limit
+type RateLimitPolicyConfig struct {
+	Name string `mapstructure:"name"`
+	// Routes 是匹配的 HTTP 路由模板，如 /v1/hello/{name}
+	Routes []string `mapstructure:"routes"`
+	// Methods 是匹配的 gRPC 全方法名，支持 /package.Service/* 匹配服务的所有方法，gRPC-Gateway 路由也按对应的方法匹配
+	Methods []string      `mapstructure:"methods"`
+	Key     string        `mapstructure:"key"`
+	Limit   int           `mapstructure:"limit"`
+	Burst   int           `mapstructure:"burst"`
+	Window  time.Duration `mapstructure:"window"`
 }
 
 // LoadConfig 从指定的文件加载配置
@@ -111,11 +333,26 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.Log = log.NewOptions()
 	}
 
+	// 如果未指定服务器运行模式，则使用独立端口模式
+	if config.Server.Mode == "" {
+		config.Server.Mode = ServerModeSeparate
+	}
+
 	// 如果日志名称为空，则设置默认名称
 	if config.Log.Name == "" {
 		config.Log.Name = "go-protoc"
 	}
 
+	// 校验配置，未知的配置项和非法的配置值一起报告
+	errs := unknownKeys(v.AllSettings(), reflect.TypeOf(config), "")
+	var invalid ValidationErrors
+	if errors.As(config.Validate(), &invalid) {
+		errs = append(errs, invalid...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("配置校验失败: %w", errs)
+	}
+
 	return &config, nil
 }
 
@@ -123,6 +360,7 @@ func LoadConfig(configPath string) (*Config, error) {
 func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
+			Mode: ServerModeSeparate,
 			HTTP: HTTPConfig{
 				Addr:    ":8090",
 				Timeout: 5,
@@ -148,37 +386,61 @@ func DefaultConfig() *Config {
 				"/debug/",
 				"/swagger/",
 				"/healthz",
+				"/livez",
+				"/readyz",
 				"/favicon.ico",
 			},
 		},
 		Middleware: MiddlewareConfig{
 			Timeout: 30 * time.Second,
+			GRPCDeadline: GRPCDeadlineConfig{
+				Default:      30 * time.Second,
+				Max:          5 * time.Minute,
+				SafetyMargin: 50 * time.Millisecond,
+			},
 			CORS: CORSConfig{
-				AllowOrigins: []string{"*"},
-				AllowMethods: []string{
-					"GET",
-					"POST",
-					"PUT",
-					"DELETE",
-					"OPTIONS",
-					"HEAD",
+				CORSPolicyConfig: CORSPolicyConfig{
+					AllowOrigins: []string{"*"},
+					AllowMethods: []string{
+						"GET",
+						"POST",
+						"PUT",
+						"DELETE",
+						"OPTIONS",
+						"HEAD",
+					},
+					AllowHeaders: []string{
+						"Authorization",
+						"Content-Type",
+						"X-Request-ID",
+						"X-Real-IP",
+					},
+					ExposeHeaders:    []string{"X-Request-ID"},
+					AllowCredentials: false,
+					MaxAge:           12 * time.Hour,
 				},
-				AllowHeaders: []string{
-					"Authorization",
-					"Content-Type",
-					"X-Request-ID",
-					"X-Real-IP",
-				},
-				ExposeHeaders:    []string{},
-				AllowCredentials: true,
-				MaxAge:           12 * time.Hour,
 			},
 			RateLimit: RateLimitConfig{
 				Enable: true,
 				Limit:  100,
 				Burst:  200,
-				Window: time.Minute,
+				Window: time.Second,
+				Key:    "ip",
+			},
+		},
+		Auth: AuthConfig{
+			PublicPaths: []string{"/livez", "/readyz", "/healthz", "/metrics", "/swagger/"},
+			JWT: JWTConfig{
+				ClockSkew:    30 * time.Second,
+				JWKSCacheTTL: 5 * time.Minute,
 			},
+			APIKey: APIKeyConfig{
+				Header: "X-API-Key",
+				Hash:   "sha256",
+			},
+		},
+		Authz: AuthzConfig{
+			Audit: "deny",
 		},
 		Log: log.NewOptions(),
 	}
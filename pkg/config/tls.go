@@ -0,0 +1,66 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// Version 将 MinVersion 解析为 TLS 版本号，默认为 TLS 1.2
+func (c *TLSConfig) Version() (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(c.MinVersion), "tls") {
+	case "", "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	default:
+		return 0, fmt.Errorf("不支持的 TLS 版本: %s", c.MinVersion)
+	}
+}
+
+// CipherSuiteIDs 将 CipherSuites 中的套件名称解析为 ID，为空时使用 Go 的默认套件
+func (c *TLSConfig) CipherSuiteIDs() ([]uint16, error) {
+	if len(c.CipherSuites) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+	for _, cs := range tls.InsecureCipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+
+	ids := make([]uint16, 0, len(c.CipherSuites))
+	for _, name := range c.CipherSuites {
+		id, ok := known[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("不支持的加密套件: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ClientAuthType 将 ClientAuth 解析为 tls.ClientAuthType
+func (c *TLSConfig) ClientAuthType() (tls.ClientAuthType, error) {
+	switch strings.ToLower(c.ClientAuth) {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("不支持的客户端认证模式: %s", c.ClientAuth)
+	}
+}
@@ -0,0 +1,546 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldError 描述一个配置项的错误，Path 是配置文件中的键路径，如 middleware.rate_limit.burst
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+// Error 实现 error 接口
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors 汇总配置校验发现的所有错误
+type ValidationErrors []*FieldError
+
+// Error 实现 error 接口
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validator 在校验过程中收集错误
+type validator struct {
+	errs ValidationErrors
+}
+
+// addf 记录一个配置项错误
+func (v *validator) addf(path, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err 返回收集到的错误，没有错误时返回 nil
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Validate 校验配置的合法性，返回包含所有错误的 ValidationErrors
+func (c *Config) Validate() error {
+	v := &validator{}
+	v.server(&c.Server)
+	v.observability(&c.Observability)
+	v.middleware(&c.Middleware)
+	v.auth(&c.Auth)
+	v.authz(&c.Authz, &c.Auth)
+	if c.Log != nil {
+		v.log(c)
+	}
+	for _, rule := range registeredRules() {
+		v.errs = append(v.errs, rule(c)...)
+	}
+	return v.err()
+}
+
+// Rule 是注册到 Validate 的额外校验规则，返回发现的所有错误
+type Rule func(c *Config) ValidationErrors
+
+var (
+	rulesMu sync.RWMutex
+	rules   []Rule
+)
+
+// RegisterRule 注册额外的校验规则，Validate 在内置校验之后按注册顺序调用
+// config 不依赖 auth、ratelimit 等功能包，JWT 签名算法、限流键等由功能包定义的可选值
+// 由使用这些包的程序注册规则校验，使配置校验、启动和热加载对同一份配置的结论一致
+func RegisterRule(rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules = append(rules, rule)
+}
+
+// registeredRules 返回已注册的校验规则
+func registeredRules() []Rule {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return slices.Clone(rules)
+}
+
+func (v *validator) server(c *ServerConfig) {
+	if c.Mode != ServerModeSeparate && c.Mode != ServerModeSingle {
+		v.addf("server.mode", "不支持的运行模式 %q，可选值: %s, %s", c.Mode, ServerModeSeparate, ServerModeSingle)
+	}
+
+	v.addr("server.http.addr", c.HTTP.Addr)
+	if c.HTTP.Timeout < 0 {
+		v.addf("server.http.timeout", "不能为负数")
+	}
+	v.tls("server.http.tls", &c.HTTP.TLS)
+
+	// 单端口模式下 gRPC 共用 HTTP 地址和 TLS 配置
+	if c.Mode != ServerModeSingle {
+		v.addr("server.grpc.addr", c.GRPC.Addr)
+		v.tls("server.grpc.tls", &c.GRPC.TLS)
+	}
+	if c.GRPC.ShutdownTimeout < 0 {
+		v.addf("server.grpc.shutdown_timeout", "不能为负数")
+	}
+}
+
+// addr 校验地址的格式为 host:port
+func (v *validator) addr(path, addr string) {
+	if addr == "" {
+		v.addf(path, "不能为空")
+		return
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		v.addf(path, "无效的地址 %q: %v", addr, err)
+	}
+}
+
+func (v *validator) tls(path string, c *TLSConfig) {
+	if !c.Enabled {
+		return
+	}
+	if c.CertFile == "" {
+		v.addf(path+".cert_file", "启用 TLS 时不能为空")
+	}
+	if c.KeyFile == "" {
+		v.addf(path+".key_file", "启用 TLS 时不能为空")
+	}
+	if _, err := c.Version(); err != nil {
+		v.addf(path+".min_version", "%v", err)
+	}
+	if _, err := c.CipherSuiteIDs(); err != nil {
+		v.addf(path+".cipher_suites", "%v", err)
+	}
+	clientAuth, err := c.ClientAuthType()
+	if err != nil {
+		v.addf(path+".client_auth", "%v", err)
+	} else if clientAuth >= tls.VerifyClientCertIfGiven && c.CAFile == "" {
+		v.addf(path+".ca_file", "client_auth 为 %s 时不能为空", c.ClientAuth)
+	}
+	if c.ReloadInterval < 0 {
+		v.addf(path+".reload_interval", "不能为负数")
+	}
+}
+
+func (v *validator) observability(c *ObservabilityConfig) {
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case TracingExporterStdout, TracingExporterJaeger:
+		case TracingExporterOTLP:
+			if c.Tracing.OTLPEndpoint == "" {
+				v.addf("observability.tracing.otlp_endpoint", "导出器为 otlp 时不能为空")
+			}
+		default:
+			v.addf("observability.tracing.exporter", "不支持的导出器 %q，可选值: %s, %s, %s",
+				c.Tracing.Exporter, TracingExporterStdout, TracingExporterJaeger, TracingExporterOTLP)
+		}
+		if c.Tracing.ServiceName == "" {
+			v.addf("observability.tracing.service_name", "启用追踪时不能为空")
+		}
+	}
+
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		v.addf("observability.metrics.path", "必须以 / 开头")
+	}
+	v.buckets("observability.metrics.http_buckets", c.Metrics.HTTPBuckets)
+	v.buckets("observability.metrics.grpc_buckets", c.Metrics.GRPCBuckets)
+
+	for i, path := range c.SkipPaths {
+		if !strings.HasPrefix(path, "/") {
+			v.addf(fmt.Sprintf("observability.skip_paths[%d]", i), "路径 %q 必须以 / 开头", path)
+		}
+	}
+}
+
+// buckets 校验直方图的桶是严格递增的
+func (v *validator) buckets(path string, buckets []float64) {
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			v.addf(fmt.Sprintf("%s[%d]", path, i), "桶必须严格递增，%v 不大于 %v", buckets[i], buckets[i-1])
+		}
+	}
+}
+
+func (v *validator) middleware(c *MiddlewareConfig) {
+	if c.Timeout <= 0 {
+		v.addf("middleware.timeout", "必须大于 0")
+	}
+	for i, route := range c.TimeoutRoutes {
+		path := fmt.Sprintf("middleware.timeout_routes[%d]", i)
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			v.addf(path+".path_prefix", "必须以 / 开头")
+		}
+		if route.Timeout <= 0 {
+			v.addf(path+".timeout", "必须大于 0")
+		}
+	}
+	for i, prefix := range c.StreamingPaths {
+		if !strings.HasPrefix(prefix, "/") {
+			v.addf(fmt.Sprintf("middleware.streaming_paths[%d]", i), "必须以 / 开头")
+		}
+	}
+
+	v.grpcDeadline(&c.GRPCDeadline)
+
+	v.corsPolicy("middleware.cors", &c.CORS.CORSPolicyConfig)
+	for i := range c.CORS.Routes {
+		route := &c.CORS.Routes[i]
+		path := fmt.Sprintf("middleware.cors.routes[%d]", i)
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			v.addf(path+".path_prefix", "必须以 / 开头")
+		}
+		v.corsPolicy(path, &route.CORSPolicyConfig)
+	}
+
+	v.prefixes("middleware.client_ip.trusted_proxies", c.ClientIP.TrustedProxies)
+	if strings.ContainsAny(c.ClientIP.Header, " \t:,") {
+		v.addf("middleware.client_ip.header", "无效的请求头名称 %q", c.ClientIP.Header)
+	}
+	v.prefixes("middleware.ip_filter.allow", c.IPFilter.Allow)
+	v.prefixes("middleware.ip_filter.deny", c.IPFilter.Deny)
+
+	v.rateLimit(&c.RateLimit)
+}
+
+// grpcDeadline 校验 gRPC 截止时间配置
+func (v *validator) grpcDeadline(c *GRPCDeadlineConfig) {
+	v.deadline("middleware.grpc_deadline", c.Default, c.Max)
+	if c.SafetyMargin < 0 {
+		v.addf("middleware.grpc_deadline.safety_margin", "不能为负数")
+	}
+	for i, m := range c.Methods {
+		path := fmt.Sprintf("middleware.grpc_deadline.methods[%d]", i)
+		v.grpcMethod(path+".method", m.Method)
+		v.deadline(path, m.Default, m.Max)
+	}
+}
+
+// deadline 校验默认和最长的超时时间
+func (v *validator) deadline(path string, def, maximum time.Duration) {
+	if def < 0 {
+		v.addf(path+".default", "不能为负数")
+	}
+	if maximum < 0 {
+		v.addf(path+".max", "不能为负数")
+	}
+	if def > 0 && maximum > 0 && def > maximum {
+		v.addf(path+".default", "不能大于 max（%s）", maximum)
+	}
+}
+
+// grpcMethod 校验 gRPC 全方法名，只支持末尾的 *
+func (v *validator) grpcMethod(path, method string) {
+	if !strings.HasPrefix(method, "/") || strings.Contains(strings.TrimSuffix(method, "*"), "*") {
+		v.addf(path, "必须是 /package.Service/Method 形式的全方法名，只支持末尾的 *")
+	}
+}
+
+// auth 校验认证配置，认证未启用时也校验除密钥来源以外的配置
+// 签名算法和 API Key 哈希算法由 auth 包定义，通过 RegisterRule 注册的规则校验
+func (v *validator) auth(c *AuthConfig) {
+	for i, prefix := range c.PublicPaths {
+		if !strings.HasPrefix(prefix, "/") {
+			v.addf(fmt.Sprintf("auth.public_paths[%d]", i), "必须以 / 开头")
+		}
+	}
+	for i, method := range c.PublicMethods {
+		v.grpcMethod(fmt.Sprintf("auth.public_methods[%d]", i), method)
+	}
+
+	jwt := &c.JWT
+	if c.Enable && !c.APIKey.Enable && jwt.Secret == "" && jwt.JWKSFile == "" && jwt.JWKSURL == "" {
+		v.addf("auth.jwt", "启用认证时 secret、jwks_file 和 jwks_url 至少需要设置一个，或者启用 api_key")
+	}
+	if jwt.ClockSkew < 0 {
+		v.addf("auth.jwt.clock_skew", "不能为负数")
+	}
+	if jwt.JWKSCacheTTL < 0 {
+		v.addf("auth.jwt.jwks_cache_ttl", "不能为负数")
+	}
+	if jwt.JWKSURL != "" {
+		if u, err := url.Parse(jwt.JWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf("auth.jwt.jwks_url", "必须是 http 或 https 地址")
+		}
+	}
+
+	key := &c.APIKey
+	if key.Enable && key.File == "" {
+		v.addf("auth.api_key.file", "启用 API Key 认证时不能为空")
+	}
+}
+
+// authz 校验授权配置，策略文件的内容在创建授权器时校验，审计日志级别通过 RegisterRule 注册的规则校验
+func (v *validator) authz(c *AuthzConfig, authn *AuthConfig) {
+	if c.Enable && !authn.Enable {
+		v.addf("authz.enable", "启用授权时需要同时启用认证（auth.enable）")
+	}
+	if c.Enable && c.PolicyFile == "" {
+		v.addf("authz.policy_file", "启用授权时不能为空")
+	}
+}
+
+// rateLimit 校验限流配置，限流未启用时也不允许负数和无效的策略，避免启用时才发现问题
+// 存储、限流键和保留的策略名称由 ratelimit 包定义，通过 RegisterRule 注册的规则校验
+func (v *validator) rateLimit(rl *RateLimitConfig) {
+	if rl.Limit < 0 || (rl.Enable && rl.Limit == 0) {
+		v.addf("middleware.rate_limit.limit", "必须大于 0")
+	}
+	if rl.Burst < 0 || (rl.Enable && rl.Burst == 0) {
+		v.addf("middleware.rate_limit.burst", "必须大于 0")
+	}
+	if rl.Window < 0 {
+		v.addf("middleware.rate_limit.window", "不能为负数")
+	}
+	for i, addr := range rl.Redis.Addrs {
+		v.addr(fmt.Sprintf("middleware.rate_limit.redis.addrs[%d]", i), addr)
+	}
+	if rl.MaxKeys < 0 {
+		v.addf("middleware.rate_limit.max_keys", "不能为负数")
+	}
+	if rl.Redis.DB < 0 {
+		v.addf("middleware.rate_limit.redis.db", "不能为负数")
+	}
+	if rl.Redis.Timeout < 0 {
+		v.addf("middleware.rate_limit.redis.timeout", "不能为负数")
+	}
+
+	names := make(map[string]bool, len(rl.Policies))
+	for i, p := range rl.Policies {
+		path := fmt.Sprintf("middleware.rate_limit.policies[%d]", i)
+		if p.Name == "" {
+			v.addf(path+".name", "不能为空")
+		} else if names[p.Name] {
+			v.addf(path+".name", "策略名称 %q 重复", p.Name)
+		}
+		names[p.Name] = true
+		if len(p.Routes) == 0 && len(p.Methods) == 0 {
+			v.addf(path, "routes 和 methods 不能同时为空")
+		}
+		for j, route := range p.Routes {
+			if !strings.HasPrefix(route, "/") {
+				v.addf(fmt.Sprintf("%s.routes[%d]", path, j), "必须以 / 开头")
+			}
+		}
+		for j, method := range p.Methods {
+			v.grpcMethod(fmt.Sprintf("%s.methods[%d]", path, j), method)
+		}
+		if p.Limit <= 0 {
+			v.addf(path+".limit", "必须大于 0")
+		}
+		if p.Burst < 0 {
+			v.addf(path+".burst", "不能为负数")
+		}
+		if p.Window < 0 {
+			v.addf(path+".window", "不能为负数")
+		}
+	}
+}
+
+// prefixes 校验网段列表，支持 CIDR 和单个 IP
+func (v *validator) prefixes(path string, values []string) {
+	for i, value := range values {
+		var err error
+		if strings.Contains(value, "/") {
+			_, err = netip.ParsePrefix(value)
+		} else {
+			_, err = netip.ParseAddr(value)
+		}
+		if err != nil {
+			v.addf(fmt.Sprintf("%s[%d]", path, i), "无效的网段或 IP %q: %v", value, err)
+		}
+	}
+}
+
+// corsPolicy 校验跨域策略
+func (v *validator) corsPolicy(path string, c *CORSPolicyConfig) {
+	if c.MaxAge < 0 {
+		v.addf(path+".max_age", "不能为负数")
+	}
+	for i, method := range c.AllowMethods {
+		if !slices.Contains(httpMethods, strings.ToUpper(method)) {
+			v.addf(fmt.Sprintf("%s.allow_methods[%d]", path, i), "无效的 HTTP 方法 %q", method)
+		}
+	}
+	for i, origin := range c.AllowOrigins {
+		originPath := fmt.Sprintf("%s.allow_origins[%d]", path, i)
+		switch {
+		case origin == "*":
+			if c.AllowCredentials {
+				v.addf(path+".allow_credentials", "allow_origins 包含 * 时不能携带凭证，请列出具体的来源")
+			}
+		case strings.HasPrefix(origin, "regex:"):
+			if _, err := regexp.Compile(strings.TrimPrefix(origin, "regex:")); err != nil {
+				v.addf(originPath, "无效的正则表达式: %v", err)
+			}
+		case strings.Contains(origin, "*"):
+			// 只支持 scheme://*.domain 形式的子域名通配
+			scheme, host, ok := strings.Cut(origin, "://")
+			if !ok || scheme == "" || !strings.HasPrefix(host, "*.") || strings.Count(host, "*") != 1 {
+				v.addf(originPath, "无效的通配来源 %q，应为 https://*.example.com 形式", origin)
+			}
+		default:
+			if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+				v.addf(originPath, "无效的来源 %q，应为 scheme://host[:port] 形式", origin)
+			}
+		}
+	}
+}
+
+// httpMethods 是 CORS 允许配置的 HTTP 方法
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+func (v *validator) log(c *Config) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		v.addf("log.level", "不支持的日志级别 %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "console" {
+		v.addf("log.format", "不支持的日志格式 %q，可选值: json, console", c.Log.Format)
+	}
+	if len(c.Log.OutputPaths) == 0 {
+		v.addf("log.output-paths", "不能为空")
+	}
+	v.rotation("log.rotation", &c.Log.Rotation)
+	v.rotation("log.error-rotation", &c.Log.ErrorRotation)
+	if c.Log.StacktraceLevel != "" {
+		if err := level.UnmarshalText([]byte(c.Log.StacktraceLevel)); err != nil {
+			v.addf("log.stacktrace-level", "不支持的日志级别 %q", c.Log.StacktraceLevel)
+		}
+	}
+	v.sampling("log.sampling", &c.Log.Sampling)
+	for i, p := range c.Log.Redaction.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			v.addf(fmt.Sprintf("log.redaction.patterns[%d]", i), "无效的正则表达式: %v", err)
+		}
+	}
+}
+
+// sampling 校验日志采样配置
+func (v *validator) sampling(path string, c *log.SamplingOptions) {
+	if c.Initial < 0 {
+		v.addf(path+".initial", "不能为负数")
+	}
+	if c.Thereafter < 0 {
+		v.addf(path+".thereafter", "不能为负数")
+	}
+	if c.Tick < 0 {
+		v.addf(path+".tick", "不能为负数")
+	}
+}
+
+// rotation 校验日志文件的轮转配置
+func (v *validator) rotation(path string, c *log.RotationOptions) {
+	if c.MaxSize < 0 {
+		v.addf(path+".max-size", "不能为负数")
+	}
+	if c.MaxAge < 0 {
+		v.addf(path+".max-age", "不能为负数")
+	}
+	if c.MaxBackups < 0 {
+		v.addf(path+".max-backups", "不能为负数")
+	}
+}
+
+// collectFields 收集结构体的配置键，squash 的嵌入结构体的字段视为外层结构体的字段
+func collectFields(fields map[string]reflect.Type, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if f.Anonymous && opts == "squash" && f.Type.Kind() == reflect.Struct {
+			collectFields(fields, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+}
+
+// unknownKeys 对照配置结构体的 mapstructure 标签，返回配置文件中无法识别的键
+func unknownKeys(settings map[string]any, t reflect.Type, prefix string) ValidationErrors {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type, t.NumField())
+	collectFields(fields, t)
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs ValidationErrors
+	for _, key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fieldType, ok := fields[strings.ToLower(key)]
+		if !ok {
+			errs = append(errs, &FieldError{Path: path, Message: "未知的配置项"})
+			continue
+		}
+		switch nested := settings[key].(type) {
+		case map[string]any:
+			errs = append(errs, unknownKeys(nested, fieldType, path)...)
+		case []any:
+			// 结构体切片（如 middleware.cors.routes）逐个元素检查
+			if fieldType.Kind() != reflect.Slice {
+				continue
+			}
+			for i, elem := range nested {
+				if m, ok := elem.(map[string]any); ok {
+					errs = append(errs, unknownKeys(m, fieldType.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+				}
+			}
+		}
+	}
+	return errs
+}
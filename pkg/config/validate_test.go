@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	// 定义测试用例
+	testCases := []struct {
+		name   string
+		modify func(c *Config)
+		paths  []string
+	}{
+		{
+			name:   "默认配置",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "多个错误一起报告",
+			modify: func(c *Config) {
+				c.Server.HTTP.Addr = ""
+				c.Middleware.RateLimit.Burst = -1
+				c.Observability.Tracing.Exporter = "zipkin"
+			},
+			paths: []string{"server.http.addr", "observability.tracing.exporter", "middleware.rate_limit.burst"},
+		},
+		{
+			name: "单端口模式不校验gRPC地址",
+			modify: func(c *Config) {
+				c.Server.Mode = ServerModeSingle
+				c.Server.GRPC.Addr = ""
+			},
+		},
+		{
+			name: "TLS配置",
+			modify: func(c *Config) {
+				c.Server.GRPC.TLS = TLSConfig{Enabled: true, MinVersion: "2.0", ClientAuth: "require_and_verify"}
+			},
+			paths: []string{
+				"server.grpc.tls.cert_file", "server.grpc.tls.key_file",
+				"server.grpc.tls.min_version", "server.grpc.tls.ca_file",
+			},
+		},
+		{
+			name: "直方图桶和跳过路径",
+			modify: func(c *Config) {
+				c.Observability.Metrics.HTTPBuckets = []float64{0.1, 0.5, 0.5}
+				c.Observability.SkipPaths = []string{"/metrics", "healthz"}
+			},
+			paths: []string{"observability.metrics.http_buckets[2]", "observability.skip_paths[1]"},
+		},
+		{
+			name: "超时路由和流式路径",
+			modify: func(c *Config) {
+				c.Middleware.TimeoutRoutes = []TimeoutRouteConfig{
+					{PathPrefix: "/v1/reports", Timeout: 2 * time.Minute},
+					{PathPrefix: "v1/export", Timeout: 0},
+				}
+				c.Middleware.StreamingPaths = []string{"/v1/events", "events"}
+			},
+			paths: []string{
+				"middleware.timeout_routes[1].path_prefix",
+				"middleware.timeout_routes[1].timeout",
+				"middleware.streaming_paths[1]",
+			},
+		},
+		{
+			name: "gRPC截止时间",
+			modify: func(c *Config) {
+				c.Middleware.GRPCDeadline.SafetyMargin = -time.Millisecond
+				c.Middleware.GRPCDeadline.Methods = []GRPCDeadlineMethodConfig{
+					{Method: "/helloworld.v1.Greeter/*", Default: time.Minute, Max: time.Second},
+					{Method: "helloworld.v1.Greeter/SayHello", Max: -time.Second},
+				}
+			},
+			paths: []string{
+				"middleware.grpc_deadline.safety_margin",
+				"middleware.grpc_deadline.methods[0].default",
+				"middleware.grpc_deadline.methods[1].method",
+				"middleware.grpc_deadline.methods[1].max",
+			},
+		},
+		{
+			name: "CORS来源和路由",
+			modify: func(c *Config) {
+				c.Middleware.CORS.AllowCredentials = true
+				c.Middleware.CORS.Routes = []CORSRouteConfig{{
+					PathPrefix: "v1/admin",
+					CORSPolicyConfig: CORSPolicyConfig{
+						AllowOrigins: []string{"https://admin.example.com", "https://a.*.example.com", "regex:(", "example.com"},
+					},
+				}}
+			},
+			paths: []string{
+				"middleware.cors.allow_credentials",
+				"middleware.cors.routes[0].path_prefix",
+				"middleware.cors.routes[0].allow_origins[1]",
+				"middleware.cors.routes[0].allow_origins[2]",
+				"middleware.cors.routes[0].allow_origins[3]",
+			},
+		},
+		{
+			name: "限流策略",
+			modify: func(c *Config) {
+				c.Middleware.RateLimit.Backend = "redis"
+				c.Middleware.RateLimit.Redis.Addrs = []string{"localhost:6379", "redis"}
+				c.Middleware.RateLimit.Policies = []RateLimitPolicyConfig{
+					{Name: "login", Routes: []string{"/v1/login"}, Key: "user", Limit: 5},
+					{Name: "login", Methods: []string{"helloworld.v1.Greeter/SayHello", "/helloworld.v1.*/SayHello"}, Limit: 0},
+					{Name: "hello", Routes: []string{"v1/hello/{name}"}, Limit: 10, Burst: -1},
+					{Name: "empty", Limit: 1},
+				}
+			},
+			paths: []string{
+				"middleware.rate_limit.redis.addrs[1]",
+				"middleware.rate_limit.policies[1].name",
+				"middleware.rate_limit.policies[1].methods[0]",
+				"middleware.rate_limit.policies[1].methods[1]",
+				"middleware.rate_limit.policies[1].limit",
+				"middleware.rate_limit.policies[2].routes[0]",
+				"middleware.rate_limit.policies[2].burst",
+				"middleware.rate_limit.policies[3]",
+			},
+		},
+		{
+			name: "客户端IP和IP过滤",
+			modify: func(c *Config) {
+				c.Middleware.ClientIP = ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8", "10.0.0.1/40"}, Header: "X-Forwarded-For, X-Real-IP"}
+				c.Middleware.IPFilter = IPFilterConfig{Deny: []string{"localhost"}}
+			},
+			paths: []string{
+				"middleware.client_ip.trusted_proxies[1]",
+				"middleware.client_ip.header",
+				"middleware.ip_filter.deny[0]",
+			},
+		},
+		{
+			name: "认证",
+			modify: func(c *Config) {
+				c.Auth.Enable = true
+				c.Auth.PublicPaths = []string{"healthz"}
+				c.Auth.PublicMethods = []string{"helloworld.v1.Greeter/SayHello"}
+				c.Auth.JWT.ClockSkew = -time.Second
+				c.Auth.JWT.JWKSURL = "file:///etc/jwks.json"
+			},
+			paths: []string{
+				"auth.public_paths[0]",
+				"auth.public_methods[0]",
+				"auth.jwt.clock_skew",
+				"auth.jwt.jwks_url",
+			},
+		},
+		{
+			name: "启用认证时缺少密钥来源",
+			modify: func(c *Config) {
+				c.Auth.Enable = true
+			},
+			paths: []string{"auth.jwt"},
+		},
+		{
+			name: "API Key",
+			modify: func(c *Config) {
+				c.Auth.Enable = true
+				c.Auth.APIKey.Enable = true
+			},
+			paths: []string{"auth.api_key.file"},
+		},
+		{
+			name: "授权",
+			modify: func(c *Config) {
+				c.Authz.Enable = true
+			},
+			paths: []string{"authz.enable", "authz.policy_file"},
+		},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tc.modify(cfg)
+
+			var paths []string
+			var invalid ValidationErrors
+			if errors.As(cfg.Validate(), &invalid) {
+				for _, e := range invalid {
+					paths = append(paths, e.Path)
+				}
+			}
+			if !slices.Equal(paths, tc.paths) {
+				t.Errorf("错误路径不匹配: 期望=%v, 实际=%v", tc.paths, paths)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte(`
+server:
+  http:
+    addr: ":8080"
+    adress: ":8081"
+  grpc:
+    addr: ":9090"
+middleware:
+  timeout: 30s
+  cors:
+    allow_origins: ["https://example.com"]
+    routes:
+      - path_prefix: /v1/public/
+        allow_orgins: ["*"]
+  rate_limit:
+    burst: -1
+log:
+  level: "info"
+  format: "json"
+  output-paths: ["stdout"]
+  colour: true
+tracing:
+  enabled: true
+`)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	_, err := LoadConfig(path)
+	var invalid ValidationErrors
+	if !errors.As(err, &invalid) {
+		t.Fatalf("期望返回 ValidationErrors，实际: %v", err)
+	}
+
+	var paths []string
+	for _, e := range invalid {
+		paths = append(paths, e.Path)
+	}
+	expected := []string{"log.colour", "middleware.cors.routes[0].allow_orgins", "server.http.adress", "tracing", "middleware.rate_limit.burst"}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("错误路径不匹配: 期望=%v, 实际=%v", expected, paths)
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	// 注册的规则对所有测试生效，只在特定的配置上报告错误
+	RegisterRule(func(c *Config) ValidationErrors {
+		if c.Middleware.RateLimit.Key != "custom" {
+			return nil
+		}
+		return ValidationErrors{{Path: "middleware.rate_limit.key", Message: "未注册"}}
+	})
+
+	cfg := DefaultConfig()
+	cfg.Middleware.RateLimit.Key = "custom"
+	cfg.Middleware.RateLimit.Burst = -1
+	var invalid ValidationErrors
+	if !errors.As(cfg.Validate(), &invalid) {
+		t.Fatal("注册的规则没有报告错误")
+	}
+	var paths []string
+	for _, e := range invalid {
+		paths = append(paths, e.Path)
+	}
+	expected := []string{"middleware.rate_limit.burst", "middleware.rate_limit.key"}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("错误路径不匹配: 期望=%v, 实际=%v", expected, paths)
+	}
+}
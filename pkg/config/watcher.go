@@ -0,0 +1,342 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce 是文件变化后等待的时间，用于合并编辑器或 ConfigMap 更新产生的多个事件
+const reloadDebounce = 200 * time.Millisecond
+
+// Section 表示可以在运行时重新加载的配置段
+type Section string
+
+// 支持热加载的配置段
+const (
+	SectionLog          Section = "log"
+	SectionCORS         Section = "middleware.cors"
+	SectionRateLimit    Section = "middleware.rate_limit"
+	SectionTimeout      Section = "middleware.timeout" // 包括 timeout_routes 和 streaming_paths
+	SectionGRPCDeadline Section = "middleware.grpc_deadline"
+	SectionClientIP     Section = "middleware.client_ip"
+	SectionIPFilter     Section = "middleware.ip_filter"
+	SectionSkipPaths    Section = "observability.skip_paths"
+	SectionAuth         Section = "auth"
+	SectionAuthz        Section = "authz"
+)
+
+// Event 描述一次成功的配置变更
+type Event struct {
+	// Old 是变更前的配置
+	Old *Config
+	// New 是变更后的配置
+	New *Config
+	// Sections 是发生变化的配置段
+	Sections []Section
+}
+
+// Changed 判断指定的配置段是否发生了变化
+func (e Event) Changed(section Section) bool {
+	return slices.Contains(e.Sections, section)
+}
+
+// subscriber 是一个配置变更订阅者
+type subscriber struct {
+	fn       func(Event) error
+	sections []Section
+}
+
+// matches 判断订阅者是否需要收到事件
+func (s subscriber) matches(e Event) bool {
+	return len(s.sections) == 0 || containsAny(e.Sections, s.sections)
+}
+
+// Watcher 监听配置文件的变化和 SIGHUP 信号，重新加载并校验配置，然后通知订阅者
+// 配置引用的其他文件通过 WatchFile 一起监听
+// Watcher 实现了 app.Server 接口，可以直接添加到 App 中
+type Watcher struct {
+	path string
+
+	mu          sync.RWMutex
+	current     *Config
+	subscribers []subscriber
+	files       []*watchedFile
+
+	// reloadMu 保证同一时间只有一次重新加载
+	reloadMu sync.Mutex
+}
+
+// NewWatcher 创建一个新的 Watcher 实例，initial 是已经加载的初始配置
+func NewWatcher(path string, initial *Config) *Watcher {
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+	return &Watcher{
+		path:    path,
+		current: initial,
+	}
+}
+
+// Current 返回当前生效的配置
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe 订阅配置变更，当 sections 中任一配置段变化时调用 fn；sections 为空时订阅所有变更
+// fn 在重新加载的协程中同步调用，不应长时间阻塞；fn 无法应用新配置时返回错误并保持原来的配置，
+// 此时重新加载失败，已经应用新配置的订阅者会收到 Old 和 New 互换的事件，恢复原来的配置
+func (w *Watcher) Subscribe(fn func(Event) error, sections ...Section) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, subscriber{fn: fn, sections: sections})
+}
+
+// Reload 重新读取并校验配置文件，然后通知订阅者
+// 校验失败或者有订阅者无法应用新配置时保留当前配置并返回错误，只有所有订阅者都应用了新配置才算重新加载成功
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	next, err := LoadConfig(w.path)
+	if err != nil {
+		return w.reloadFailed(err)
+	}
+
+	w.mu.RLock()
+	prev := w.current
+	subscribers := slices.Clone(w.subscribers)
+	w.mu.RUnlock()
+
+	event := Event{Old: prev, New: next, Sections: diffSections(prev, next)}
+	if len(event.Sections) > 0 {
+		if err := notify(subscribers, event); err != nil {
+			return w.reloadFailed(err)
+		}
+	}
+
+	w.mu.Lock()
+	w.current = next
+	w.mu.Unlock()
+	metrics.ConfigReloadsTotal.WithLabelValues("success").Inc()
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	metrics.ConfigLastReloadSuccessTimestamp.SetToCurrentTime()
+
+	warnRestartRequired(prev, next)
+	if len(event.Sections) == 0 {
+		log.Infow("配置已重新加载，没有可热更新的变化", "path", w.path)
+		return nil
+	}
+	log.Infow("配置已重新加载", "path", w.path, "sections", event.Sections)
+	return nil
+}
+
+// reloadFailed 记录重新加载失败
+func (w *Watcher) reloadFailed(err error) error {
+	metrics.ConfigReloadsTotal.WithLabelValues("failure").Inc()
+	metrics.ConfigLastReloadSuccessful.Set(0)
+	log.Errorw("重新加载配置失败，继续使用当前配置", "path", w.path, "error", err)
+	return err
+}
+
+// notify 通知所有订阅者并汇总错误，有订阅者返回错误时用 Old 和 New 互换的事件通知已经应用新配置的订阅者
+func notify(subscribers []subscriber, event Event) error {
+	var errs []error
+	var applied []subscriber
+	for _, sub := range subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		if err := sub.fn(event); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		applied = append(applied, sub)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	rollback := Event{Old: event.New, New: event.Old, Sections: event.Sections}
+	for i := len(applied) - 1; i >= 0; i-- {
+		if err := applied[i].fn(rollback); err != nil {
+			log.Errorw("恢复原来的配置失败", "sections", event.Sections, "error", err)
+		}
+	}
+	return fmt.Errorf("应用新配置失败: %w", errors.Join(errs...))
+}
+
+// WatchFile 监听配置引用的其他文件（如授权策略文件），文件变化或接收到 SIGHUP 时调用 fn
+// fn 在监听协程中同步调用，不应长时间阻塞；必须在 Start 之前调用
+func (w *Watcher) WatchFile(path string, fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = append(w.files, &watchedFile{path: path, fn: fn})
+}
+
+// watchedFile 是一个被监听的文件
+type watchedFile struct {
+	path string
+	fn   func()
+	// realPath 是解析符号链接后的真实路径，用于识别 ConfigMap 的符号链接切换
+	realPath string
+	// pending 表示文件发生了变化，等待防抖结束后调用 fn
+	pending bool
+}
+
+// changed 判断文件监听事件是否表示该文件发生了变化
+func (f *watchedFile) changed(ev fsnotify.Event) bool {
+	// 目录中的其他文件变化不触发重新加载
+	currentFile, _ := filepath.EvalSymlinks(f.path)
+	if filepath.Clean(ev.Name) != filepath.Clean(f.path) && currentFile == f.realPath {
+		return false
+	}
+	f.realPath = currentFile
+	return !ev.Has(fsnotify.Chmod)
+}
+
+// Start 开始监听配置文件、WatchFile 添加的文件和 SIGHUP 信号，阻塞直到上下文取消
+// 文件监听失败时只记录错误，仍然可以通过 SIGHUP 触发重新加载
+func (w *Watcher) Start(ctx context.Context) error {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	w.mu.RLock()
+	files := append([]*watchedFile{{path: w.path, fn: func() { _ = w.Reload() }}}, w.files...)
+	w.mu.RUnlock()
+
+	var fileEvents <-chan fsnotify.Event
+	var fileErrors <-chan error
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorw("创建配置文件监听器失败，仅支持 SIGHUP 重新加载", "error", err)
+	} else {
+		defer fw.Close()
+		fileEvents, fileErrors = fw.Events, fw.Errors
+		for _, f := range files {
+			// 监听所在目录而不是文件本身，以便处理编辑器替换文件和 Kubernetes ConfigMap 的符号链接切换
+			if err := fw.Add(filepath.Dir(f.path)); err != nil {
+				log.Errorw("监听配置文件目录失败，仅支持 SIGHUP 重新加载", "path", f.path, "error", err)
+			}
+			f.realPath, _ = filepath.EvalSymlinks(f.path)
+		}
+	}
+
+	log.Infow("开始监听配置变化", "path", w.path, "files", len(files)-1)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sighup:
+			log.Infow("接收到 SIGHUP 信号，重新加载配置")
+			for _, f := range files {
+				f.fn()
+			}
+		case ev, ok := <-fileEvents:
+			if !ok {
+				fileEvents = nil
+				continue
+			}
+			for _, f := range files {
+				if f.changed(ev) {
+					f.pending = true
+					debounce = time.After(reloadDebounce)
+				}
+			}
+		case err, ok := <-fileErrors:
+			if !ok {
+				fileErrors = nil
+				continue
+			}
+			log.Warnw("配置文件监听出错", "error", err)
+		case <-debounce:
+			debounce = nil
+			for _, f := range files {
+				if f.pending {
+					f.pending = false
+					f.fn()
+				}
+			}
+		}
+	}
+}
+
+// Stop 实现 app.Server 接口，监听在 Start 的上下文取消后自动停止
+func (w *Watcher) Stop(ctx context.Context) error {
+	return nil
+}
+
+// diffSections 返回两个配置之间发生变化的可热加载配置段
+func diffSections(prev, next *Config) []Section {
+	var sections []Section
+	if !reflect.DeepEqual(prev.Log, next.Log) {
+		sections = append(sections, SectionLog)
+	}
+	if !reflect.DeepEqual(prev.Middleware.CORS, next.Middleware.CORS) {
+		sections = append(sections, SectionCORS)
+	}
+	if !reflect.DeepEqual(prev.Middleware.RateLimit, next.Middleware.RateLimit) {
+		sections = append(sections, SectionRateLimit)
+	}
+	if prev.Middleware.Timeout != next.Middleware.Timeout ||
+		!reflect.DeepEqual(prev.Middleware.TimeoutRoutes, next.Middleware.TimeoutRoutes) ||
+		!reflect.DeepEqual(prev.Middleware.StreamingPaths, next.Middleware.StreamingPaths) {
+		sections = append(sections, SectionTimeout)
+	}
+	if !reflect.DeepEqual(prev.Middleware.GRPCDeadline, next.Middleware.GRPCDeadline) {
+		sections = append(sections, SectionGRPCDeadline)
+	}
+	if !reflect.DeepEqual(prev.Middleware.ClientIP, next.Middleware.ClientIP) {
+		sections = append(sections, SectionClientIP)
+	}
+	if !reflect.DeepEqual(prev.Middleware.IPFilter, next.Middleware.IPFilter) {
+		sections = append(sections, SectionIPFilter)
+	}
+	if !reflect.DeepEqual(prev.Observability.SkipPaths, next.Observability.SkipPaths) {
+		sections = append(sections, SectionSkipPaths)
+	}
+	if !reflect.DeepEqual(prev.Auth, next.Auth) {
+		sections = append(sections, SectionAuth)
+	}
+	if prev.Authz != next.Authz {
+		sections = append(sections, SectionAuthz)
+	}
+	return sections
+}
+
+// warnRestartRequired 对需要重启才能生效的配置变化输出警告
+func warnRestartRequired(prev, next *Config) {
+	if !reflect.DeepEqual(prev.Server, next.Server) {
+		log.Warnw("server 配置发生变化，需要重启才能生效")
+	}
+	if !reflect.DeepEqual(prev.Observability.Tracing, next.Observability.Tracing) ||
+		!reflect.DeepEqual(prev.Observability.Metrics, next.Observability.Metrics) {
+		log.Warnw("observability 配置发生变化，需要重启才能生效")
+	}
+}
+
+// containsAny 判断 a 中是否包含 b 中的任一元素
+func containsAny(a, b []Section) bool {
+	for _, s := range b {
+		if slices.Contains(a, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testConfig = `
+server:
+  http:
+    addr: ":8080"
+  grpc:
+    addr: ":9090"
+middleware:
+  timeout: %s
+  rate_limit:
+    enable: true
+    limit: 100
+    burst: %d
+`
+
+func writeConfig(t *testing.T, path, timeout string, burst int) {
+	t.Helper()
+	data := []byte(fmt.Sprintf(testConfig, timeout, burst))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "30s", 200)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	w := NewWatcher(path, cfg)
+	var timeoutEvents, allEvents []Event
+	w.Subscribe(func(e Event) error { timeoutEvents = append(timeoutEvents, e); return nil }, SectionTimeout)
+	w.Subscribe(func(e Event) error { allEvents = append(allEvents, e); return nil })
+
+	// 只修改限流配置，超时订阅者不应收到通知
+	writeConfig(t, path, "30s", 300)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("重新加载配置失败: %v", err)
+	}
+	if len(timeoutEvents) != 0 {
+		t.Errorf("超时订阅者不应收到通知: %+v", timeoutEvents)
+	}
+	if len(allEvents) != 1 || !allEvents[0].Changed(SectionRateLimit) {
+		t.Fatalf("期望收到限流配置变更通知: %+v", allEvents)
+	}
+	if allEvents[0].Old.Middleware.RateLimit.Burst != 200 || allEvents[0].New.Middleware.RateLimit.Burst != 300 {
+		t.Errorf("变更前后的配置不匹配: old=%d, new=%d",
+			allEvents[0].Old.Middleware.RateLimit.Burst, allEvents[0].New.Middleware.RateLimit.Burst)
+	}
+
+	// 修改超时时间
+	writeConfig(t, path, "10s", 300)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("重新加载配置失败: %v", err)
+	}
+	if len(timeoutEvents) != 1 || timeoutEvents[0].New.Middleware.Timeout != 10*time.Second {
+		t.Errorf("期望收到超时配置变更通知: %+v", timeoutEvents)
+	}
+
+	// 非法配置被拒绝，继续使用当前配置
+	writeConfig(t, path, "10s", 0)
+	if err := w.Reload(); err == nil {
+		t.Error("期望非法配置返回错误")
+	}
+	if got := w.Current().Middleware.RateLimit.Burst; got != 300 {
+		t.Errorf("非法配置不应生效: burst=%d", got)
+	}
+	if len(allEvents) != 2 {
+		t.Errorf("非法配置不应通知订阅者: %d", len(allEvents))
+	}
+}
+
+func TestWatcherReloadSubscriberError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "30s", 200)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	w := NewWatcher(path, cfg)
+	// applied 是订阅者当前应用的 burst，第二个订阅者拒绝 burst 为 300 的配置
+	applied := 200
+	w.Subscribe(func(e Event) error {
+		applied = e.New.Middleware.RateLimit.Burst
+		return nil
+	}, SectionRateLimit)
+	w.Subscribe(func(e Event) error {
+		if e.New.Middleware.RateLimit.Burst == 300 {
+			return errors.New("拒绝新配置")
+		}
+		return nil
+	}, SectionRateLimit)
+
+	// 订阅者返回错误时重新加载失败，已经应用的订阅者恢复原来的配置
+	writeConfig(t, path, "30s", 300)
+	if err := w.Reload(); err == nil {
+		t.Fatal("期望订阅者的错误导致重新加载失败")
+	}
+	if applied != 200 {
+		t.Errorf("订阅者没有恢复原来的配置: burst=%d", applied)
+	}
+	if got := w.Current().Middleware.RateLimit.Burst; got != 200 {
+		t.Errorf("重新加载失败后当前配置不应变化: burst=%d", got)
+	}
+	if got := testutil.ToFloat64(metrics.ConfigLastReloadSuccessful); got != 0 {
+		t.Errorf("config_last_reload_successful = %v，期望 0", got)
+	}
+
+	// 之后的重新加载以原来的配置为基准
+	writeConfig(t, path, "30s", 400)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("重新加载配置失败: %v", err)
+	}
+	if applied != 400 || w.Current().Middleware.RateLimit.Burst != 400 {
+		t.Errorf("新配置没有生效: applied=%d, current=%d", applied, w.Current().Middleware.RateLimit.Burst)
+	}
+	if got := testutil.ToFloat64(metrics.ConfigLastReloadSuccessful); got != 1 {
+		t.Errorf("config_last_reload_successful = %v，期望 1", got)
+	}
+}
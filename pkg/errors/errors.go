@@ -2,15 +2,20 @@ package errors
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+
+	"google.golang.org/grpc/status"
 )
 
 // Error 定义了标准错误响应结构
 type Error struct {
-	Code    int         `json:"code"`              // 错误码
-	Message string      `json:"message"`           // 错误信息
-	Details interface{} `json:"details,omitempty"` // 错误详情
+	Code     int               `json:"code"`               // 错误码
+	Message  string            `json:"message"`            // 错误信息
+	Reason   string            `json:"reason,omitempty"`   // 机器可读的错误原因，如 USER_NOT_FOUND
+	Metadata map[string]string `json:"metadata,omitempty"` // 错误的附加信息
+	Details  interface{}       `json:"details,omitempty"`  // 错误详情
 }
 
 // 实现 error 接口
@@ -18,6 +23,12 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("错误码: %d, 信息: %s", e.Code, e.Message)
 }
 
+// Is 判断两个错误的错误码是否相同，使 errors.Is 可以匹配由预定义错误派生的错误
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
 // NewError 创建一个新的错误实例
 func NewError(code int, message string) *Error {
 	return &Error{
@@ -26,14 +37,59 @@ func NewError(code int, message string) *Error {
 	}
 }
 
-// WithDetails 添加错误详情
+// clone 返回错误的副本，避免修改预定义的错误
+func (e *Error) clone() *Error {
+	c := *e
+	if e.Metadata != nil {
+		c.Metadata = make(map[string]string, len(e.Metadata))
+		for k, v := range e.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return &c
+}
+
+// WithDetails 返回添加了错误详情的新错误
 func (e *Error) WithDetails(details interface{}) *Error {
-	e.Details = details
-	return e
+	c := e.clone()
+	c.Details = details
+	return c
+}
+
+// WithReason 返回设置了错误原因的新错误
+func (e *Error) WithReason(reason string) *Error {
+	c := e.clone()
+	c.Reason = reason
+	return c
+}
+
+// WithMetadata 返回添加了附加信息的新错误
+func (e *Error) WithMetadata(key, value string) *Error {
+	c := e.clone()
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string)
+	}
+	c.Metadata[key] = value
+	return c
+}
+
+// httpStatusCodes 是与所在错误码段默认状态码不同的错误码
+var httpStatusCodes = map[int]int{
+	10001: http.StatusServiceUnavailable,
+	10002: http.StatusGatewayTimeout,
+	20101: http.StatusConflict,
+	20102: http.StatusBadRequest,
+	20301: http.StatusTooManyRequests,
+	30001: http.StatusGatewayTimeout,
+	40002: http.StatusNotFound,
+	50001: http.StatusNotFound,
 }
 
 // HTTPStatusCode 根据错误码获取对应的 HTTP 状态码
 func (e *Error) HTTPStatusCode() int {
+	if code, ok := httpStatusCodes[e.Code]; ok {
+		return code
+	}
 	switch e.Code / 100 {
 	case 101:
 		return http.StatusBadRequest // 10100-10199 参数验证错误
@@ -57,41 +113,41 @@ func (e *Error) HTTPStatusCode() int {
 // 预定义的系统错误
 var (
 	// 系统级错误
-	ErrInternal = NewError(10000, "系统内部错误")
-	ErrService  = NewError(10001, "服务暂时不可用")
-	ErrTimeout  = NewError(10002, "请求超时")
+	ErrInternal = NewError(10000, "系统内部错误").WithReason("INTERNAL")
+	ErrService  = NewError(10001, "服务暂时不可用").WithReason("SERVICE_UNAVAILABLE")
+	ErrTimeout  = NewError(10002, "请求超时").WithReason("TIMEOUT")
 
 	// 参数验证错误
-	ErrValidation   = NewError(10100, "参数验证失败")
-	ErrInvalidType  = NewError(10101, "参数类型错误")
-	ErrMissingParam = NewError(10102, "必填参数缺失")
+	ErrValidation   = NewError(10100, "参数验证失败").WithReason("VALIDATION_FAILED")
+	ErrInvalidType  = NewError(10101, "参数类型错误").WithReason("INVALID_TYPE")
+	ErrMissingParam = NewError(10102, "必填参数缺失").WithReason("MISSING_PARAM")
 
 	// 用户模块错误
-	ErrUserNotFound = NewError(20100, "用户未找到")
-	ErrUserExists   = NewError(20101, "用户已存在")
-	ErrInvalidUser  = NewError(20102, "用户名无效")
+	ErrUserNotFound = NewError(20100, "用户未找到").WithReason("USER_NOT_FOUND")
+	ErrUserExists   = NewError(20101, "用户已存在").WithReason("USER_EXISTS")
+	ErrInvalidUser  = NewError(20102, "用户名无效").WithReason("INVALID_USER")
 
 	// 认证模块错误
-	ErrUnauthorized = NewError(20200, "未授权访问")
-	ErrTokenExpired = NewError(20201, "访问令牌过期")
-	ErrInvalidToken = NewError(20202, "无效的访问令牌")
+	ErrUnauthorized = NewError(20200, "未授权访问").WithReason("UNAUTHORIZED")
+	ErrTokenExpired = NewError(20201, "访问令牌过期").WithReason("TOKEN_EXPIRED")
+	ErrInvalidToken = NewError(20202, "无效的访问令牌").WithReason("INVALID_TOKEN")
 
 	// 授权模块错误
-	ErrPermissionDenied = NewError(20300, "权限不足")
-	ErrRateLimit        = NewError(20301, "超出访问限制")
+	ErrPermissionDenied = NewError(20300, "权限不足").WithReason("PERMISSION_DENIED")
+	ErrRateLimit        = NewError(20301, "超出访问限制").WithReason("RATE_LIMITED")
 
 	// 第三方服务错误
-	ErrThirdParty        = NewError(30000, "第三方服务异常")
-	ErrThirdPartyTimeout = NewError(30001, "第三方服务超时")
+	ErrThirdParty        = NewError(30000, "第三方服务异常").WithReason("THIRD_PARTY_ERROR")
+	ErrThirdPartyTimeout = NewError(30001, "第三方服务超时").WithReason("THIRD_PARTY_TIMEOUT")
 
 	// 数据库错误
-	ErrDBConnection = NewError(40000, "数据库连接错误")
-	ErrDBQuery      = NewError(40001, "数据库查询错误")
-	ErrDBNotFound   = NewError(40002, "数据不存在")
+	ErrDBConnection = NewError(40000, "数据库连接错误").WithReason("DB_CONNECTION")
+	ErrDBQuery      = NewError(40001, "数据库查询错误").WithReason("DB_QUERY")
+	ErrDBNotFound   = NewError(40002, "数据不存在").WithReason("DB_NOT_FOUND")
 
 	// 缓存错误
-	ErrCacheService  = NewError(50000, "缓存服务错误")
-	ErrCacheNotFound = NewError(50001, "缓存键不存在")
+	ErrCacheService  = NewError(50000, "缓存服务错误").WithReason("CACHE_SERVICE")
+	ErrCacheNotFound = NewError(50001, "缓存键不存在").WithReason("CACHE_NOT_FOUND")
 )
 
 // WriteJSON 将错误信息写入 HTTP 响应
@@ -107,10 +163,18 @@ func FromError(err error) *Error {
 		return nil
 	}
 
-	if e, ok := err.(*Error); ok {
+	var e *Error
+	if errors.As(err, &e) {
 		return e
 	}
 
+	// gRPC 状态错误中携带了业务错误信息时还原为业务错误
+	if st, ok := status.FromError(err); ok {
+		if e, ok := FromStatus(st); ok {
+			return e
+		}
+	}
+
 	return ErrInternal.WithDetails(err.Error())
 }
 
@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain 是 errdetails.ErrorInfo 中标识业务错误来源的域
+const Domain = "go-protoc"
+
+// ErrorInfo 元数据中保留的键
+const (
+	// metadataCode 存放业务错误码
+	metadataCode = "code"
+	// metadataDetails 存放 JSON 编码的错误详情
+	metadataDetails = "details"
+)
+
+// GRPCCode 根据 HTTP 状态码获取对应的 gRPC 状态码
+func (e *Error) GRPCCode() codes.Code {
+	switch e.HTTPStatusCode() {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// GRPCStatus 将错误转换为 gRPC 状态，业务错误码、原因、附加信息和详情通过 errdetails.ErrorInfo 传递
+// 实现了该方法后，status.FromError、status.Convert 和 gRPC 服务端都能直接识别 *Error
+func (e *Error) GRPCStatus() *status.Status {
+	metadata := make(map[string]string, len(e.Metadata)+2)
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	metadata[metadataCode] = strconv.Itoa(e.Code)
+	if e.Details != nil {
+		if data, err := json.Marshal(e.Details); err == nil {
+			metadata[metadataDetails] = string(data)
+		}
+	}
+
+	st := status.New(e.GRPCCode(), e.Message)
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   e.Reason,
+		Domain:   Domain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromStatus 从 gRPC 状态中还原业务错误，状态中没有本域的 errdetails.ErrorInfo 时返回 false
+func FromStatus(st *status.Status) (*Error, bool) {
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != Domain {
+			continue
+		}
+
+		code, err := strconv.Atoi(info.GetMetadata()[metadataCode])
+		if err != nil {
+			continue
+		}
+
+		e := &Error{
+			Code:    code,
+			Message: st.Message(),
+			Reason:  info.GetReason(),
+		}
+		for k, v := range info.GetMetadata() {
+			switch k {
+			case metadataCode:
+			case metadataDetails:
+				var details interface{}
+				if err := json.Unmarshal([]byte(v), &details); err == nil {
+					e.Details = details
+				}
+			default:
+				if e.Metadata == nil {
+					e.Metadata = make(map[string]string)
+				}
+				e.Metadata[k] = v
+			}
+		}
+		return e, true
+	}
+	return nil, false
+}
@@ -0,0 +1,79 @@
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusRoundTrip(t *testing.T) {
+	original := ErrUserNotFound.
+		WithMetadata("user_id", "12345").
+		WithDetails(map[string]interface{}{"hint": "检查用户 ID"})
+
+	st := status.Convert(original)
+	if st.Code() != codes.NotFound {
+		t.Fatalf("gRPC 状态码不匹配: 期望=%s, 实际=%s", codes.NotFound, st.Code())
+	}
+
+	got, ok := FromStatus(st)
+	if !ok {
+		t.Fatal("期望从状态中还原业务错误")
+	}
+	if got.Code != 20100 || got.Message != "用户未找到" || got.Reason != "USER_NOT_FOUND" {
+		t.Errorf("业务错误不匹配: %+v", got)
+	}
+	if len(got.Metadata) != 1 || got.Metadata["user_id"] != "12345" {
+		t.Errorf("附加信息不匹配: %v", got.Metadata)
+	}
+	if details, _ := got.Details.(map[string]interface{}); details["hint"] != "检查用户 ID" {
+		t.Errorf("错误详情不匹配: %v", got.Details)
+	}
+	if !errors.Is(got, ErrUserNotFound) {
+		t.Error("期望 errors.Is 匹配预定义错误")
+	}
+
+	// 派生错误不应修改预定义错误
+	if ErrUserNotFound.Metadata != nil || ErrUserNotFound.Details != nil {
+		t.Errorf("预定义错误被修改: %+v", ErrUserNotFound)
+	}
+
+	// 普通状态没有业务错误信息
+	if _, ok := FromStatus(status.New(codes.Internal, "boom")); ok {
+		t.Error("期望普通状态无法还原业务错误")
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	// 定义测试用例
+	testCases := []struct {
+		err        *Error
+		httpStatus int
+		grpcCode   codes.Code
+	}{
+		{err: ErrInternal, httpStatus: http.StatusInternalServerError, grpcCode: codes.Internal},
+		{err: ErrTimeout, httpStatus: http.StatusGatewayTimeout, grpcCode: codes.DeadlineExceeded},
+		{err: ErrValidation, httpStatus: http.StatusBadRequest, grpcCode: codes.InvalidArgument},
+		{err: ErrUserExists, httpStatus: http.StatusConflict, grpcCode: codes.AlreadyExists},
+		{err: ErrInvalidToken, httpStatus: http.StatusUnauthorized, grpcCode: codes.Unauthenticated},
+		{err: ErrPermissionDenied, httpStatus: http.StatusForbidden, grpcCode: codes.PermissionDenied},
+		{err: ErrRateLimit, httpStatus: http.StatusTooManyRequests, grpcCode: codes.ResourceExhausted},
+		{err: ErrThirdParty, httpStatus: http.StatusBadGateway, grpcCode: codes.Unavailable},
+		{err: ErrCacheNotFound, httpStatus: http.StatusNotFound, grpcCode: codes.NotFound},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.err.Reason, func(t *testing.T) {
+			if got := tc.err.HTTPStatusCode(); got != tc.httpStatus {
+				t.Errorf("HTTP 状态码不匹配: 期望=%d, 实际=%d", tc.httpStatus, got)
+			}
+			if got := tc.err.GRPCCode(); got != tc.grpcCode {
+				t.Errorf("gRPC 状态码不匹配: 期望=%s, 实际=%s", tc.grpcCode, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// watchInterval 是 Watch 在没有状态变化通知时重新执行检查的周期
+const watchInterval = 5 * time.Second
+
+// GRPCService 基于 Registry 实现了标准的 grpc.health.v1.Health 服务
+//
+// 服务名的解析规则：
+//   - 空字符串表示整体就绪状态；
+//   - 已注册的检查名称只执行该检查；
+//   - 已注册到 gRPC 服务器上的服务名（由 lookup 判断）返回整体就绪状态；
+//   - 其他服务名返回 NOT_FOUND（Watch 返回 SERVICE_UNKNOWN）。
+type GRPCService struct {
+	healthpb.UnimplementedHealthServer
+	registry *Registry
+	lookup   func(service string) bool
+}
+
+// NewGRPCService 创建一个新的 GRPCService 实例，lookup 可以为 nil
+func NewGRPCService(registry *Registry, lookup func(service string) bool) *GRPCService {
+	return &GRPCService{
+		registry: registry,
+		lookup:   lookup,
+	}
+}
+
+var _ healthpb.HealthServer = &GRPCService{}
+
+// IsGRPCMethod 判断 gRPC 全方法名是否属于 grpc.health.v1.Health 服务
+// 健康检查与 HTTP 的 /livez、/readyz 一样不需要认证，也不受 IP 过滤和限流影响，避免探针失败
+func IsGRPCMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// Check 实现 grpc.health.v1.Health/Check
+func (s *GRPCService) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.status(ctx, req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "未知的服务: %s", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// List 实现 grpc.health.v1.Health/List，返回整体状态以及每个检查的状态
+func (s *GRPCService) List(ctx context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	statuses := make(map[string]*healthpb.HealthCheckResponse)
+	statuses[""] = &healthpb.HealthCheckResponse{Status: servingStatus(s.registry.Run(ctx, Readiness).Healthy)}
+	for _, kind := range []Kind{Liveness, Readiness} {
+		for _, res := range s.registry.Run(ctx, kind).Results {
+			statuses[res.Name] = &healthpb.HealthCheckResponse{Status: servingStatus(res.Err == nil)}
+		}
+	}
+	return &healthpb.HealthListResponse{Statuses: statuses}, nil
+}
+
+// Watch 实现 grpc.health.v1.Health/Watch，在状态变化时推送新的状态
+func (s *GRPCService) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		changed := s.registry.Changed()
+
+		st, ok := s.status(ctx, req.GetService())
+		if !ok {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return status.Errorf(codes.Canceled, "发送健康状态失败: %v", err)
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.Error(codes.Canceled, "客户端取消了 Watch")
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// status 计算指定服务的状态，第二个返回值表示服务是否已知
+func (s *GRPCService) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	if service == "" {
+		return servingStatus(s.registry.Run(ctx, Readiness).Healthy), true
+	}
+	if res, ok := s.registry.RunCheck(ctx, service); ok {
+		return servingStatus(res.Err == nil), true
+	}
+	if s.lookup != nil && s.lookup(service) {
+		return servingStatus(s.registry.Run(ctx, Readiness).Healthy), true
+	}
+	return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+}
+
+// servingStatus 将布尔值转换为 gRPC 健康状态
+func servingStatus(healthy bool) healthpb.HealthCheckResponse_ServingStatus {
+	if healthy {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,330 @@
+// Package health 提供存活 (liveness) 与就绪 (readiness) 检查的注册和执行能力，
+// 并以 HTTP (/livez、/readyz) 和 grpc.health.v1.Health 服务的形式对外暴露。
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+)
+
+// DefaultTimeout 是单个检查未显式指定超时时间时使用的默认超时
+const DefaultTimeout = 3 * time.Second
+
+// ShutdownCheckName 是应用进入关闭流程后在就绪报告中出现的检查名称
+const ShutdownCheckName = "shutdown"
+
+// ErrShuttingDown 表示应用正在关闭，不再接收新的流量
+var ErrShuttingDown = errors.New("应用正在关闭")
+
+// Kind 表示检查的类型
+type Kind int
+
+const (
+	// Liveness 存活检查，失败意味着进程需要被重启
+	Liveness Kind = iota
+	// Readiness 就绪检查，失败意味着实例暂时不应接收流量
+	Readiness
+)
+
+// String 返回检查类型的名称
+func (k Kind) String() string {
+	switch k {
+	case Liveness:
+		return "livez"
+	case Readiness:
+		return "readyz"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+// Checker 定义了一个健康检查
+type Checker interface {
+	// Check 执行检查，返回 nil 表示健康
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 是将普通函数适配为 Checker 的类型
+type CheckerFunc func(ctx context.Context) error
+
+// Check 实现 Checker 接口
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckOption 定义了用于配置单个检查的函数类型
+type CheckOption func(*check)
+
+// WithTimeout 设置检查的超时时间
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(c *check) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// WithCritical 设置检查是否关键，非关键检查失败只会出现在详细输出中，不影响整体结果
+func WithCritical(critical bool) CheckOption {
+	return func(c *check) {
+		c.critical = critical
+	}
+}
+
+// check 是一个已注册的检查
+type check struct {
+	name     string
+	checker  Checker
+	timeout  time.Duration
+	critical bool
+}
+
+// Result 是单个检查的执行结果
+type Result struct {
+	Name     string
+	Critical bool
+	Err      error
+	Duration time.Duration
+}
+
+// Report 是一组检查的执行结果
+type Report struct {
+	Kind    Kind
+	Healthy bool
+	Results []Result
+}
+
+// Registry 保存所有已注册的检查
+type Registry struct {
+	mu           sync.RWMutex
+	checks       map[Kind][]*check
+	shuttingDown atomic.Bool
+	// changed 在注册表状态变化时被关闭并替换，用于广播通知
+	changed chan struct{}
+}
+
+// NewRegistry 创建一个新的 Registry 实例
+func NewRegistry() *Registry {
+	return &Registry{
+		checks:  make(map[Kind][]*check),
+		changed: make(chan struct{}),
+	}
+}
+
+// defaultRegistry 是进程级默认的注册表，HTTPServer、GRPCServer 和 App 默认共享它
+var defaultRegistry = NewRegistry()
+
+// Default 返回默认的注册表
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// RegisterLiveness 向默认注册表注册一个存活检查
+func RegisterLiveness(name string, checker Checker, opts ...CheckOption) {
+	defaultRegistry.Register(Liveness, name, checker, opts...)
+}
+
+// RegisterReadiness 向默认注册表注册一个就绪检查
+func RegisterReadiness(name string, checker Checker, opts ...CheckOption) {
+	defaultRegistry.Register(Readiness, name, checker, opts...)
+}
+
+// Register 注册一个检查，同名检查会被替换
+func (r *Registry) Register(kind Kind, name string, checker Checker, opts ...CheckOption) {
+	c := &check{
+		name:     name,
+		checker:  checker,
+		timeout:  DefaultTimeout,
+		critical: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	replaced := false
+	for i, existing := range r.checks[kind] {
+		if existing.name == name {
+			r.checks[kind][i] = c
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		r.checks[kind] = append(r.checks[kind], c)
+	}
+	r.notifyLocked()
+	r.mu.Unlock()
+
+	if replaced {
+		log.Warnw("健康检查已存在，已被替换", "kind", kind.String(), "name", name)
+		return
+	}
+	log.Infow("已注册健康检查", "kind", kind.String(), "name", name, "critical", c.critical, "timeout", c.timeout)
+}
+
+// RegisterLiveness 注册一个存活检查
+func (r *Registry) RegisterLiveness(name string, checker Checker, opts ...CheckOption) {
+	r.Register(Liveness, name, checker, opts...)
+}
+
+// RegisterReadiness 注册一个就绪检查
+func (r *Registry) RegisterReadiness(name string, checker Checker, opts ...CheckOption) {
+	r.Register(Readiness, name, checker, opts...)
+}
+
+// Unregister 移除一个检查
+func (r *Registry) Unregister(kind Kind, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	checks := r.checks[kind]
+	for i, c := range checks {
+		if c.name == name {
+			r.checks[kind] = append(checks[:i:i], checks[i+1:]...)
+			r.notifyLocked()
+			return
+		}
+	}
+}
+
+// Shutdown 标记应用进入关闭流程，此后所有就绪检查立即失败
+func (r *Registry) Shutdown() {
+	if r.shuttingDown.Swap(true) {
+		return
+	}
+	log.Infow("应用进入关闭流程，就绪状态切换为 NOT_SERVING")
+	r.mu.Lock()
+	r.notifyLocked()
+	r.mu.Unlock()
+}
+
+// ShuttingDown 返回应用是否处于关闭流程中
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Changed 返回一个在注册表状态发生变化时被关闭的通道
+func (r *Registry) Changed() <-chan struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.changed
+}
+
+// notifyLocked 唤醒所有等待状态变化的调用方，调用方必须持有写锁
+func (r *Registry) notifyLocked() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Names 返回指定类型下所有检查的名称
+func (r *Registry) Names(kind Kind) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checks[kind]))
+	for _, c := range r.checks[kind] {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Run 并发执行指定类型的所有检查，exclude 中的检查会被跳过
+func (r *Registry) Run(ctx context.Context, kind Kind, exclude ...string) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks[kind]))
+	for _, c := range r.checks[kind] {
+		if !slices.Contains(exclude, c.name) {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Kind: kind, Healthy: true}
+	if kind == Readiness && r.ShuttingDown() {
+		report.Healthy = false
+		report.Results = append(report.Results, Result{
+			Name:     ShutdownCheckName,
+			Critical: true,
+			Err:      ErrShuttingDown,
+		})
+	}
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		if res.Err != nil && res.Critical {
+			report.Healthy = false
+		}
+	}
+	report.Results = append(report.Results, results...)
+	return report
+}
+
+// RunCheck 执行单个指定名称的检查，第二个返回值表示该检查是否存在
+func (r *Registry) RunCheck(ctx context.Context, name string) (Result, bool) {
+	r.mu.RLock()
+	var (
+		found *check
+		kind  Kind
+	)
+	for k, checks := range r.checks {
+		for _, c := range checks {
+			if c.name == name {
+				found, kind = c, k
+				break
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	if found == nil {
+		return Result{}, false
+	}
+	if kind == Readiness && r.ShuttingDown() {
+		return Result{Name: name, Critical: found.critical, Err: ErrShuttingDown}, true
+	}
+	return found.run(ctx), true
+}
+
+// run 在超时控制下执行检查，并将 panic 转换为错误
+func (c *check) run(ctx context.Context) (res Result) {
+	start := time.Now()
+	res = Result{Name: c.name, Critical: c.critical}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errCh <- fmt.Errorf("检查发生 panic: %v", p)
+			}
+		}()
+		errCh <- c.checker.Check(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		res.Err = err
+	case <-ctx.Done():
+		res.Err = fmt.Errorf("检查超时: %w", ctx.Err())
+	}
+	res.Duration = time.Since(start)
+	return res
+}
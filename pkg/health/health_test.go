@@ -0,0 +1,195 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRegistryRun(t *testing.T) {
+	ok := CheckerFunc(func(context.Context) error { return nil })
+	fail := CheckerFunc(func(context.Context) error { return errors.New("down") })
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// 定义测试用例
+	testCases := []struct {
+		name     string
+		register func(r *Registry)
+		expected bool
+	}{
+		{
+			name:     "没有检查",
+			register: func(r *Registry) {},
+			expected: true,
+		},
+		{
+			name: "全部通过",
+			register: func(r *Registry) {
+				r.RegisterReadiness("a", ok)
+				r.RegisterReadiness("b", ok)
+			},
+			expected: true,
+		},
+		{
+			name: "关键检查失败",
+			register: func(r *Registry) {
+				r.RegisterReadiness("a", ok)
+				r.RegisterReadiness("db", fail)
+			},
+			expected: false,
+		},
+		{
+			name: "非关键检查失败",
+			register: func(r *Registry) {
+				r.RegisterReadiness("cache", fail, WithCritical(false))
+			},
+			expected: true,
+		},
+		{
+			name: "检查超时",
+			register: func(r *Registry) {
+				r.RegisterReadiness("slow", slow, WithTimeout(10*time.Millisecond))
+			},
+			expected: false,
+		},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRegistry()
+			tc.register(r)
+
+			report := r.Run(context.Background(), Readiness)
+			if report.Healthy != tc.expected {
+				t.Errorf("健康状态不匹配: 期望=%v, 实际=%v, 结果=%+v", tc.expected, report.Healthy, report.Results)
+			}
+		})
+	}
+}
+
+func TestRegistryShutdown(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterLiveness("ping", CheckerFunc(func(context.Context) error { return nil }))
+	changed := r.Changed()
+
+	r.Shutdown()
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Shutdown 之后没有发出状态变化通知")
+	}
+	if r.Run(context.Background(), Readiness).Healthy {
+		t.Error("关闭流程中就绪检查应该失败")
+	}
+	if !r.Run(context.Background(), Liveness).Healthy {
+		t.Error("关闭流程不应影响存活检查")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterReadiness("ok", CheckerFunc(func(context.Context) error { return nil }))
+	r.RegisterReadiness("db", CheckerFunc(func(context.Context) error { return errors.New("down") }))
+
+	// 定义测试用例
+	testCases := []struct {
+		name         string
+		target       string
+		expectedCode int
+		contains     []string
+	}{
+		{
+			name:         "失败时返回503",
+			target:       "/readyz",
+			expectedCode: http.StatusServiceUnavailable,
+			contains:     []string{"[-]db failed: down", "readyz check failed"},
+		},
+		{
+			name:         "详细输出",
+			target:       "/readyz?verbose&exclude=db",
+			expectedCode: http.StatusOK,
+			contains:     []string{"[+]ok ok", "readyz check passed"},
+		},
+		{
+			name:         "排除失败的检查",
+			target:       "/readyz?exclude=db",
+			expectedCode: http.StatusOK,
+			contains:     []string{"ok"},
+		},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.Handler(Readiness).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("状态码不匹配: 期望=%d, 实际=%d", tc.expectedCode, rec.Code)
+			}
+			for _, s := range tc.contains {
+				if !strings.Contains(rec.Body.String(), s) {
+					t.Errorf("响应中缺少 '%s': %s", s, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestGRPCServiceCheck(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterReadiness("db", CheckerFunc(func(context.Context) error { return nil }))
+	svc := NewGRPCService(r, func(service string) bool { return service == "helloworld.v1.Greeter" })
+
+	// 定义测试用例
+	testCases := []struct {
+		name     string
+		service  string
+		expected healthpb.HealthCheckResponse_ServingStatus
+		wantErr  bool
+	}{
+		{name: "整体状态", service: "", expected: healthpb.HealthCheckResponse_SERVING},
+		{name: "单个检查", service: "db", expected: healthpb.HealthCheckResponse_SERVING},
+		{name: "已注册的服务", service: "helloworld.v1.Greeter", expected: healthpb.HealthCheckResponse_SERVING},
+		{name: "未知服务", service: "unknown", wantErr: true},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := svc.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tc.service})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Check返回了错误: %v", err)
+			}
+			if resp.GetStatus() != tc.expected {
+				t.Errorf("状态不匹配: 期望=%v, 实际=%v", tc.expected, resp.GetStatus())
+			}
+		})
+	}
+
+	r.Shutdown()
+	resp, err := svc.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check返回了错误: %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("关闭流程中应返回 NOT_SERVING, 实际=%v", resp.GetStatus())
+	}
+}
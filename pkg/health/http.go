@@ -0,0 +1,53 @@
+package health
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// Handler 返回执行指定类型检查的 HTTP 处理器
+//
+// 默认只返回 "ok" 或失败的检查列表；带上 ?verbose 参数时逐项输出每个检查的结果，
+// 通过 ?exclude=<name> 可以跳过指定检查（可重复）。
+// 所有关键检查通过时返回 200，否则返回 503。
+func (r *Registry) Handler(kind Kind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		_, verbose := query["verbose"]
+		report := r.Run(req.Context(), kind, query["exclude"]...)
+
+		var buf bytes.Buffer
+		for _, res := range report.Results {
+			switch {
+			case res.Err == nil:
+				if verbose {
+					fmt.Fprintf(&buf, "[+]%s ok\n", res.Name)
+				}
+			case !res.Critical:
+				if verbose {
+					fmt.Fprintf(&buf, "[!]%s failed (non-critical): %v\n", res.Name, res.Err)
+				}
+			default:
+				fmt.Fprintf(&buf, "[-]%s failed: %v\n", res.Name, res.Err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		if !report.Healthy {
+			fmt.Fprintf(&buf, "%s check failed\n", kind)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write(buf.Bytes())
+			return
+		}
+
+		if !verbose {
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+		fmt.Fprintf(&buf, "%s check passed\n", kind)
+		_, _ = w.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc/grpclog"
+	"k8s.io/klog/v2"
+)
+
+// 第三方库日志使用的日志记录器名称，可以通过 SetLoggerLevel 单独调整级别
+const (
+	GRPCLoggerName = "grpc"
+	KlogLoggerName = "klog"
+)
+
+// InstallBridges 将 log/slog 默认日志记录器、grpclog 和 klog 的输出转发到全局日志记录器
+// slog.SetDefault 同时会转发标准库 log 包的输出。grpclog.SetLoggerV2 不是并发安全的，需要在创建 gRPC 服务器和客户端之前调用
+func InstallBridges() {
+	slog.SetDefault(slog.New(NewSlogHandler("")))
+	grpclog.SetLoggerV2(NewGRPCLogger(0))
+	klog.SetSlogLogger(slog.New(NewSlogHandler(KlogLoggerName)))
+}
+
+// grpcLogger 是使用全局日志记录器输出的 grpclog.LoggerV2
+// gRPC 的 Info 日志较多，记录为调试级别，需要时通过 SetLoggerLevel("grpc", "debug") 打开
+type grpcLogger struct {
+	verbosity int
+}
+
+var _ grpclog.LoggerV2 = &grpcLogger{}
+
+// NewGRPCLogger 返回一个使用名称为 grpc 的日志记录器输出的 grpclog.LoggerV2，verbosity 是 V 方法允许的最大详细级别
+func NewGRPCLogger(verbosity int) grpclog.LoggerV2 {
+	return &grpcLogger{verbosity: verbosity}
+}
+
+func (g *grpcLogger) Info(args ...interface{}) {
+	Named(GRPCLoggerName).Debugw(fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Infoln(args ...interface{}) {
+	Named(GRPCLoggerName).Debugw(sprintln(args))
+}
+
+func (g *grpcLogger) Infof(format string, args ...interface{}) {
+	Named(GRPCLoggerName).Debugf(format, args...)
+}
+
+func (g *grpcLogger) Warning(args ...interface{}) {
+	Named(GRPCLoggerName).Warnw(fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Warningln(args ...interface{}) {
+	Named(GRPCLoggerName).Warnw(sprintln(args))
+}
+
+func (g *grpcLogger) Warningf(format string, args ...interface{}) {
+	Named(GRPCLoggerName).Warnf(format, args...)
+}
+
+func (g *grpcLogger) Error(args ...interface{}) {
+	Named(GRPCLoggerName).Errorw(fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Errorln(args ...interface{}) {
+	Named(GRPCLoggerName).Errorw(sprintln(args))
+}
+
+func (g *grpcLogger) Errorf(format string, args ...interface{}) {
+	Named(GRPCLoggerName).Errorf(format, args...)
+}
+
+func (g *grpcLogger) Fatal(args ...interface{}) {
+	Named(GRPCLoggerName).Fatalw(fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Fatalln(args ...interface{}) {
+	Named(GRPCLoggerName).Fatalw(sprintln(args))
+}
+
+func (g *grpcLogger) Fatalf(format string, args ...interface{}) {
+	Named(GRPCLoggerName).Fatalf(format, args...)
+}
+
+// V 判断是否记录详细级别为 l 的日志
+func (g *grpcLogger) V(l int) bool {
+	return l <= g.verbosity
+}
+
+// sprintln 与 fmt.Sprintln 相同，但去掉末尾的换行
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 上下文中自动附加到日志的字段名
+const (
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+	KeyRequestID = "request_id"
+	KeySubject   = "subject"
+)
+
+type (
+	// loggerKey 是存放日志记录器的上下文键
+	loggerKey struct{}
+	// requestIDKey 是存放请求 ID 的上下文键
+	requestIDKey struct{}
+	// subjectKey 是存放已认证主体的上下文键
+	subjectKey struct{}
+)
+
+// WithContext 返回一个携带日志记录器的新上下文，该日志记录器在上下文原有日志记录器的基础上添加了 keysAndValues
+// 中间件通过它为请求注入日志记录器，处理器通过 FromContext 取出
+func WithContext(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	l := loggerFromContext(ctx)
+	if len(keysAndValues) > 0 {
+		l = l.WithValues(keysAndValues...)
+	}
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext 返回上下文中的日志记录器，没有时返回全局日志记录器
+// 返回的日志记录器会自动附加上下文中的 trace_id、span_id、request_id 和 subject，缺失的字段不会输出
+func FromContext(ctx context.Context) Logger {
+	l := loggerFromContext(ctx)
+	if fields := contextFields(ctx); len(fields) > 0 {
+		return l.WithValues(fields...)
+	}
+	return l
+}
+
+// FromContextNamed 与 FromContext 相同，但返回名称为 name 的子日志记录器，便于按模块调整级别
+func FromContextNamed(ctx context.Context, name string) Logger {
+	l := loggerFromContext(ctx)
+	if n, ok := l.(namer); ok {
+		l = n.Named(name)
+	}
+	if fields := contextFields(ctx); len(fields) > 0 {
+		return l.WithValues(fields...)
+	}
+	return l
+}
+
+// loggerFromContext 返回上下文中存放的日志记录器，没有时返回全局日志记录器
+func loggerFromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return L()
+}
+
+// contextFields 从上下文中提取需要附加到日志的字段
+// 这些字段在记录日志时才提取，因此注入日志记录器之后才放入上下文的请求 ID 和主体同样会被记录
+func contextFields(ctx context.Context) []interface{} {
+	var fields []interface{}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		fields = append(fields, KeyTraceID, spanCtx.TraceID().String(), KeySpanID, spanCtx.SpanID().String())
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		fields = append(fields, KeyRequestID, id)
+	}
+	if subject := SubjectFromContext(ctx); subject != "" {
+		fields = append(fields, KeySubject, subject)
+	}
+	return fields
+}
+
+// ContextWithRequestID 返回一个携带请求 ID 的新上下文
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext 返回上下文中的请求 ID，没有时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithSubject 返回一个携带已认证主体的新上下文，认证中间件在确认调用方身份后调用
+func ContextWithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext 返回上下文中的已认证主体，没有时返回空字符串
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// useObserver 将全局日志记录器替换为记录到内存的日志记录器，测试结束后恢复
+func useObserver(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	mu.Lock()
+	old := setStd(&zapLogger{z: zap.New(core), level: zap.NewAtomicLevel()})
+	mu.Unlock()
+
+	t.Cleanup(func() {
+		mu.Lock()
+		setStd(old)
+		mu.Unlock()
+	})
+	return logs
+}
+
+func TestFromContext(t *testing.T) {
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x02},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name string
+		ctx  func() context.Context
+		want map[string]interface{}
+	}{
+		{
+			name: "空上下文",
+			ctx:  context.Background,
+			want: map[string]interface{}{},
+		},
+		{
+			name: "附加追踪、请求 ID 和主体",
+			ctx: func() context.Context {
+				ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+				ctx = ContextWithRequestID(ctx, "req-1")
+				return ContextWithSubject(ctx, "alice")
+			},
+			want: map[string]interface{}{
+				KeyTraceID:   spanCtx.TraceID().String(),
+				KeySpanID:    spanCtx.SpanID().String(),
+				KeyRequestID: "req-1",
+				KeySubject:   "alice",
+			},
+		},
+		{
+			name: "注入的日志记录器保留字段，之后放入的请求 ID 同样被记录",
+			ctx: func() context.Context {
+				ctx := WithContext(context.Background(), "method", "GET")
+				return ContextWithRequestID(ctx, "req-2")
+			},
+			want: map[string]interface{}{
+				"method":     "GET",
+				KeyRequestID: "req-2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := useObserver(t)
+			FromContext(tt.ctx()).Infow("hello")
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("期望 1 条日志，实际 %d 条", len(entries))
+			}
+			got := entries[0].ContextMap()
+			if len(got) != len(tt.want) {
+				t.Fatalf("日志字段 = %v，期望 %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("字段 %s = %v，期望 %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggerLevel 描述一个命名日志记录器的级别覆盖
+type LoggerLevel struct {
+	// Name 是日志记录器名称，如 middleware.http，覆盖同时作用于其子日志记录器
+	Name string `json:"name"`
+	// Level 是日志级别
+	Level string `json:"level"`
+	// ExpiresAt 是临时级别自动恢复的时间，永久设置时为空
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// LevelStatus 描述当前生效的日志级别
+type LevelStatus struct {
+	// Level 是全局日志级别
+	Level string `json:"level"`
+	// ExpiresAt 是临时全局级别自动恢复的时间，永久设置时为空
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Loggers 是按名称排序的命名日志记录器级别覆盖
+	Loggers []LoggerLevel `json:"loggers"`
+}
+
+// levelEntry 记录一个级别设置，以及临时设置到期后需要恢复的永久设置
+type levelEntry struct {
+	level     zapcore.Level
+	expiresAt time.Time
+	// temporary 表示当前是带 TTL 的临时设置
+	temporary bool
+	// hasBase 和 base 是临时设置之前的永久设置，hasBase 为 false 表示恢复时移除覆盖
+	hasBase bool
+	base    zapcore.Level
+	// gen 用于识别过期的恢复定时器
+	gen   uint64
+	timer *time.Timer
+}
+
+// stop 停止等待中的恢复定时器
+func (e *levelEntry) stop() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+}
+
+// levelSnapshot 是命名日志记录器级别覆盖的只读快照，供记录日志时无锁读取
+type levelSnapshot struct {
+	levels map[string]zapcore.Level
+	// min 是所有覆盖中的最低级别
+	min zapcore.Level
+}
+
+// levelRegistry 管理运行时修改的日志级别，包括命名日志记录器的级别覆盖和临时设置的自动恢复
+// 级别覆盖是进程级的，重新初始化全局日志记录器后仍然有效
+type levelRegistry struct {
+	mu      sync.Mutex
+	global  levelEntry
+	loggers map[string]*levelEntry
+	gen     uint64
+
+	snapshot atomic.Pointer[levelSnapshot]
+}
+
+// runtimeLevels 是全局的运行时日志级别注册表
+var runtimeLevels = &levelRegistry{loggers: make(map[string]*levelEntry)}
+
+// SetLoggerLevel 在运行时修改日志级别
+// name 为空时修改全局级别，否则修改名称为 name 的日志记录器及其子日志记录器的级别，level 为空表示移除该名称的级别覆盖
+// ttl 大于 0 时为临时设置，到期后恢复为最近一次永久设置
+func SetLoggerLevel(name, level string, ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("无效的 TTL %s", ttl)
+	}
+	if name == "" {
+		return runtimeLevels.setGlobal(level, ttl)
+	}
+	return runtimeLevels.setLogger(name, level, ttl)
+}
+
+// CurrentLevels 返回当前生效的全局级别和命名日志记录器的级别覆盖
+func CurrentLevels() LevelStatus {
+	return runtimeLevels.status()
+}
+
+// parseLevel 解析日志级别
+func parseLevel(level string) (zapcore.Level, error) {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return lvl, fmt.Errorf("不支持的日志级别 %q", level)
+	}
+	return lvl, nil
+}
+
+// setGlobal 修改全局日志记录器的级别
+func (r *levelRegistry) setGlobal(level string, ttl time.Duration) error {
+	if level == "" {
+		return errors.New("全局日志级别不能为空")
+	}
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, err := stdLevel()
+	if err != nil {
+		return err
+	}
+	if err := setStdLevel(lvl); err != nil {
+		return err
+	}
+
+	e := &r.global
+	if ttl > 0 && !e.temporary {
+		e.hasBase, e.base = true, current
+	}
+	r.apply(e, lvl, ttl, func(gen uint64) { r.revertGlobal(gen) })
+	return nil
+}
+
+// revertGlobal 将临时全局级别恢复为永久设置
+func (r *levelRegistry) revertGlobal(gen uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := &r.global
+	if e.gen != gen || !e.temporary {
+		return
+	}
+	if err := setStdLevel(e.base); err != nil {
+		Errorw("恢复全局日志级别失败", "level", e.base.String(), "error", err)
+	}
+	*e = levelEntry{level: e.base, gen: e.gen}
+}
+
+// resetGlobal 取消临时全局级别，在全局日志记录器被替换时调用
+func (r *levelRegistry) resetGlobal() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.global.stop()
+	r.global = levelEntry{gen: r.global.gen}
+}
+
+// setLogger 修改命名日志记录器的级别覆盖
+func (r *levelRegistry) setLogger(name, level string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.loggers[name]
+	if level == "" {
+		if ok {
+			e.stop()
+			delete(r.loggers, name)
+			r.publish()
+		}
+		return nil
+	}
+
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		e = &levelEntry{}
+		r.loggers[name] = e
+	} else if ttl > 0 && !e.temporary {
+		e.hasBase, e.base = true, e.level
+	}
+	r.apply(e, lvl, ttl, func(gen uint64) { r.revertLogger(name, gen) })
+	r.publish()
+	return nil
+}
+
+// revertLogger 将命名日志记录器的临时级别恢复为永久设置，没有永久设置时移除覆盖
+func (r *levelRegistry) revertLogger(name string, gen uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.loggers[name]
+	if !ok || e.gen != gen || !e.temporary {
+		return
+	}
+	if e.hasBase {
+		*e = levelEntry{level: e.base, gen: e.gen}
+	} else {
+		delete(r.loggers, name)
+	}
+	r.publish()
+}
+
+// apply 更新级别设置，ttl 大于 0 时启动恢复定时器，调用方需要持有锁
+func (r *levelRegistry) apply(e *levelEntry, lvl zapcore.Level, ttl time.Duration, revert func(gen uint64)) {
+	e.stop()
+	r.gen++
+	e.gen = r.gen
+	e.level = lvl
+
+	if ttl <= 0 {
+		e.temporary, e.hasBase, e.expiresAt = false, false, time.Time{}
+		return
+	}
+	gen := e.gen
+	e.temporary = true
+	e.expiresAt = time.Now().Add(ttl)
+	e.timer = time.AfterFunc(ttl, func() { revert(gen) })
+}
+
+// publish 发布新的级别覆盖快照，调用方需要持有锁
+func (r *levelRegistry) publish() {
+	if len(r.loggers) == 0 {
+		r.snapshot.Store(nil)
+		return
+	}
+	snap := &levelSnapshot{levels: make(map[string]zapcore.Level, len(r.loggers)), min: zapcore.FatalLevel}
+	for name, e := range r.loggers {
+		snap.levels[name] = e.level
+		if e.level < snap.min {
+			snap.min = e.level
+		}
+	}
+	r.snapshot.Store(snap)
+}
+
+// status 返回当前的级别设置
+func (r *levelRegistry) status() LevelStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := LevelStatus{Loggers: make([]LoggerLevel, 0, len(r.loggers))}
+	if lvl, err := stdLevel(); err == nil {
+		st.Level = lvl.String()
+	}
+	st.ExpiresAt = expiresAt(&r.global)
+	for name, e := range r.loggers {
+		st.Loggers = append(st.Loggers, LoggerLevel{Name: name, Level: e.level.String(), ExpiresAt: expiresAt(e)})
+	}
+	sort.Slice(st.Loggers, func(i, j int) bool { return st.Loggers[i].Name < st.Loggers[j].Name })
+	return st
+}
+
+// expiresAt 返回临时设置的到期时间，永久设置返回 nil
+func expiresAt(e *levelEntry) *time.Time {
+	if !e.temporary {
+		return nil
+	}
+	t := e.expiresAt
+	return &t
+}
+
+// enabledAny 判断是否存在允许 lvl 级别日志的级别覆盖
+func (r *levelRegistry) enabledAny(lvl zapcore.Level) bool {
+	snap := r.snapshot.Load()
+	return snap != nil && lvl >= snap.min
+}
+
+// enabled 判断名称为 name 的日志记录器是否记录 lvl 级别的日志
+// 使用最长匹配的名称覆盖，如 middleware.http.cors 匹配 middleware.http 的覆盖，没有覆盖时使用全局级别
+func (r *levelRegistry) enabled(name string, lvl, global zapcore.Level) bool {
+	snap := r.snapshot.Load()
+	if snap == nil {
+		return lvl >= global
+	}
+	for n := name; n != ""; {
+		if l, ok := snap.levels[n]; ok {
+			return lvl >= l
+		}
+		i := strings.LastIndexByte(n, '.')
+		if i < 0 {
+			break
+		}
+		n = n[:i]
+	}
+	return lvl >= global
+}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// useLevelObserver 将全局日志记录器替换为按运行时级别过滤、记录到内存的日志记录器，测试结束后恢复
+func useLevelObserver(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	atomicLevel := zap.NewAtomicLevelAt(level)
+
+	mu.Lock()
+	old := setStd(&zapLogger{
+		z:     zap.New(&levelCore{Core: core, root: "app", level: atomicLevel}).Named("app"),
+		level: atomicLevel,
+	})
+	mu.Unlock()
+
+	t.Cleanup(func() {
+		runtimeLevels.mu.Lock()
+		for name, e := range runtimeLevels.loggers {
+			e.stop()
+			delete(runtimeLevels.loggers, name)
+		}
+		runtimeLevels.publish()
+		runtimeLevels.global.stop()
+		runtimeLevels.global = levelEntry{}
+		runtimeLevels.mu.Unlock()
+
+		mu.Lock()
+		setStd(old)
+		mu.Unlock()
+	})
+	return logs
+}
+
+func TestSetLoggerLevel(t *testing.T) {
+	logs := useLevelObserver(t, zapcore.InfoLevel)
+
+	if err := SetLoggerLevel("middleware.http", "debug", 0); err != nil {
+		t.Fatalf("设置级别失败: %v", err)
+	}
+
+	Named("middleware.http").Debugw("http 子日志记录器")
+	Named("middleware.http.cors").Debugw("继承父名称的覆盖")
+	Named("middleware.grpc").Debugw("没有覆盖")
+	Debugw("全局日志记录器")
+
+	if got := logs.Len(); got != 2 {
+		t.Fatalf("期望记录 2 条调试日志，实际 %d 条: %v", got, logs.All())
+	}
+
+	st := CurrentLevels()
+	if st.Level != "info" || len(st.Loggers) != 1 || st.Loggers[0].Name != "middleware.http" || st.Loggers[0].Level != "debug" {
+		t.Errorf("当前级别 = %+v", st)
+	}
+
+	if err := SetLoggerLevel("middleware.http", "", 0); err != nil {
+		t.Fatalf("移除级别覆盖失败: %v", err)
+	}
+	Named("middleware.http").Debugw("移除覆盖后不再记录")
+	if got := logs.Len(); got != 2 {
+		t.Errorf("移除覆盖后仍然记录了调试日志")
+	}
+
+	if err := SetLoggerLevel("middleware.http", "verbose", 0); err == nil {
+		t.Error("不支持的级别应该返回错误")
+	}
+}
+
+func TestSetLoggerLevelTTL(t *testing.T) {
+	useLevelObserver(t, zapcore.InfoLevel)
+
+	tests := []struct {
+		name   string
+		logger string
+		// before 是设置临时级别之前的永久设置，空表示没有
+		before string
+		want   func(LevelStatus) bool
+	}{
+		{
+			name:   "全局级别到期后恢复",
+			logger: "",
+			want:   func(st LevelStatus) bool { return st.Level == "info" && st.ExpiresAt == nil },
+		},
+		{
+			name:   "没有永久设置的覆盖到期后移除",
+			logger: "middleware.http",
+			want:   func(st LevelStatus) bool { return len(st.Loggers) == 0 },
+		},
+		{
+			name:   "覆盖到期后恢复为永久设置",
+			logger: "middleware.grpc",
+			before: "warn",
+			want: func(st LevelStatus) bool {
+				return len(st.Loggers) == 1 && st.Loggers[0].Level == "warn" && st.Loggers[0].ExpiresAt == nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != "" {
+				if err := SetLoggerLevel(tt.logger, tt.before, 0); err != nil {
+					t.Fatalf("设置永久级别失败: %v", err)
+				}
+				t.Cleanup(func() { _ = SetLoggerLevel(tt.logger, "", 0) })
+			}
+			if err := SetLoggerLevel(tt.logger, "debug", 20*time.Millisecond); err != nil {
+				t.Fatalf("设置临时级别失败: %v", err)
+			}
+			if tt.want(CurrentLevels()) {
+				t.Fatalf("临时级别没有生效: %+v", CurrentLevels())
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for !tt.want(CurrentLevels()) {
+				if time.Now().After(deadline) {
+					t.Fatalf("临时级别没有按时恢复: %+v", CurrentLevels())
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package log
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Logger 定义了项目所需的日志接口。
@@ -43,115 +46,201 @@ type Logger interface {
 }
 
 var (
-	// mu 保护对全局日志记录器的访问
+	// mu 串行化全局日志记录器的替换、重新打开和级别修改
 	mu sync.Mutex
-	// std 是全局日志记录器
-	std Logger
+	// std 是全局日志记录器，读取时不加锁，重新初始化与并发的日志调用之间没有数据竞争
+	std atomic.Pointer[stdLogger]
 )
 
+// stdLogger 包装全局日志记录器，使不同实现的 Logger 可以保存在同一个 atomic.Pointer 中
+type stdLogger struct {
+	Logger
+}
+
+// setStd 替换全局日志记录器并返回原来的日志记录器
+func setStd(logger Logger) Logger {
+	if old := std.Swap(&stdLogger{Logger: logger}); old != nil {
+		return old.Logger
+	}
+	return nil
+}
+
 // init 在包初始化时设置一个默认的 failsafe 日志记录器。
 func init() {
 	// 这个 failsafe logger 在 Init() 被调用前使用。
 	// 它保证了在主 logger 初始化失败时，日志功能依然可用。
 	zapLogger, _ := zap.NewProduction() // zap.NewProduction() 不会返回错误
-	std = &SugaredLogger{SugaredLogger: zapLogger.Sugar()}
+	setStd(&SugaredLogger{SugaredLogger: zapLogger.Sugar()})
 }
 
 // Init 使用给定的选项初始化全局日志记录器。
 // 它会替换掉 failsafe 日志记录器。
 func Init(opts *Options) (err error) {
+	// 新的日志记录器使用配置中的级别，取消临时全局级别
+	runtimeLevels.resetGlobal()
+
 	mu.Lock()
 	defer mu.Unlock()
 
 	// 在替换前，同步旧的日志记录器。
-	std.Sync()
+	L().Sync()
 
 	logger, err := NewZapLogger(opts)
 	if err != nil {
 		return err
 	}
-	std = logger
+	old := setStd(logger)
+
+	// 释放旧日志记录器的日志文件，新日志记录器使用的同一路径的文件保持打开，
+	// 仍在使用旧日志记录器的请求写入同一个文件；新配置不再使用的文件被关闭，之后旧日志记录器的写入被丢弃
+	if c, ok := old.(fileCloser); ok {
+		_ = c.closeFiles()
+	}
 
 	return nil
 }
 
-// L 返回全局日志记录器。
-func L() Logger {
+// leveler 是支持在运行时修改日志级别的日志记录器
+type leveler interface {
+	Level() zapcore.Level
+	SetLevel(level zapcore.Level)
+}
+
+// reopener 是支持重新打开日志文件的日志记录器
+type reopener interface {
+	Reopen() error
+}
+
+// fileCloser 是打开了日志文件的日志记录器
+type fileCloser interface {
+	closeFiles() error
+}
+
+// Reopen 重新打开全局日志记录器的日志文件，用于配合 logrotate 等外部工具。
+func Reopen() error {
 	mu.Lock()
 	defer mu.Unlock()
-	return std
+	if r, ok := L().(reopener); ok {
+		return r.Reopen()
+	}
+	return nil
 }
 
-// Sync 同步全局日志记录器。
-func Sync() {
+// namer 是支持创建命名子日志记录器的日志记录器
+type namer interface {
+	Named(name string) Logger
+}
+
+// SetLevel 在运行时修改全局日志记录器的级别。
+// 会取消通过 SetLoggerLevel 设置的临时全局级别。
+func SetLevel(level string) error {
+	return runtimeLevels.setGlobal(level, 0)
+}
+
+// stdLevel 返回全局日志记录器的级别
+func stdLevel() (zapcore.Level, error) {
 	mu.Lock()
 	defer mu.Unlock()
-	std.Sync()
+	l, ok := L().(leveler)
+	if !ok {
+		return zapcore.InfoLevel, fmt.Errorf("当前日志记录器 %T 不支持修改日志级别", L())
+	}
+	return l.Level(), nil
+}
+
+// setStdLevel 修改全局日志记录器的级别
+func setStdLevel(level zapcore.Level) error {
+	mu.Lock()
+	defer mu.Unlock()
+	l, ok := L().(leveler)
+	if !ok {
+		return fmt.Errorf("当前日志记录器 %T 不支持修改日志级别", L())
+	}
+	l.SetLevel(level)
+	return nil
+}
+
+// Named 返回全局日志记录器的命名子日志记录器，名称用于按模块调整日志级别，如 middleware.http。
+// 全局日志记录器可能被重新初始化，调用方不应长期持有返回值。
+func Named(name string) Logger {
+	l := L()
+	if n, ok := l.(namer); ok {
+		return n.Named(name)
+	}
+	return l
+}
+
+// L 返回全局日志记录器。
+func L() Logger {
+	return std.Load().Logger
+}
+
+// Sync 同步全局日志记录器。
+func Sync() {
+	L().Sync()
 }
 
 // Debugf 使用全局日志记录器记录一条调试级别的消息。
 func Debugf(format string, args ...interface{}) {
-	std.Debugf(format, args...)
+	L().Debugf(format, args...)
 }
 
 // Infof 使用全局日志记录器记录一条信息级别的消息。
 func Infof(format string, args ...interface{}) {
-	std.Infof(format, args...)
+	L().Infof(format, args...)
 }
 
 // Warnf 使用全局日志记录器记录一条警告级别的消息。
 func Warnf(format string, args ...interface{}) {
-	std.Warnf(format, args...)
+	L().Warnf(format, args...)
 }
 
 // Errorf 使用全局日志记录器记录一条错误级别的消息。
 func Errorf(format string, args ...interface{}) {
-	std.Errorf(format, args...)
+	L().Errorf(format, args...)
 }
 
 // Panicf 使用全局日志记录器记录一条 panic 级别的消息。
 func Panicf(format string, args ...interface{}) {
-	std.Panicf(format, args...)
+	L().Panicf(format, args...)
 }
 
 // Fatalf 使用全局日志记录器记录一条致命级别的消息。
 func Fatalf(format string, args ...interface{}) {
-	std.Fatalf(format, args...)
+	L().Fatalf(format, args...)
 }
 
 // Debugw 使用全局日志记录器记录一条调试级别的结构化消息。
 func Debugw(msg string, keysAndValues ...interface{}) {
-	std.Debugw(msg, keysAndValues...)
+	L().Debugw(msg, keysAndValues...)
 }
 
 // Infow 使用全局日志记录器记录一条信息级别的结构化消息。
 func Infow(msg string, keysAndValues ...interface{}) {
-	std.Infow(msg, keysAndValues...)
+	L().Infow(msg, keysAndValues...)
 }
 
 // Warnw 使用全局日志记录器记录一条警告级别的结构化消息。
 func Warnw(msg string, keysAndValues ...interface{}) {
-	std.Warnw(msg, keysAndValues...)
+	L().Warnw(msg, keysAndValues...)
 }
 
 // Errorw 使用全局日志记录器记录一条错误级别的结构化消息。
 func Errorw(msg string, keysAndValues ...interface{}) {
-	std.Errorw(msg, keysAndValues...)
+	L().Errorw(msg, keysAndValues...)
 }
 
 // Panicw 使用全局日志记录器记录一条 panic 级别的结构化消息。
 func Panicw(msg string, keysAndValues ...interface{}) {
-	std.Panicw(msg, keysAndValues...)
+	L().Panicw(msg, keysAndValues...)
 }
 
 // Fatalw 使用全局日志记录器记录一条致命级别的结构化消息。
 func Fatalw(msg string, keysAndValues ...interface{}) {
-	std.Fatalw(msg, keysAndValues...)
+	L().Fatalw(msg, keysAndValues...)
 }
 
 // WithValues 返回一个包含额外上下文的全局日志记录器。
 func WithValues(keysAndValues ...interface{}) Logger {
-	mu.Lock()
-	defer mu.Unlock()
-	return std.WithValues(keysAndValues...)
+	return L().WithValues(keysAndValues...)
 }
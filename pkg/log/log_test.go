@@ -0,0 +1,57 @@
+package log
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestInitConcurrent 在记录日志的同时重新初始化全局日志记录器，配合 go test -race 检查数据竞争
+func TestInitConcurrent(t *testing.T) {
+	mu.Lock()
+	old := L()
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		cur := setStd(old)
+		mu.Unlock()
+		if c, ok := cur.(fileCloser); ok {
+			_ = c.closeFiles()
+		}
+	})
+
+	opts := NewOptions()
+	opts.Format = "json"
+	opts.OutputPaths = []string{filepath.Join(t.TempDir(), "app.log")}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				Infow("并发日志", "n", i)
+				Debugf("并发日志 %d", i)
+				Named("test").Infow("命名日志记录器")
+				WithValues("k", "v").Infow("附加字段")
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := Init(opts); err != nil {
+			t.Fatalf("初始化日志记录器失败: %v", err)
+		}
+		if err := Reopen(); err != nil {
+			t.Fatalf("重新打开日志文件失败: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
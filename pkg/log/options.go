@@ -1,6 +1,8 @@
 package log
 
 import (
+	"time"
+
 	"go.uber.org/zap/zapcore"
 )
 
@@ -20,6 +22,50 @@ type Options struct {
 	EnableCaller bool `json:"enable-caller" mapstructure:"enable-caller"`
 	// Name 是日志记录器的名称。
 	Name string `json:"name" mapstructure:"name"`
+	// Rotation 是 OutputPaths 中日志文件的轮转配置。
+	Rotation RotationOptions `json:"rotation" mapstructure:"rotation"`
+	// ErrorRotation 是 ErrorOutputPaths 中日志文件的轮转配置。
+	ErrorRotation RotationOptions `json:"error-rotation" mapstructure:"error-rotation"`
+	// Sampling 是日志采样配置。
+	Sampling SamplingOptions `json:"sampling" mapstructure:"sampling"`
+	// StacktraceLevel 是记录调用栈的最低日志级别，为空时不记录调用栈。
+	StacktraceLevel string `json:"stacktrace-level" mapstructure:"stacktrace-level"`
+	// Redaction 是敏感信息脱敏配置，默认开启。
+	Redaction RedactionOptions `json:"redaction" mapstructure:"redaction"`
+}
+
+// SamplingOptions 是日志采样配置。
+// 每个 Tick 内级别和消息相同的日志先记录 Initial 条，之后每 Thereafter 条记录一条。
+type SamplingOptions struct {
+	// Disabled 表示关闭采样，记录所有日志。
+	Disabled bool `json:"disabled" mapstructure:"disabled"`
+	// Initial 为 0 时使用 DefaultSamplingInitial。
+	Initial int `json:"initial" mapstructure:"initial"`
+	// Thereafter 为 0 时使用 DefaultSamplingThereafter。
+	Thereafter int `json:"thereafter" mapstructure:"thereafter"`
+	// Tick 为 0 时使用 DefaultSamplingTick。
+	Tick time.Duration `json:"tick" mapstructure:"tick"`
+}
+
+// 日志采样的默认配置
+const (
+	DefaultSamplingInitial    = 100
+	DefaultSamplingThereafter = 100
+	DefaultSamplingTick       = time.Second
+)
+
+// withDefaults 返回用默认值填充零值后的采样配置
+func (o SamplingOptions) withDefaults() SamplingOptions {
+	if o.Initial == 0 {
+		o.Initial = DefaultSamplingInitial
+	}
+	if o.Thereafter == 0 {
+		o.Thereafter = DefaultSamplingThereafter
+	}
+	if o.Tick == 0 {
+		o.Tick = DefaultSamplingTick
+	}
+	return o
 }
 
 // NewOptions 创建一个带有默认值的新 Options 对象。
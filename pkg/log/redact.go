@@ -0,0 +1,294 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRedactMask 是替换敏感内容使用的掩码
+const DefaultRedactMask = "******"
+
+// CardNumberPattern 匹配银行卡号，匹配结果还需要通过 Luhn 校验才会被替换
+const CardNumberPattern = `\b\d(?:[ -]?\d){12,18}\b`
+
+// DefaultRedactKeys 是默认脱敏的字段名
+var DefaultRedactKeys = []string{
+	"authorization", "password", "passwd", "secret", "token", "api_key", "cookie", "credential", "private_key",
+}
+
+// DefaultRedactPatterns 是默认脱敏的内容模式
+var DefaultRedactPatterns = []string{
+	CardNumberPattern,
+	// Bearer 令牌
+	`(?i)bearer\s+[a-z0-9\-._~+/]+=*`,
+}
+
+// RedactionOptions 是敏感信息脱敏配置
+// 字段名匹配的字段整体替换为掩码；字符串内容（包括 JSON 格式的请求和响应体）中匹配模式的部分替换为掩码
+type RedactionOptions struct {
+	// Disabled 表示关闭脱敏
+	Disabled bool `json:"disabled" mapstructure:"disabled"`
+	// Keys 是需要脱敏的字段名，不区分大小写并忽略 - 和 _，字段名包含其中任意一个即脱敏，为空时使用 DefaultRedactKeys
+	Keys []string `json:"keys" mapstructure:"keys"`
+	// Patterns 是需要脱敏的内容的正则表达式，为空时使用 DefaultRedactPatterns
+	Patterns []string `json:"patterns" mapstructure:"patterns"`
+	// Mask 是替换敏感内容使用的掩码，为空时使用 DefaultRedactMask
+	Mask string `json:"mask" mapstructure:"mask"`
+}
+
+// redactor 按配置对日志字段和内容脱敏
+type redactor struct {
+	keys     []string
+	patterns []*redactPattern
+	mask     string
+}
+
+// redactPattern 是编译后的脱敏模式
+type redactPattern struct {
+	re *regexp.Regexp
+	// luhn 表示匹配结果需要通过 Luhn 校验，用于减少银行卡号模式的误判
+	luhn bool
+}
+
+// newRedactor 根据配置创建 redactor，关闭脱敏时返回 nil
+func newRedactor(opts *RedactionOptions) (*redactor, error) {
+	if opts.Disabled {
+		return nil, nil
+	}
+
+	r := &redactor{mask: opts.Mask}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+
+	keys := opts.Keys
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+	for _, k := range keys {
+		if k = normalizeKey(k); k != "" {
+			r.keys = append(r.keys, k)
+		}
+	}
+
+	patterns := opts.Patterns
+	if len(patterns) == 0 {
+		patterns = DefaultRedactPatterns
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("无效的脱敏模式 %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, &redactPattern{re: re, luhn: p == CardNumberPattern})
+	}
+	return r, nil
+}
+
+// normalizeKey 将字段名转换为小写并去掉 - 和 _
+func normalizeKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+// sensitiveKey 判断字段名是否需要脱敏
+func (r *redactor) sensitiveKey(key string) bool {
+	key = normalizeKey(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// fields 返回脱敏后的字段，没有字段需要脱敏时返回原切片
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		nf, changed := r.field(f)
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = nf
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// field 对单个字段脱敏，返回新字段和是否发生了变化
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.SkipType || f.Type == zapcore.NamespaceType {
+		return f, false
+	}
+	if r.sensitiveKey(f.Key) {
+		return zap.String(f.Key, r.mask), true
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		if s := r.text(f.String); s != f.String {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		// []byte 类型的请求和响应体通过 zap.Any 记录时是 BinaryType
+		if b, ok := f.Interface.([]byte); ok && utf8.Valid(b) {
+			if s := r.text(string(b)); s != string(b) {
+				return zap.String(f.Key, s), true
+			}
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			orig := s.String()
+			if redacted := r.text(orig); redacted != orig {
+				return zap.String(f.Key, redacted), true
+			}
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			orig := err.Error()
+			if redacted := r.text(orig); redacted != orig {
+				return zap.String(f.Key, redacted), true
+			}
+		}
+	case zapcore.ReflectType:
+		// 结构体、映射等值按 JSON 编码，先转换为通用结构再脱敏
+		data, err := json.Marshal(f.Interface)
+		if err != nil {
+			return f, false
+		}
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return f, false
+		}
+		if redacted, changed := r.value(v); changed {
+			return zap.Any(f.Key, redacted), true
+		}
+	}
+	return f, false
+}
+
+// value 对 JSON 解码得到的通用结构递归脱敏
+func (r *redactor) value(v any) (any, bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		changed := false
+		for k, val := range t {
+			if r.sensitiveKey(k) {
+				t[k] = r.mask
+				changed = true
+				continue
+			}
+			if nv, c := r.value(val); c {
+				t[k] = nv
+				changed = true
+			}
+		}
+		return t, changed
+	case []any:
+		changed := false
+		for i, val := range t {
+			if nv, c := r.value(val); c {
+				t[i] = nv
+				changed = true
+			}
+		}
+		return t, changed
+	case string:
+		s := r.text(t)
+		return s, s != t
+	default:
+		return v, false
+	}
+}
+
+// text 对字符串脱敏，JSON 格式的内容（如请求和响应体）按字段名和模式脱敏，其余内容按模式脱敏
+func (r *redactor) text(s string) string {
+	if trimmed := strings.TrimSpace(s); len(trimmed) > 1 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		var v any
+		if err := json.Unmarshal([]byte(trimmed), &v); err == nil {
+			redacted, changed := r.value(v)
+			if !changed {
+				return s
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(redacted); err == nil {
+				return strings.TrimSuffix(buf.String(), "\n")
+			}
+		}
+	}
+	return r.replacePatterns(s)
+}
+
+// replacePatterns 将字符串中匹配脱敏模式的部分替换为掩码
+func (r *redactor) replacePatterns(s string) string {
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.luhn && !luhnValid(match) {
+				return match
+			}
+			return r.mask
+		})
+	}
+	return s
+}
+
+// luhnValid 使用 Luhn 算法校验数字串，忽略其中的空格和 -
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
+
+// redactCore 在日志到达编码器之前对消息和字段脱敏
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+// With 实现 zapcore.Core 接口，附加的上下文字段同样脱敏
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.fields(fields)), r: c.r}
+}
+
+// Check 实现 zapcore.Core 接口
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现 zapcore.Core 接口
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.replacePatterns(ent.Message)
+	return c.Core.Write(ent, c.r.fields(fields))
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedaction(t *testing.T) {
+	r, err := newRedactor(&RedactionOptions{})
+	if err != nil {
+		t.Fatalf("创建 redactor 失败: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "敏感字段名", key: "Authorization", value: "Bearer abc", want: DefaultRedactMask},
+		{name: "字段名忽略分隔符", key: "X-Api-Key", value: "k1", want: DefaultRedactMask},
+		{name: "字段名包含敏感词", key: "refresh_token", value: "t1", want: DefaultRedactMask},
+		{name: "普通字段", key: "path", value: "/v1/users", want: "/v1/users"},
+		{name: "银行卡号", key: "note", value: "卡号 4111 1111 1111 1111 已绑定", want: "卡号 ****** 已绑定"},
+		{name: "未通过 Luhn 校验的数字", key: "order", value: "1234567890123", want: "1234567890123"},
+		{name: "Bearer 令牌", key: "header", value: "bearer eyJhbGciOi.x-y", want: DefaultRedactMask},
+		{
+			name:  "JSON 请求体",
+			key:   "body",
+			value: `{"user":"alice","password":"p@ss","card":{"number":"4111111111111111"}}`,
+			want:  `{"card":{"number":"******"},"password":"******","user":"alice"}`,
+		},
+		{name: "JSON 字节请求体", key: "body", value: []byte(`{"token":"t"}`), want: `{"token":"******"}`},
+		{
+			name:  "映射",
+			key:   "headers",
+			value: map[string][]string{"Cookie": {"sid=1"}, "Accept": {"*/*"}},
+			want:  map[string]interface{}{"Cookie": DefaultRedactMask, "Accept": []interface{}{"*/*"}},
+		},
+		{name: "错误信息", key: "error", value: errors.New("bearer abc 无效"), want: "****** 无效"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			zap.New(&redactCore{Core: core, r: r}).Sugar().Infow("请求", tt.key, tt.value)
+
+			got := logs.All()[0].ContextMap()[tt.key]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %#v, 期望 %#v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("上下文字段和消息", func(t *testing.T) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		zap.New(&redactCore{Core: core, r: r}).Sugar().With("password", "p").Infow("卡号 4111111111111111")
+
+		entry := logs.All()[0]
+		if entry.Message != "卡号 ******" || entry.ContextMap()["password"] != DefaultRedactMask {
+			t.Errorf("脱敏结果不正确: %q %v", entry.Message, entry.ContextMap())
+		}
+	})
+}
+
+func TestSamplingAndStacktrace(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func(*Options)
+		// want 是 150 条相同日志中被记录的条数
+		want       int
+		stacktrace bool
+	}{
+		{name: "默认采样", opts: func(*Options) {}, want: DefaultSamplingInitial},
+		{name: "关闭采样", opts: func(o *Options) { o.Sampling.Disabled = true }, want: 150},
+		{name: "自定义采样", opts: func(o *Options) { o.Sampling = SamplingOptions{Initial: 10, Thereafter: 50} }, want: 12},
+		{name: "记录调用栈", opts: func(o *Options) { o.StacktraceLevel = "warn" }, want: DefaultSamplingInitial, stacktrace: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			opts := NewOptions()
+			opts.Format = "json"
+			opts.OutputPaths = []string{path}
+			tt.opts(opts)
+
+			l, err := NewZapLogger(opts)
+			if err != nil {
+				t.Fatalf("创建日志记录器失败: %v", err)
+			}
+			t.Cleanup(func() { _ = l.(*zapLogger).closeFiles() })
+
+			for i := 0; i < 150; i++ {
+				l.Warnw("重复的日志")
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("读取日志文件失败: %v", err)
+			}
+			if got := strings.Count(string(data), "重复的日志"); got != tt.want {
+				t.Errorf("记录了 %d 条日志，期望 %d 条", got, tt.want)
+			}
+			if got := strings.Contains(string(data), `"stacktrace"`); got != tt.stacktrace {
+				t.Errorf("包含调用栈 = %v，期望 %v", got, tt.stacktrace)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// SignalReopener 在接收到 SIGUSR1 时重新打开全局日志记录器的日志文件，实现 app.Server 接口
+// 配合 logrotate 等外部工具使用：工具移走日志文件后发送 SIGUSR1，服务在原路径创建新文件继续写入
+type SignalReopener struct{}
+
+// NewSignalReopener 创建一个 SignalReopener
+func NewSignalReopener() *SignalReopener {
+	return &SignalReopener{}
+}
+
+// Start 开始监听重新打开日志文件的信号，阻塞直到上下文取消
+func (r *SignalReopener) Start(ctx context.Context) error {
+	if len(reopenSignals) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, reopenSignals...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-ch:
+			if err := Reopen(); err != nil {
+				Errorw("重新打开日志文件失败", "signal", sig.String(), "error", err)
+				continue
+			}
+			Infow("已重新打开日志文件", "signal", sig.String())
+		}
+	}
+}
+
+// Stop 实现 app.Server 接口，监听在 Start 的上下文取消后自动停止
+func (r *SignalReopener) Stop(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals 是触发重新打开日志文件的信号
+var reopenSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package log
+
+import "os"
+
+// reopenSignals 是触发重新打开日志文件的信号，Windows 不支持 SIGUSR1
+var reopenSignals []os.Signal
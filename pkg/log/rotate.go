@@ -0,0 +1,292 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// RotationOptions 是日志文件的轮转配置，只对文件路径生效，stdout 和 stderr 不会轮转
+type RotationOptions struct {
+	// MaxSize 是单个日志文件的最大大小（MB），超过后轮转，为 0 时不轮转
+	MaxSize int `json:"max-size" mapstructure:"max-size"`
+	// MaxAge 是轮转后的旧日志文件保留的最大天数，为 0 时不按时间清理
+	MaxAge int `json:"max-age" mapstructure:"max-age"`
+	// MaxBackups 是轮转后的旧日志文件保留的最大个数，为 0 时不按个数清理
+	MaxBackups int `json:"max-backups" mapstructure:"max-backups"`
+	// Compress 表示是否使用 gzip 压缩轮转后的旧日志文件
+	Compress bool `json:"compress" mapstructure:"compress"`
+	// LocalTime 表示旧日志文件名中的时间是否使用本地时间，默认使用 UTC
+	LocalTime bool `json:"local-time" mapstructure:"local-time"`
+}
+
+// Enabled 返回是否启用轮转
+func (o *RotationOptions) Enabled() bool {
+	return o.MaxSize > 0
+}
+
+// logFile 是可以重新打开的日志文件
+type logFile interface {
+	zapcore.WriteSyncer
+	// Reopen 关闭并重新打开日志文件，用于配合 logrotate 等外部工具
+	Reopen() error
+	// Close 关闭日志文件，由 sharedFile 保证关闭之后不再写入
+	Close() error
+}
+
+// openOutputs 打开日志输出路径，返回合并后的 WriteSyncer 和其中的日志文件
+// stdout 和 stderr 直接输出到标准输出和标准错误，其余路径按 rotation 配置作为日志文件打开
+func openOutputs(paths []string, rotation RotationOptions) (zapcore.WriteSyncer, []*sharedFile, error) {
+	writers := make([]zapcore.WriteSyncer, 0, len(paths))
+	files := make([]*sharedFile, 0, len(paths))
+	for _, path := range paths {
+		switch path {
+		case "stdout":
+			writers = append(writers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			writers = append(writers, zapcore.Lock(os.Stderr))
+		default:
+			f, err := acquireFile(path, rotation)
+			if err != nil {
+				closeFiles(files)
+				return nil, nil, err
+			}
+			writers = append(writers, f)
+			files = append(files, f)
+		}
+	}
+	if len(writers) == 1 {
+		return writers[0], files, nil
+	}
+	return zapcore.NewMultiWriteSyncer(writers...), files, nil
+}
+
+// newLogFile 按 rotation 配置打开日志文件
+func newLogFile(path string, rotation RotationOptions) (logFile, error) {
+	if rotation.Enabled() {
+		return &rotateFile{Logger: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    rotation.MaxSize,
+			MaxAge:     rotation.MaxAge,
+			MaxBackups: rotation.MaxBackups,
+			LocalTime:  rotation.LocalTime,
+			Compress:   rotation.Compress,
+		}}, nil
+	}
+	f := &reopenFile{path: path}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// reopenFiles 重新打开所有日志文件
+func reopenFiles(files []*sharedFile) error {
+	var errs []error
+	for _, f := range files {
+		errs = append(errs, f.Reopen())
+	}
+	return errors.Join(errs...)
+}
+
+// closeFiles 释放所有日志文件，文件在最后一个使用者释放后关闭
+func closeFiles(files []*sharedFile) error {
+	var errs []error
+	for _, f := range files {
+		errs = append(errs, f.release())
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	// openFilesMu 保护 openFiles 和 sharedFile 的引用计数
+	openFilesMu sync.Mutex
+	// openFiles 是按绝对路径索引的已打开的日志文件
+	openFiles = make(map[string]*sharedFile)
+)
+
+// sharedFile 是按路径共享的日志文件
+// 重新初始化日志记录器时新旧日志记录器使用同一个 sharedFile，同一路径只有一个打开的文件和一个 lumberjack，
+// 仍在使用旧日志记录器的请求写入同一个文件；最后一个使用者释放后关闭文件，之后的写入被丢弃
+type sharedFile struct {
+	key string
+	// refs 是使用者的数量，由 openFilesMu 保护
+	refs int
+
+	mu       sync.Mutex
+	file     logFile
+	rotation RotationOptions
+	closed   bool
+}
+
+// acquireFile 返回 path 对应的日志文件并增加引用计数，文件没有打开时按 rotation 配置打开
+// 已打开的文件的轮转配置变化时替换为新的配置
+func acquireFile(path string, rotation RotationOptions) (*sharedFile, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+
+	openFilesMu.Lock()
+	defer openFilesMu.Unlock()
+	if f, ok := openFiles[key]; ok {
+		if err := f.configure(path, rotation); err != nil {
+			return nil, err
+		}
+		f.refs++
+		return f, nil
+	}
+	file, err := newLogFile(path, rotation)
+	if err != nil {
+		return nil, err
+	}
+	f := &sharedFile{key: key, refs: 1, file: file, rotation: rotation}
+	openFiles[key] = f
+	return f, nil
+}
+
+// configure 在轮转配置变化时关闭原来的文件，按新的配置重新打开
+func (f *sharedFile) configure(path string, rotation RotationOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rotation == rotation {
+		return nil
+	}
+	file, err := newLogFile(path, rotation)
+	if err != nil {
+		return err
+	}
+	_ = f.file.Close()
+	f.file, f.rotation = file, rotation
+	return nil
+}
+
+// release 减少引用计数，最后一个使用者释放时关闭文件
+func (f *sharedFile) release() error {
+	openFilesMu.Lock()
+	f.refs--
+	last := f.refs == 0
+	if last {
+		delete(openFiles, f.key)
+	}
+	openFilesMu.Unlock()
+	if !last {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return f.file.Close()
+}
+
+// Write 实现 io.Writer 接口，文件关闭后丢弃写入
+func (f *sharedFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return len(p), nil
+	}
+	return f.file.Write(p)
+}
+
+// Sync 实现 zapcore.WriteSyncer 接口
+func (f *sharedFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Reopen 重新打开日志文件，用于配合 logrotate 等外部工具
+func (f *sharedFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	return f.file.Reopen()
+}
+
+// rotateFile 是按大小轮转的日志文件
+type rotateFile struct {
+	*lumberjack.Logger
+}
+
+// Sync 实现 zapcore.WriteSyncer 接口，lumberjack 直接写入文件，不需要刷新
+func (f *rotateFile) Sync() error {
+	return nil
+}
+
+// Reopen 关闭当前文件，下一次写入时 lumberjack 会重新打开配置的路径
+func (f *rotateFile) Reopen() error {
+	return f.Logger.Close()
+}
+
+// reopenFile 是不轮转的日志文件，文件被外部工具移走后可以通过 Reopen 重新创建
+type reopenFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// open 以追加模式打开日志文件，调用方需要持有锁
+func (f *reopenFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	f.f = file
+	return nil
+}
+
+// Write 实现 io.Writer 接口，文件已关闭时重新打开
+func (f *reopenFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	return f.f.Write(p)
+}
+
+// Sync 实现 zapcore.WriteSyncer 接口
+func (f *reopenFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return nil
+	}
+	return f.f.Sync()
+}
+
+// Reopen 关闭并重新打开日志文件
+func (f *reopenFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f != nil {
+		_ = f.f.Close()
+		f.f = nil
+	}
+	return f.open()
+}
+
+// Close 关闭日志文件
+func (f *reopenFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
@@ -0,0 +1,142 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFileLogger 创建输出到 dir 中 app.log 的日志记录器
+func newFileLogger(t *testing.T, dir string, rotation RotationOptions) *zapLogger {
+	t.Helper()
+	opts := NewOptions()
+	opts.Format = "json"
+	opts.OutputPaths = []string{filepath.Join(dir, "app.log")}
+	opts.ErrorOutputPaths = []string{"stderr"}
+	opts.Rotation = rotation
+
+	l, err := NewZapLogger(opts)
+	if err != nil {
+		t.Fatalf("创建日志记录器失败: %v", err)
+	}
+	t.Cleanup(func() { _ = l.(*zapLogger).closeFiles() })
+	return l.(*zapLogger)
+}
+
+func TestReopen(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation RotationOptions
+	}{
+		{name: "不轮转的日志文件"},
+		{name: "轮转的日志文件", rotation: RotationOptions{MaxSize: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l := newFileLogger(t, dir, tt.rotation)
+			path := filepath.Join(dir, "app.log")
+
+			l.Infow("移走之前")
+			// 模拟 logrotate 移走日志文件
+			if err := os.Rename(path, path+".1"); err != nil {
+				t.Fatalf("移动日志文件失败: %v", err)
+			}
+			if err := l.Reopen(); err != nil {
+				t.Fatalf("重新打开日志文件失败: %v", err)
+			}
+			l.Infow("重新打开之后")
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("读取新的日志文件失败: %v", err)
+			}
+			if !strings.Contains(string(data), "重新打开之后") || strings.Contains(string(data), "移走之前") {
+				t.Errorf("新的日志文件内容不正确: %s", data)
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir, RotationOptions{MaxSize: 1, MaxBackups: 1})
+
+	// 写入超过 1MB 的日志触发轮转，每条消息不同以免被采样丢弃
+	line := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		l.Infof("%d %s", i, line)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("读取日志目录失败: %v", err)
+	}
+	if len(entries) < 2 {
+		t.Errorf("期望日志文件被轮转，目录中只有 %d 个文件", len(entries))
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation RotationOptions
+	}{
+		{name: "不轮转的日志文件"},
+		{name: "轮转的日志文件", rotation: RotationOptions{MaxSize: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app.log")
+			opts := NewOptions()
+			opts.Format = "json"
+			opts.OutputPaths = []string{path}
+			opts.ErrorOutputPaths = []string{"stderr"}
+			opts.Rotation = tt.rotation
+
+			old, err := NewZapLogger(opts)
+			if err != nil {
+				t.Fatalf("创建日志记录器失败: %v", err)
+			}
+			cur, err := NewZapLogger(opts)
+			if err != nil {
+				t.Fatalf("创建日志记录器失败: %v", err)
+			}
+			// 模拟 Init 替换日志记录器后释放旧日志记录器的文件
+			_ = old.(*zapLogger).closeFiles()
+
+			if old.(*zapLogger).files[0] != cur.(*zapLogger).files[0] {
+				t.Fatal("同一路径的日志文件没有共享")
+			}
+			old.Infow("旧日志记录器")
+			cur.Infow("新日志记录器")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("读取日志文件失败: %v", err)
+			}
+			if !strings.Contains(string(data), "旧日志记录器") || !strings.Contains(string(data), "新日志记录器") {
+				t.Errorf("日志文件内容不正确: %s", data)
+			}
+
+			// 最后一个使用者释放后关闭文件，之后的写入被丢弃，不会重新打开文件
+			_ = cur.(*zapLogger).closeFiles()
+			if err := os.Remove(path); err != nil {
+				t.Fatalf("删除日志文件失败: %v", err)
+			}
+			old.Infow("关闭之后")
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("关闭之后的写入重新打开了日志文件: %v", err)
+			}
+			openFilesMu.Lock()
+			n := len(openFiles)
+			openFilesMu.Unlock()
+			if n != 0 {
+				t.Errorf("期望没有打开的日志文件，实际 %d 个", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler 是使用全局日志记录器输出的 slog.Handler
+// 全局日志记录器在记录日志时才获取，因此重新初始化全局日志记录器后仍然使用新的配置
+type slogHandler struct {
+	// name 是日志记录器名称，为空时使用全局日志记录器
+	name string
+	// attrs 是通过 WithAttrs 附加的键值对
+	attrs []interface{}
+	// prefix 是通过 WithGroup 添加的分组前缀，如 "db."
+	prefix string
+}
+
+var _ slog.Handler = &slogHandler{}
+
+// NewSlogHandler 返回一个使用全局日志记录器输出的 slog.Handler，name 不为空时使用对应的命名日志记录器
+// 上下文中的日志记录器和 trace_id、request_id 等字段会被附加，分组展开为以 . 连接的字段名
+func NewSlogHandler(name string) slog.Handler {
+	return &slogHandler{name: name}
+}
+
+// Enabled 实现 slog.Handler 接口
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if l, ok := L().(*zapLogger); ok {
+		return l.z.Core().Enabled(zapLevel(level))
+	}
+	return true
+}
+
+// Handle 实现 slog.Handler 接口
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	keysAndValues := make([]interface{}, 0, len(h.attrs)+2*r.NumAttrs())
+	keysAndValues = append(keysAndValues, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		keysAndValues = appendAttr(keysAndValues, h.prefix, a)
+		return true
+	})
+
+	var l Logger
+	if h.name != "" {
+		l = FromContextNamed(ctx, h.name)
+	} else {
+		l = FromContext(ctx)
+	}
+
+	lvl := zapLevel(r.Level)
+	zl, ok := l.(*zapLogger)
+	if !ok {
+		logAt(l.WithValues(keysAndValues...), lvl, r.Message)
+		return nil
+	}
+
+	// 直接写入 zap，使用记录中的时间和调用位置
+	ce := zl.z.Check(lvl, r.Message)
+	if ce == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		ce.Time = r.Time
+	}
+	if r.PC != 0 && ce.Caller.Defined {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(r.PC, frame.File, frame.Line, true)
+	}
+	ce.Write(handleFields(keysAndValues)...)
+	return nil
+}
+
+// WithAttrs 实现 slog.Handler 接口
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = make([]interface{}, len(h.attrs), len(h.attrs)+2*len(attrs))
+	copy(h2.attrs, h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+// WithGroup 实现 slog.Handler 接口
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendAttr 将 slog 属性展开为键值对追加到 keysAndValues，分组中的属性名以 . 连接
+func appendAttr(keysAndValues []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return keysAndValues
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			keysAndValues = appendAttr(keysAndValues, prefix, ga)
+		}
+		return keysAndValues
+	}
+	return append(keysAndValues, prefix+a.Key, a.Value.Any())
+}
+
+// zapLevel 将 slog 级别转换为 zap 级别，自定义级别归入不高于它的最近级别
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// logAt 使用 l 记录一条 lvl 级别的消息
+func logAt(l Logger, lvl zapcore.Level, msg string) {
+	switch lvl {
+	case zapcore.DebugLevel:
+		l.Debugw(msg)
+	case zapcore.InfoLevel:
+		l.Infow(msg)
+	case zapcore.WarnLevel:
+		l.Warnw(msg)
+	default:
+		l.Errorw(msg)
+	}
+}
+
+// slogLogger 是使用 *slog.Logger 输出的 Logger，便于已经使用 log/slog 的服务逐步迁移
+type slogLogger struct {
+	l *slog.Logger
+}
+
+var _ Logger = &slogLogger{}
+
+// FromSlog 返回一个使用 l 输出的 Logger
+// Panic 和 Fatal 级别使用 slog.LevelError 记录后分别 panic 和退出进程
+func FromSlog(l *slog.Logger) Logger {
+	return &slogLogger{l: l}
+}
+
+// log 记录一条日志，调用位置为 Logger 方法的调用方
+func (s *slogLogger) log(level slog.Level, msg string, keysAndValues []interface{}) {
+	ctx := context.Background()
+	if !s.l.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	// 跳过 runtime.Callers、log 和 Logger 方法
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(keysAndValues...)
+	_ = s.l.Handler().Handle(ctx, r)
+}
+
+func (s *slogLogger) Debugf(format string, args ...interface{}) {
+	s.log(slog.LevelDebug, fmt.Sprintf(format, args...), nil)
+}
+
+func (s *slogLogger) Infof(format string, args ...interface{}) {
+	s.log(slog.LevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+func (s *slogLogger) Warnf(format string, args ...interface{}) {
+	s.log(slog.LevelWarn, fmt.Sprintf(format, args...), nil)
+}
+
+func (s *slogLogger) Errorf(format string, args ...interface{}) {
+	s.log(slog.LevelError, fmt.Sprintf(format, args...), nil)
+}
+
+func (s *slogLogger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	s.log(slog.LevelError, msg, nil)
+	panic(msg)
+}
+
+func (s *slogLogger) Fatalf(format string, args ...interface{}) {
+	s.log(slog.LevelError, fmt.Sprintf(format, args...), nil)
+	os.Exit(1)
+}
+
+func (s *slogLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.log(slog.LevelDebug, msg, keysAndValues)
+}
+
+func (s *slogLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.log(slog.LevelInfo, msg, keysAndValues)
+}
+
+func (s *slogLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.log(slog.LevelWarn, msg, keysAndValues)
+}
+
+func (s *slogLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.log(slog.LevelError, msg, keysAndValues)
+}
+
+func (s *slogLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	s.log(slog.LevelError, msg, keysAndValues)
+	panic(msg)
+}
+
+func (s *slogLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	s.log(slog.LevelError, msg, keysAndValues)
+	os.Exit(1)
+}
+
+// WithValues 返回一个附加了 keysAndValues 的 Logger
+func (s *slogLogger) WithValues(keysAndValues ...interface{}) Logger {
+	return &slogLogger{l: s.l.With(keysAndValues...)}
+}
+
+// Sync 实现 Logger 接口，slog 没有缓冲，不需要同步
+func (s *slogLogger) Sync() {}
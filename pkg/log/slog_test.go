@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSlogHandler(t *testing.T) {
+	logs := useObserver(t)
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+
+	tests := []struct {
+		name      string
+		log       func(l *slog.Logger)
+		wantLevel zapcore.Level
+		want      map[string]interface{}
+	}{
+		{
+			name:      "属性和级别",
+			log:       func(l *slog.Logger) { l.Warn("消息", "a", 1) },
+			wantLevel: zapcore.WarnLevel,
+			want:      map[string]interface{}{"a": int64(1)},
+		},
+		{
+			name:      "分组展开为以点连接的字段名",
+			log:       func(l *slog.Logger) { l.With("a", "x").WithGroup("db").Info("消息", slog.Group("conn", "id", 2)) },
+			wantLevel: zapcore.InfoLevel,
+			want:      map[string]interface{}{"a": "x", "db.conn.id": int64(2)},
+		},
+		{
+			name:      "上下文字段",
+			log:       func(l *slog.Logger) { l.DebugContext(ctx, "消息") },
+			wantLevel: zapcore.DebugLevel,
+			want:      map[string]interface{}{KeyRequestID: "req-1"},
+		},
+		{
+			name:      "自定义级别",
+			log:       func(l *slog.Logger) { l.Log(context.Background(), slog.LevelError+4, "消息") },
+			wantLevel: zapcore.ErrorLevel,
+			want:      map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.TakeAll()
+			tt.log(slog.New(NewSlogHandler("")))
+
+			entries := logs.TakeAll()
+			if len(entries) != 1 {
+				t.Fatalf("期望 1 条日志，实际 %d 条", len(entries))
+			}
+			if entries[0].Level != tt.wantLevel {
+				t.Errorf("级别 = %s，期望 %s", entries[0].Level, tt.wantLevel)
+			}
+			if got := entries[0].ContextMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("字段 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	l := FromSlog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})))
+
+	l.WithValues("k", "v").Infow("消息", "n", 1)
+	l.Debugw("低于 Info 的日志不记录")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("解析日志失败: %v, %s", err, buf.String())
+	}
+	if got["msg"] != "消息" || got["k"] != "v" || got["n"] != float64(1) || got["level"] != "INFO" {
+		t.Errorf("日志内容不正确: %v", got)
+	}
+	source, _ := got["source"].(map[string]interface{})
+	if file, _ := source["file"].(string); !strings.HasSuffix(file, "slog_test.go") {
+		t.Errorf("调用位置不正确: %v", got["source"])
+	}
+}
@@ -1,6 +1,9 @@
 package log
 
 import (
+	"fmt"
+	"strings"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -8,7 +11,9 @@ import (
 // zapLogger 是一个使用 zap 来记录日志的记录器。
 type zapLogger struct {
 	z     *zap.Logger
-	level zapcore.Level
+	level zap.AtomicLevel
+	// files 是日志记录器打开的日志文件，所有派生的日志记录器共享
+	files []*sharedFile
 }
 
 // NewZapLogger 根据给定的选项创建一个新的 zapLogger。
@@ -24,29 +29,69 @@ func NewZapLogger(opts *Options) (Logger, error) {
 	}
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	cfg := &zap.Config{
-		Level:             zap.NewAtomicLevelAt(zapLevel),
-		Development:       opts.Format == "console",
-		DisableCaller:     !opts.EnableCaller,
-		DisableStacktrace: true,
-		Sampling: &zap.SamplingConfig{
-			Initial:    100,
-			Thereafter: 100,
-		},
-		Encoding:         opts.Format,
-		EncoderConfig:    encoderConfig,
-		OutputPaths:      opts.OutputPaths,
-		ErrorOutputPaths: opts.ErrorOutputPaths,
-	}
-
-	z, err := cfg.Build(zap.AddCallerSkip(1))
+	var encoder zapcore.Encoder
+	switch opts.Format {
+	case "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("不支持的日志格式 %q", opts.Format)
+	}
+
+	var stacktraceLevel zapcore.Level
+	if opts.StacktraceLevel != "" {
+		var err error
+		if stacktraceLevel, err = parseLevel(opts.StacktraceLevel); err != nil {
+			return nil, fmt.Errorf("无效的调用栈级别: %w", err)
+		}
+	}
+	if opts.Sampling.Initial < 0 || opts.Sampling.Thereafter < 0 || opts.Sampling.Tick < 0 {
+		return nil, fmt.Errorf("无效的日志采样配置 %+v", opts.Sampling)
+	}
+	redactor, err := newRedactor(&opts.Redaction)
 	if err != nil {
 		return nil, err
 	}
 
+	sink, files, err := openOutputs(opts.OutputPaths, opts.Rotation)
+	if err != nil {
+		return nil, fmt.Errorf("打开日志输出失败: %w", err)
+	}
+	errSink, errFiles, err := openOutputs(opts.ErrorOutputPaths, opts.ErrorRotation)
+	if err != nil {
+		_ = closeFiles(files)
+		return nil, fmt.Errorf("打开错误日志输出失败: %w", err)
+	}
+
+	// 底层 Core 记录所有级别，由 levelCore 按全局级别和命名日志记录器的级别覆盖过滤
+	// 脱敏紧贴编码器，保证经过 With 附加的上下文字段和每条日志的字段都会被脱敏
+	atomicLevel := zap.NewAtomicLevelAt(zapLevel)
+	core := zapcore.NewCore(encoder, sink, zapcore.DebugLevel)
+	if redactor != nil {
+		core = &redactCore{Core: core, r: redactor}
+	}
+	if !opts.Sampling.Disabled {
+		sampling := opts.Sampling.withDefaults()
+		core = zapcore.NewSamplerWithOptions(core, sampling.Tick, sampling.Initial, sampling.Thereafter)
+	}
+	core = &levelCore{Core: core, root: opts.Name, level: atomicLevel}
+
+	zapOpts := []zap.Option{zap.ErrorOutput(errSink), zap.AddCallerSkip(1)}
+	if opts.StacktraceLevel != "" {
+		zapOpts = append(zapOpts, zap.AddStacktrace(stacktraceLevel))
+	}
+	if opts.Format == "console" {
+		zapOpts = append(zapOpts, zap.Development())
+	}
+	if opts.EnableCaller {
+		zapOpts = append(zapOpts, zap.AddCaller())
+	}
+
 	logger := &zapLogger{
-		z:     z.Named(opts.Name),
-		level: zapLevel,
+		z:     zap.New(core, zapOpts...).Named(opts.Name),
+		level: atomicLevel,
+		files: append(files, errFiles...),
 	}
 
 	return logger, nil
@@ -102,13 +147,75 @@ func (l *zapLogger) Warnw(msg string, keysAndValues ...interface{}) {
 
 func (l *zapLogger) WithValues(keysAndValues ...interface{}) Logger {
 	newLogger := l.z.With(handleFields(keysAndValues)...)
-	return &zapLogger{z: newLogger}
+	return &zapLogger{z: newLogger, level: l.level, files: l.files}
+}
+
+// Level 返回全局日志级别
+func (l *zapLogger) Level() zapcore.Level {
+	return l.level.Level()
+}
+
+// SetLevel 在运行时修改全局日志级别，所有派生的日志记录器共享同一个级别
+func (l *zapLogger) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
+
+// Named 返回一个命名子日志记录器，名称通过 . 与父日志记录器的名称连接
+func (l *zapLogger) Named(name string) Logger {
+	return &zapLogger{z: l.z.Named(name), level: l.level, files: l.files}
+}
+
+// Reopen 重新打开日志文件
+func (l *zapLogger) Reopen() error {
+	return reopenFiles(l.files)
+}
+
+// closeFiles 释放日志文件，日志记录器被替换后调用，文件在没有其他日志记录器使用时关闭
+func (l *zapLogger) closeFiles() error {
+	return closeFiles(l.files)
 }
 
 func (l *zapLogger) Sync() {
 	_ = l.z.Sync()
 }
 
+// levelCore 按日志记录器名称过滤日志，有级别覆盖的日志记录器使用覆盖的级别，其余使用全局级别
+type levelCore struct {
+	zapcore.Core
+	// root 是根日志记录器的名称，匹配级别覆盖时会被去掉
+	root  string
+	level zap.AtomicLevel
+}
+
+// Enabled 实现 zapcore.LevelEnabler 接口
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) || runtimeLevels.enabledAny(lvl)
+}
+
+// With 实现 zapcore.Core 接口
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), root: c.root, level: c.level}
+}
+
+// Check 实现 zapcore.Core 接口
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !runtimeLevels.enabled(c.relativeName(ent.LoggerName), ent.Level, c.level.Level()) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// relativeName 返回去掉根名称后的日志记录器名称，如 go-protoc.middleware.http 返回 middleware.http
+func (c *levelCore) relativeName(name string) string {
+	if c.root == "" {
+		return name
+	}
+	if name == c.root {
+		return ""
+	}
+	return strings.TrimPrefix(name, c.root+".")
+}
+
 // handleFields 将一个 interface{} 切片转换为一个 zap.Field 切片。
 func handleFields(args []interface{}) []zap.Field {
 	if len(args) == 0 {
@@ -143,6 +250,11 @@ type SugaredLogger struct {
 
 var _ Logger = &SugaredLogger{}
 
+// Named 返回一个命名子日志记录器。
+func (l *SugaredLogger) Named(name string) Logger {
+	return &SugaredLogger{l.SugaredLogger.Named(name)}
+}
+
 // WithValues 返回一个新的 SugaredLogger，其中包含额外的上下文。
 func (l *SugaredLogger) WithValues(keysAndValues ...interface{}) Logger {
 	return &SugaredLogger{l.SugaredLogger.With(keysAndValues...)}
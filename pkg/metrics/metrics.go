@@ -6,6 +6,7 @@ import (
 )
 
 // 全局指标变量
+// HTTP 指标的 path 标签是匹配到的路由模板，grpc_method 标签是 gRPC-Gateway 路由对应的 gRPC 全方法名
 var (
 	// HTTPRequestsTotal 记录HTTP请求总数
 	HTTPRequestsTotal = promauto.NewCounterVec(
@@ -13,17 +14,22 @@ var (
 			Name: "http_requests_total",
 			Help: "HTTP请求总数",
 		},
-		[]string{"method", "path", "status"},
+		[]string{"method", "path", "status", "grpc_method"},
 	)
 
 	// HTTPRequestDuration 记录HTTP请求耗时
 	HTTPRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "HTTP请求耗时（秒）",
-			Buckets: prometheus.DefBuckets,
+		httpRequestDurationOpts(prometheus.DefBuckets),
+		[]string{"method", "path", "grpc_method"},
+	)
+
+	// HTTPRequestTimeouts 记录超时中间件中止的HTTP请求数
+	HTTPRequestTimeouts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_request_timeouts_total",
+			Help: "处理超时的HTTP请求总数",
 		},
-		[]string{"method", "path"},
+		[]string{"method", "path", "grpc_method"},
 	)
 
 	// GRPCRequestsTotal 记录gRPC请求总数
@@ -35,13 +41,116 @@ var (
 		[]string{"method", "status"},
 	)
 
+	// GRPCDeadlineExceeded 记录超过截止时间的gRPC请求数
+	GRPCDeadlineExceeded = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_deadline_exceeded_total",
+			Help: "超过截止时间的gRPC请求总数",
+		},
+		[]string{"method"},
+	)
+
 	// GRPCRequestDuration 记录gRPC请求耗时
 	GRPCRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "grpc_request_duration_seconds",
-			Help:    "gRPC请求耗时（秒）",
-			Buckets: prometheus.DefBuckets,
+		grpcRequestDurationOpts(prometheus.DefBuckets),
+		[]string{"method"},
+	)
+
+	// GRPCRequestsInFlight 记录正在处理中的gRPC请求数
+	GRPCRequestsInFlight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "grpc_requests_in_flight",
+			Help: "正在处理中的gRPC请求数",
+		},
+		[]string{"method"},
+	)
+
+	// GRPCStreamMessagesReceived 记录gRPC流接收的消息数
+	GRPCStreamMessagesReceived = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_stream_messages_received_total",
+			Help: "gRPC流接收的消息总数",
+		},
+		[]string{"method"},
+	)
+
+	// GRPCStreamMessagesSent 记录gRPC流发送的消息数
+	GRPCStreamMessagesSent = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_stream_messages_sent_total",
+			Help: "gRPC流发送的消息总数",
 		},
 		[]string{"method"},
 	)
+
+	// AuthzDecisions 记录授权决策数，decision 为 allow、deny 或 dry_run_deny（试运行模式下放行的拒绝决策）
+	AuthzDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authz_decisions_total",
+			Help: "授权决策总数",
+		},
+		[]string{"decision"},
+	)
+
+	// ConfigReloadsTotal 记录配置重新加载的次数
+	ConfigReloadsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "配置重新加载次数",
+		},
+		[]string{"result"},
+	)
+
+	// ConfigLastReloadSuccessful 记录最近一次配置重新加载是否成功（1 成功，0 失败）
+	ConfigLastReloadSuccessful = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_last_reload_successful",
+			Help: "最近一次配置重新加载是否成功",
+		},
+	)
+
+	// ConfigLastReloadSuccessTimestamp 记录最近一次成功加载配置的时间
+	ConfigLastReloadSuccessTimestamp = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_last_reload_success_timestamp_seconds",
+			Help: "最近一次成功加载配置的 Unix 时间戳（秒）",
+		},
+	)
 )
+
+// httpRequestDurationOpts 返回 HTTP 请求耗时直方图的配置
+func httpRequestDurationOpts(buckets []float64) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP请求耗时（秒）",
+		Buckets: buckets,
+	}
+}
+
+// grpcRequestDurationOpts 返回 gRPC 请求耗时直方图的配置
+func grpcRequestDurationOpts(buckets []float64) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Name:    "grpc_request_duration_seconds",
+		Help:    "gRPC请求耗时（秒）",
+		Buckets: buckets,
+	}
+}
+
+// SetHistogramBuckets 使用给定的桶重新创建 HTTP 和 gRPC 请求耗时直方图
+// 传入空切片的直方图保持不变。必须在服务器开始处理请求之前调用，已记录的数据会被丢弃。
+func SetHistogramBuckets(httpBuckets, grpcBuckets []float64) {
+	if len(httpBuckets) > 0 {
+		prometheus.Unregister(HTTPRequestDuration)
+		HTTPRequestDuration = promauto.NewHistogramVec(
+			httpRequestDurationOpts(httpBuckets),
+			[]string{"method", "path", "grpc_method"},
+		)
+	}
+	if len(grpcBuckets) > 0 {
+		prometheus.Unregister(GRPCRequestDuration)
+		GRPCRequestDuration = promauto.NewHistogramVec(
+			grpcRequestDurationOpts(grpcBuckets),
+			[]string{"method"},
+		)
+	}
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryAuthInterceptor 是一个 gRPC 一元拦截器，使用 authorization 元数据认证调用方
+// 方法在 PublicMethods 中或带有 (auth.v1.public) 选项时不需要认证，通过认证的调用方通过 auth.FromContext 获取
+func UnaryAuthInterceptor(guard *auth.Guard) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateCall(ctx, guard, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor 是一个 gRPC 流拦截器，在流建立时认证调用方
+func StreamAuthInterceptor(guard *auth.Guard) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateCall(ss.Context(), guard, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, wrapServerStream(ss, ctx))
+	}
+}
+
+// authenticateCall 认证调用并返回携带调用方的上下文，失败时返回 Unauthenticated 状态错误
+func authenticateCall(ctx context.Context, guard *auth.Guard, method string) (context.Context, error) {
+	if !guard.Enabled() || guard.PublicMethod(method) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	p, err := guard.Authenticate(ctx, auth.Request{Header: md.Get})
+	if err != nil {
+		apiErr, ok := err.(*apierrors.Error)
+		if !ok {
+			apiErr = apierrors.ErrUnauthorized
+		}
+		return ctx, apiErr.GRPCStatus().Err()
+	}
+	return auth.NewContext(ctx, p), nil
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenAuthenticator 是测试用的认证器，只接受令牌 valid
+type tokenAuthenticator struct{}
+
+func (tokenAuthenticator) Authenticate(ctx context.Context, req auth.Request) (*auth.Principal, error) {
+	switch auth.BearerToken(req) {
+	case "":
+		return nil, auth.ErrNoCredentials
+	case "valid":
+		return &auth.Principal{Subject: "alice"}, nil
+	default:
+		return nil, apierrors.ErrInvalidToken
+	}
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	guard := auth.NewGuard(auth.Options{
+		Enable:        true,
+		PublicMethods: []string{"/helloworld.v1.Greeter/*"},
+	}, tokenAuthenticator{})
+	interceptor := UnaryAuthInterceptor(guard)
+
+	// 定义测试用例，reason 为空表示调用通过认证
+	testCases := []struct {
+		name   string
+		method string
+		token  string
+		reason string
+	}{
+		{name: "有效令牌", method: "/helloworld.v2.Greeter/SayHello", token: "valid"},
+		{name: "没有令牌", method: "/helloworld.v2.Greeter/SayHello", reason: apierrors.ErrUnauthorized.Reason},
+		{name: "无效令牌", method: "/helloworld.v2.Greeter/SayHello", token: "invalid", reason: apierrors.ErrInvalidToken.Reason},
+		{name: "公开方法", method: "/helloworld.v1.Greeter/SayHello"},
+		{name: "健康检查始终公开", method: "/grpc.health.v1.Health/Check"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tc.token))
+			}
+
+			var subject string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if p, ok := auth.FromContext(ctx); ok {
+					subject = p.Subject
+				}
+				return nil, nil
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+
+			if tc.reason == "" {
+				if err != nil {
+					t.Fatalf("认证失败: %v", err)
+				}
+				if tc.token != "" && subject != "alice" {
+					t.Errorf("处理器获取到的调用方 = %q，期望 alice", subject)
+				}
+				return
+			}
+			st := status.Convert(err)
+			if st.Code() != codes.Unauthenticated || apierrors.FromError(err).Reason != tc.reason {
+				t.Errorf("错误 = %v，期望 Unauthenticated/%s", err, tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/authz"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// UnaryAuthzInterceptor 是一个 gRPC 一元拦截器，按授权策略决定调用方能否调用方法，必须位于认证拦截器之后
+// 上下文中没有调用方的调用是认证拦截器放行的公开调用，不需要授权
+func UnaryAuthzInterceptor(authorizer *authz.Authorizer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorizeCall(ctx, authorizer, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthzInterceptor 是一个 gRPC 流拦截器，在流建立时授权
+func StreamAuthzInterceptor(authorizer *authz.Authorizer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorizeCall(ss.Context(), authorizer, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// authorizeCall 判断调用方能否调用方法，拒绝时返回 PermissionDenied 状态错误
+func authorizeCall(ctx context.Context, authorizer *authz.Authorizer, method string) error {
+	p, ok := auth.FromContext(ctx)
+	if !authorizer.Enabled() || !ok {
+		return nil
+	}
+
+	if err := authorizer.Authorize(ctx, p, authz.Resource{Method: method}); err != nil {
+		apiErr, ok := err.(*apierrors.Error)
+		if !ok {
+			apiErr = apierrors.ErrPermissionDenied
+		}
+		return apiErr.GRPCStatus().Err()
+	}
+	return nil
+}
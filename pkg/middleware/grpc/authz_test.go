@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/authz"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryAuthzInterceptor(t *testing.T) {
+	authorizer, err := authz.NewAuthorizer(authz.Options{Enable: true}, &authz.Policy{
+		Roles:    []authz.Role{{Name: "greeter", Permissions: []authz.Permission{{Methods: []string{"/helloworld.v1.Greeter/*"}}}}},
+		Bindings: []authz.Binding{{Roles: []string{"greeter"}, Scopes: []string{"greeter:read"}}},
+	})
+	if err != nil {
+		t.Fatalf("创建授权器失败: %v", err)
+	}
+	interceptor := UnaryAuthzInterceptor(authorizer)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	// 定义测试用例，principal 为 nil 表示认证拦截器放行的公开调用
+	testCases := []struct {
+		name      string
+		method    string
+		principal *auth.Principal
+		denied    bool
+	}{
+		{name: "允许", method: "/helloworld.v1.Greeter/SayHello", principal: &auth.Principal{Subject: "alice", Scopes: []string{"greeter:read"}}},
+		{name: "没有角色", method: "/helloworld.v1.Greeter/SayHello", principal: &auth.Principal{Subject: "bob"}, denied: true},
+		{name: "角色没有权限", method: "/helloworld.v2.Greeter/SayHello", principal: &auth.Principal{Subject: "alice", Scopes: []string{"greeter:read"}}, denied: true},
+		{name: "公开调用", method: "/helloworld.v2.Greeter/SayHello"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.principal != nil {
+				ctx = auth.NewContext(ctx, tc.principal)
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+
+			if !tc.denied {
+				if err != nil {
+					t.Fatalf("授权失败: %v", err)
+				}
+				return
+			}
+			if status.Code(err) != codes.PermissionDenied || apierrors.FromError(err).Reason != apierrors.ErrPermissionDenied.Reason {
+				t.Errorf("错误 = %v，期望 PermissionDenied", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	tlsutil "github.com/costa92/go-protoc/pkg/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryClientCertInterceptor 是一个 gRPC 一元拦截器，将经过验证的客户端证书身份放入上下文
+func UnaryClientCertInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withClientIdentity(ctx), req)
+	}
+}
+
+// StreamClientCertInterceptor 是一个 gRPC 流拦截器，将经过验证的客户端证书身份放入上下文
+func StreamClientCertInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, wrapServerStream(ss, withClientIdentity(ss.Context())))
+	}
+}
+
+// withClientIdentity 从 peer 信息中提取客户端身份并放入上下文
+func withClientIdentity(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+	if id, ok := tlsutil.IdentityFromConnectionState(&tlsInfo.State); ok {
+		return log.ContextWithSubject(tlsutil.NewContext(ctx, id), id.Name())
+	}
+	return ctx
+}
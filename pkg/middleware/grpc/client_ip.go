@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/costa92/go-protoc/pkg/clientip"
+	"github.com/costa92/go-protoc/pkg/health"
+	"github.com/costa92/go-protoc/pkg/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientIPInterceptor 是一个 gRPC 一元拦截器，解析客户端 IP
+// 对端是受信任的代理时从配置的转发元数据（默认 x-forwarded-for）中解析，
+// 结果放入上下文供日志和 IP 过滤使用，并记录到当前 span 的 client.address 属性中
+func UnaryClientIPInterceptor(resolver *clientip.Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withClientIP(ctx, resolver), req)
+	}
+}
+
+// StreamClientIPInterceptor 是一个 gRPC 流拦截器，解析客户端 IP
+func StreamClientIPInterceptor(resolver *clientip.Resolver) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, wrapServerStream(ss, withClientIP(ss.Context(), resolver)))
+	}
+}
+
+// withClientIP 解析客户端 IP 并放入上下文
+func withClientIP(ctx context.Context, resolver *clientip.Resolver) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ctx
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	addr := resolver.Resolve(p.Addr.String(), md.Get)
+	if !addr.IsValid() {
+		return ctx
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(clientip.Attribute, addr.String()))
+	return clientip.NewContext(ctx, addr)
+}
+
+// UnaryIPFilterInterceptor 是一个 gRPC 一元拦截器，客户端 IP 不被允许时返回 PermissionDenied
+// 客户端 IP 由 UnaryClientIPInterceptor 解析，没有时使用对端地址
+func UnaryIPFilterInterceptor(filter *clientip.Filter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkClientIP(ctx, filter, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamIPFilterInterceptor 是一个 gRPC 流拦截器，客户端 IP 不被允许时返回 PermissionDenied
+func StreamIPFilterInterceptor(filter *clientip.Filter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkClientIP(ss.Context(), filter, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// checkClientIP 检查客户端 IP 是否允许访问，grpc.health.v1.Health 服务的调用不受限制
+func checkClientIP(ctx context.Context, filter *clientip.Filter, method string) error {
+	if !filter.Enabled() || health.IsGRPCMethod(method) {
+		return nil
+	}
+	addr := peerIP(ctx)
+	if filter.Allowed(addr) {
+		return nil
+	}
+	log.FromContextNamed(ctx, loggerName).Infow("客户端 IP 不允许访问")
+	return status.Error(codes.PermissionDenied, "禁止访问")
+}
+
+// peerIP 返回上下文中的客户端 IP，没有时使用对端地址
+func peerIP(ctx context.Context) netip.Addr {
+	if addr, ok := clientip.FromContext(ctx); ok {
+		return addr
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr, _ := clientip.ParseAddr(p.Addr.String())
+		return addr
+	}
+	return netip.Addr{}
+}
+
+// clientIPString 返回上下文中的客户端 IP 的字符串形式，无法获取时返回空字符串
+func clientIPString(ctx context.Context) string {
+	if addr := peerIP(ctx); addr.IsValid() {
+		return addr.String()
+	}
+	return ""
+}
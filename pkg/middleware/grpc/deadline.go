@@ -0,0 +1,168 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeadlineMethod 是按 gRPC 方法覆盖的截止时间规则，匹配的调用完全使用该规则
+type DeadlineMethod struct {
+	// Method 是 gRPC 全方法名，如 /helloworld.v1.Greeter/SayHello，只支持末尾的 *
+	Method string
+	// Default 是客户端没有设置截止时间时使用的超时时间，为 0 时不设置
+	Default time.Duration
+	// Max 是允许的最长超时时间，客户端设置的截止时间更晚时会被缩短，为 0 时不限制
+	Max time.Duration
+}
+
+// DeadlineOptions 定义截止时间拦截器的配置
+type DeadlineOptions struct {
+	// Default 是一元调用的客户端没有设置截止时间时使用的超时时间，为 0 时不设置
+	Default time.Duration
+	// Max 是一元调用允许的最长超时时间，为 0 时不限制
+	Max time.Duration
+	// Methods 是按方法覆盖的规则，按顺序使用第一个匹配的规则；流式调用只使用匹配的规则
+	Methods []DeadlineMethod
+	// SafetyMargin 是向下游发起调用时从剩余时间中预留的余量，用于处理下游的响应
+	SafetyMargin time.Duration
+}
+
+// deadlineRule 返回方法使用的截止时间规则，没有需要应用的规则时返回 false
+func (o *DeadlineOptions) deadlineRule(method string, stream bool) (DeadlineMethod, bool) {
+	for _, m := range o.Methods {
+		if prefix, ok := strings.CutSuffix(m.Method, "*"); ok {
+			if strings.HasPrefix(method, prefix) {
+				return m, true
+			}
+		} else if m.Method == method {
+			return m, true
+		}
+	}
+	if stream {
+		return DeadlineMethod{}, false
+	}
+	return DeadlineMethod{Default: o.Default, Max: o.Max}, true
+}
+
+// Deadline 是支持在运行时更新配置的 gRPC 截止时间拦截器
+type Deadline struct {
+	opts atomic.Pointer[DeadlineOptions]
+}
+
+// NewDeadline 创建一个新的 Deadline 实例
+func NewDeadline(opts DeadlineOptions) *Deadline {
+	d := &Deadline{}
+	d.Update(opts)
+	return d
+}
+
+// Update 更新截止时间配置，对之后的调用生效
+func (d *Deadline) Update(opts DeadlineOptions) {
+	d.opts.Store(&opts)
+}
+
+// apply 为调用设置截止时间，返回新的上下文和取消函数
+func (d *Deadline) apply(ctx context.Context, method string, stream bool) (context.Context, context.CancelFunc) {
+	opts := d.opts.Load()
+	ctx = context.WithValue(ctx, safetyMarginKey{}, opts.SafetyMargin)
+
+	rule, ok := opts.deadlineRule(method, stream)
+	if !ok {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if rule.Max > 0 && time.Until(deadline) > rule.Max {
+			return context.WithTimeout(ctx, rule.Max)
+		}
+		return ctx, func() {}
+	}
+	if rule.Default > 0 {
+		return context.WithTimeout(ctx, rule.Default)
+	}
+	return ctx, func() {}
+}
+
+// UnaryDeadlineInterceptor 是一个 gRPC 一元拦截器，按方法设置默认截止时间并缩短超过上限的 grpc-timeout
+// 处理器返回上下文超时错误时转换为 errors.ErrTimeout（DeadlineExceeded），超时的调用记录在 grpc_deadline_exceeded_total 中
+func UnaryDeadlineInterceptor(d *Deadline) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := d.apply(ctx, info.FullMethod, false)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		return resp, deadlineError(ctx, info.FullMethod, err)
+	}
+}
+
+// StreamDeadlineInterceptor 是一个 gRPC 流拦截器，只对匹配按方法覆盖规则的流设置截止时间
+func StreamDeadlineInterceptor(d *Deadline) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := d.apply(ss.Context(), info.FullMethod, true)
+		defer cancel()
+
+		err := handler(srv, wrapServerStream(ss, ctx))
+		return deadlineError(ctx, info.FullMethod, err)
+	}
+}
+
+// deadlineError 记录超时的调用，并把上下文超时错误转换为 errors.ErrTimeout
+func deadlineError(ctx context.Context, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != context.DeadlineExceeded && status.Code(err) != codes.DeadlineExceeded {
+		return err
+	}
+
+	metrics.GRPCDeadlineExceeded.WithLabelValues(method).Inc()
+	log.FromContextNamed(ctx, loggerName).Warnw("请求处理超时", "error", err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return apierrors.ErrTimeout.GRPCStatus().Err()
+	}
+	return err
+}
+
+// safetyMarginKey 是存放向下游发起调用时预留余量的上下文键
+type safetyMarginKey struct{}
+
+// OutgoingContext 返回向下游发起调用时使用的上下文，截止时间是当前截止时间减去 DeadlineOptions.SafetyMargin
+// 上下文没有截止时间时原样返回；剩余时间不足余量时返回的上下文已经超时，调用会立即失败
+func OutgoingContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}
+	}
+	margin, _ := ctx.Value(safetyMarginKey{}).(time.Duration)
+	if margin <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, deadline.Add(-margin))
+}
+
+// UnaryClientDeadlineInterceptor 是一个 gRPC 一元客户端拦截器，使用 OutgoingContext 向下游传递剩余的时间预算
+func UnaryClientDeadlineInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := OutgoingContext(ctx)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientDeadlineInterceptor 是一个 gRPC 流客户端拦截器，使用 OutgoingContext 向下游传递剩余的时间预算
+// 流在返回之后继续使用上下文，因此不取消；截止时间到达或上游上下文结束时上下文的资源会被释放
+func StreamClientDeadlineInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, _ = OutgoingContext(ctx)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
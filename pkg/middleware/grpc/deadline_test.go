@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryDeadlineInterceptor(t *testing.T) {
+	deadline := NewDeadline(DeadlineOptions{
+		Default: time.Second,
+		Max:     time.Minute,
+		Methods: []DeadlineMethod{
+			{Method: "/helloworld.v2.Greeter/SayHello", Default: 2 * time.Second, Max: 5 * time.Second},
+			{Method: "/helloworld.v2.Greeter/*", Max: 10 * time.Second},
+		},
+		SafetyMargin: 100 * time.Millisecond,
+	})
+	interceptor := UnaryDeadlineInterceptor(deadline)
+
+	// 定义测试用例，remaining 为 0 表示调用没有截止时间
+	testCases := []struct {
+		name      string
+		method    string
+		incoming  time.Duration
+		remaining time.Duration
+	}{
+		{name: "默认截止时间", method: "/helloworld.v1.Greeter/SayHello", remaining: time.Second},
+		{name: "缩短客户端的截止时间", method: "/helloworld.v1.Greeter/SayHello", incoming: time.Hour, remaining: time.Minute},
+		{name: "保留客户端较短的截止时间", method: "/helloworld.v1.Greeter/SayHello", incoming: 3 * time.Second, remaining: 3 * time.Second},
+		{name: "按方法覆盖", method: "/helloworld.v2.Greeter/SayHello", incoming: time.Hour, remaining: 5 * time.Second},
+		{name: "按方法覆盖的默认值", method: "/helloworld.v2.Greeter/SayHello", remaining: 2 * time.Second},
+		{name: "匹配的规则没有默认值", method: "/helloworld.v2.Greeter/SayHelloAgain"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.incoming > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.incoming)
+				defer cancel()
+			}
+
+			var remaining, outgoing time.Duration
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if d, ok := ctx.Deadline(); ok {
+					remaining = time.Until(d)
+				}
+				out, cancel := OutgoingContext(ctx)
+				defer cancel()
+				if d, ok := out.Deadline(); ok {
+					outgoing = time.Until(d)
+				}
+				return nil, nil
+			}
+			if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler); err != nil {
+				t.Fatalf("调用失败: %v", err)
+			}
+
+			if tc.remaining == 0 {
+				if remaining != 0 {
+					t.Errorf("不应该设置截止时间，剩余 %s", remaining)
+				}
+				return
+			}
+			if remaining > tc.remaining || remaining < tc.remaining-100*time.Millisecond {
+				t.Errorf("剩余时间 = %s，期望 %s", remaining, tc.remaining)
+			}
+			if margin := remaining - outgoing; margin < 90*time.Millisecond || margin > 110*time.Millisecond {
+				t.Errorf("下游调用预留的余量 = %s，期望 100ms", margin)
+			}
+		})
+	}
+
+	t.Run("超时", func(t *testing.T) {
+		deadline.Update(DeadlineOptions{Default: 10 * time.Millisecond})
+		method := "/helloworld.v1.Greeter/SayHello"
+		before := testutil.ToFloat64(metrics.GRPCDeadlineExceeded.WithLabelValues(method))
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		if st := status.Convert(err); st.Code() != codes.DeadlineExceeded || st.Message() != apierrors.ErrTimeout.Message {
+			t.Errorf("错误 = %v，期望 errors.ErrTimeout", err)
+		}
+		if after := testutil.ToFloat64(metrics.GRPCDeadlineExceeded.WithLabelValues(method)); after != before+1 {
+			t.Errorf("超时计数 = %v，期望 %v", after, before+1)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// UnaryErrorInterceptor 是一个 gRPC 一元拦截器，将处理器返回的业务错误 *errors.Error（包括被包装的）
+// 转换为携带 errdetails.ErrorInfo 的 gRPC 状态错误，其他错误保持不变
+func UnaryErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, toStatusError(err)
+	}
+}
+
+// StreamErrorInterceptor 是一个 gRPC 流拦截器，将处理器返回的业务错误转换为 gRPC 状态错误
+func StreamErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatusError(handler(srv, ss))
+	}
+}
+
+// toStatusError 将业务错误转换为 gRPC 状态错误
+func toStatusError(err error) error {
+	var e *apierrors.Error
+	if errors.As(err, &e) {
+		return e.GRPCStatus().Err()
+	}
+	return err
+}
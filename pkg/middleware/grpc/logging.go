@@ -10,15 +10,20 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// loggerName 是本包日志记录器的名称，可以通过 log.SetLoggerLevel 单独调整级别
+const loggerName = "middleware.grpc"
+
 // UnaryLoggingInterceptor 是一个 gRPC 一元拦截器，用于记录请求信息
+// 拦截器为请求注入携带 method 和 client_ip 的日志记录器，服务实现通过 log.FromContext 获取，
+// 记录的日志会自动附加 trace_id、span_id、request_id 和 subject
 func UnaryLoggingInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
+		ctx = log.WithContext(ctx, "method", info.FullMethod, "client_ip", clientIPString(ctx))
 		resp, err := handler(ctx, req)
 		duration := time.Since(start)
 
-		log.L().WithValues(
-			"method", info.FullMethod,
+		log.FromContext(ctx).WithValues(
 			"duration", duration,
 			"error", err,
 		).Infof("gRPC request")
@@ -28,14 +33,15 @@ func UnaryLoggingInterceptor() grpc.UnaryServerInterceptor {
 }
 
 // StreamLoggingInterceptor 是一个 gRPC 流拦截器，用于记录请求信息
+// 注入的日志记录器可以通过 log.FromContext(ss.Context()) 获取
 func StreamLoggingInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		start := time.Now()
-		err := handler(srv, ss)
+		ctx := log.WithContext(ss.Context(), "method", info.FullMethod, "client_ip", clientIPString(ss.Context()))
+		err := handler(srv, wrapServerStream(ss, ctx))
 		duration := time.Since(start)
 
-		log.L().WithValues(
-			"method", info.FullMethod,
+		log.FromContext(ctx).WithValues(
 			"duration", duration,
 			"error", err,
 		).Infof("gRPC stream request")
@@ -49,7 +55,7 @@ func UnaryRecoveryInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		defer func() {
 			if r := recover(); r != nil {
-				log.L().WithValues(
+				log.FromContext(ctx).WithValues(
 					"method", info.FullMethod,
 					"panic", r,
 				).Errorf("gRPC panic recovered")
@@ -65,7 +71,7 @@ func StreamRecoveryInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
 		defer func() {
 			if r := recover(); r != nil {
-				log.L().WithValues(
+				log.FromContext(ss.Context()).WithValues(
 					"method", info.FullMethod,
 					"panic", r,
 				).Errorf("gRPC stream panic recovered")
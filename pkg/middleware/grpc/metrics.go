@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryMetricsInterceptor 是一个 gRPC 一元拦截器，用于记录请求数、耗时、状态码和处理中的请求数
+func UnaryMetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		inFlight := metrics.GRPCRequestsInFlight.WithLabelValues(info.FullMethod)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeGRPCRequest(info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor 是一个 gRPC 流拦截器，除请求级指标外还记录每个流收发的消息数
+func StreamMetricsInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		inFlight := metrics.GRPCRequestsInFlight.WithLabelValues(info.FullMethod)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		err := handler(srv, &metricsServerStream{ServerStream: ss, method: info.FullMethod})
+		observeGRPCRequest(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// observeGRPCRequest 记录一次 gRPC 请求的结果和耗时
+func observeGRPCRequest(method string, start time.Time, err error) {
+	metrics.GRPCRequestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.GRPCRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// metricsServerStream 包装 grpc.ServerStream 以统计收发的消息数
+type metricsServerStream struct {
+	grpc.ServerStream
+	method string
+}
+
+// SendMsg 发送消息并计数
+func (s *metricsServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		metrics.GRPCStreamMessagesSent.WithLabelValues(s.method).Inc()
+	}
+	return err
+}
+
+// RecvMsg 接收消息并计数
+func (s *metricsServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		metrics.GRPCStreamMessagesReceived.WithLabelValues(s.method).Inc()
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/health"
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryRateLimitInterceptor 是一个 gRPC 一元拦截器，按 gRPC 全方法名匹配限流策略
+// 限流信息通过 x-ratelimit-limit、x-ratelimit-remaining 和 x-ratelimit-reset 响应头元数据返回，
+// 被拒绝的请求返回 errors.ErrRateLimit（ResourceExhausted）和 retry-after 元数据
+func UnaryRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := allowCall(ctx, limiter, info.FullMethod, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		}); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitInterceptor 是一个 gRPC 流拦截器，每个流在建立时消耗一个令牌
+func StreamRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allowCall(ss.Context(), limiter, info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// allowCall 检查调用是否被允许并通过 setHeader 返回限流元数据，被拒绝时返回 errors.ErrRateLimit
+// grpc.health.v1.Health 服务的调用不限流
+func allowCall(ctx context.Context, limiter *ratelimit.Limiter, method string, setHeader func(metadata.MD) error) error {
+	if !limiter.Enabled() || health.IsGRPCMethod(method) {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	res := limiter.Allow(ctx, "", method, ratelimit.Request{
+		ClientIP: clientIPString(ctx),
+		Subject:  log.SubjectFromContext(ctx),
+		Header:   md.Get,
+		Policy:   boundRateLimitPolicy(ctx),
+	})
+	headers := res.Headers()
+	if len(headers) > 0 {
+		out := metadata.MD{}
+		for k, v := range headers {
+			out.Set(k, v)
+		}
+		// 头部已经发送时无法再设置，忽略错误
+		_ = setHeader(out)
+	}
+	if res.Allowed {
+		return nil
+	}
+
+	log.FromContextNamed(ctx, loggerName).Debugw("请求被限流", "policy", res.Policy)
+	return apierrors.ErrRateLimit.
+		WithMetadata("policy", res.Policy).
+		WithMetadata("retry_after", headers[ratelimit.HeaderRetryAfter]).
+		GRPCStatus().Err()
+}
+
+// boundRateLimitPolicy 返回调用方凭证绑定的限流策略，没有调用方时返回空字符串
+func boundRateLimitPolicy(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.RateLimitPolicy
+	}
+	return ""
+}
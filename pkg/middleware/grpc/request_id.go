@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/requestid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryRequestIDInterceptor 是一个 gRPC 一元拦截器，处理请求 ID
+// 使用客户端通过 x-request-id 元数据传入的合法请求 ID，没有或不合法时生成一个新的，
+// 请求 ID 会通过响应头元数据返回、放入上下文供日志使用，并记录到当前 span 中
+func UnaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, id := withRequestID(ctx)
+		if err := grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id)); err != nil {
+			log.FromContextNamed(ctx, loggerName).Debugw("设置请求 ID 响应头失败", "error", err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRequestIDInterceptor 是一个 gRPC 流拦截器，处理请求 ID
+func StreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, id := withRequestID(ss.Context())
+		if err := ss.SetHeader(metadata.Pairs(requestid.MetadataKey, id)); err != nil {
+			log.FromContextNamed(ctx, loggerName).Debugw("设置请求 ID 响应头失败", "error", err)
+		}
+		return handler(srv, wrapServerStream(ss, ctx))
+	}
+}
+
+// withRequestID 从入站元数据中取出请求 ID，没有或不合法时生成一个新的，放入上下文并记录到当前 span 中
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.MetadataKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = requestid.Ensure(id)
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(requestid.Attribute, id))
+	return log.ContextWithRequestID(ctx, id), id
+}
@@ -0,0 +1,23 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// wrappedServerStream 包装 grpc.ServerStream 以替换其上下文
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回替换后的上下文
+func (s *wrappedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// wrapServerStream 返回一个使用给定上下文的 ServerStream
+func wrapServerStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &wrappedServerStream{ServerStream: ss, ctx: ctx}
+}
@@ -3,23 +3,16 @@ package grpc
 import (
 	"context"
 
+	"github.com/costa92/go-protoc/pkg/validation"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
-// validator 接口定义了一个验证方法
-type validator interface {
-	Validate() error
-}
-
 // ValidationUnaryServerInterceptor 返回一个 gRPC 拦截器，用于验证请求
+// 请求消息实现了 ValidateAll 时返回所有字段错误，错误以 errdetails.BadRequest 的形式附加在 InvalidArgument 状态中
 func ValidationUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		if v, ok := req.(validator); ok {
-			if err := v.Validate(); err != nil {
-				return nil, status.Error(codes.InvalidArgument, err.Error())
-			}
+		if err := validation.Validate(req); err != nil {
+			return nil, validation.Status(req, err).Err()
 		}
 		return handler(ctx, req)
 	}
@@ -44,10 +37,8 @@ func (s *recvWrapper) RecvMsg(m interface{}) error {
 		return err
 	}
 
-	if v, ok := m.(validator); ok {
-		if err := v.Validate(); err != nil {
-			return status.Error(codes.InvalidArgument, err.Error())
-		}
+	if err := validation.Validate(m); err != nil {
+		return validation.Status(m, err).Err()
 	}
 
 	return nil
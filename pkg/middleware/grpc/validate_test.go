@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	helloworldv2 "github.com/costa92/go-protoc/pkg/api/helloworld/v2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidationUnaryServerInterceptor(t *testing.T) {
+	interceptor := ValidationUnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.v2.Greeter/SayHello"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &helloworldv2.HelloReply{}, nil
+	}
+
+	// 校验通过
+	if _, err := interceptor(context.Background(), &helloworldv2.HelloRequest{Name: "bob"}, info, handler); err != nil {
+		t.Fatalf("期望校验通过，实际: %v", err)
+	}
+
+	// 校验失败
+	_, err := interceptor(context.Background(), &helloworldv2.HelloRequest{}, info, handler)
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("状态码不匹配: 期望=%s, 实际=%s", codes.InvalidArgument, st.Code())
+	}
+
+	var br *errdetails.BadRequest
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*errdetails.BadRequest); ok {
+			br = d
+		}
+	}
+	if br == nil || len(br.GetFieldViolations()) != 1 {
+		t.Fatalf("期望包含一个 BadRequest 字段错误: %v", st.Details())
+	}
+	if field := br.GetFieldViolations()[0].GetField(); field != "name" {
+		t.Errorf("字段路径不匹配: 期望=name, 实际=%s", field)
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// guardKey 是存放交给 GatewayAuthMiddleware 认证的 Guard 的上下文键
+type guardKey struct{}
+
+// AuthMiddleware 创建一个使用 guard 的认证中间件，通过认证的调用方通过 auth.FromContext 获取
+// 自定义路由按 PublicPaths 判断是否需要认证；gRPC-Gateway 在内层处理器中才完成路由匹配，
+// 因此网关路由把 guard 放入上下文，由 GatewayAuthMiddleware 额外按对应的 gRPC 方法判断
+// 认证失败时返回 401 和 WWW-Authenticate: Bearer 响应头
+func AuthMiddleware(guard *auth.Guard) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !guard.Enabled() || guard.PublicPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if route := mux.CurrentRoute(r); route != nil && route.GetName() == GatewayRouteName {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), guardKey{}, guard)))
+				return
+			}
+			if r, ok := authenticateRequest(w, r, guard); ok {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// GatewayAuthMiddleware 返回 gRPC-Gateway 的认证中间件，使用 AuthMiddleware 放入上下文的 guard
+// 对应的 gRPC 方法在 PublicMethods 中或带有 (auth.v1.public) 选项时不需要认证
+func GatewayAuthMiddleware() runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			guard, _ := r.Context().Value(guardKey{}).(*auth.Guard)
+			if guard == nil {
+				next(w, r, pathParams)
+				return
+			}
+
+			if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+				if route := lookupGatewayRoute(r.Method, pattern.String()); route != nil && guard.PublicMethod(route.rpcMethod) {
+					next(w, r, pathParams)
+					return
+				}
+			}
+			if r, ok := authenticateRequest(w, r, guard); ok {
+				next(w, r, pathParams)
+			}
+		}
+	}
+}
+
+// authenticateRequest 认证请求并返回携带调用方的请求，失败时写入 401 响应并返回 false
+func authenticateRequest(w http.ResponseWriter, r *http.Request, guard *auth.Guard) (*http.Request, bool) {
+	p, err := guard.Authenticate(r.Context(), auth.Request{
+		Header: r.Header.Values,
+		Query:  func(name string) []string { return r.URL.Query()[name] },
+	})
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		apiErr, ok := err.(*errors.Error)
+		if !ok {
+			apiErr = errors.ErrUnauthorized
+		}
+		response.WriteAPIError(w, apiErr)
+		return r, false
+	}
+	return r.WithContext(auth.NewContext(r.Context(), p)), true
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	helloworldv2 "github.com/costa92/go-protoc/pkg/api/helloworld/v2"
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// tokenAuthenticator 是测试用的认证器，只接受令牌 valid
+type tokenAuthenticator struct{}
+
+func (tokenAuthenticator) Authenticate(ctx context.Context, req auth.Request) (*auth.Principal, error) {
+	switch auth.BearerToken(req) {
+	case "":
+		return nil, auth.ErrNoCredentials
+	case "valid":
+		return &auth.Principal{Subject: "alice"}, nil
+	default:
+		return nil, errors.ErrInvalidToken
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	guard := auth.NewGuard(auth.Options{
+		Enable:        true,
+		PublicMethods: []string{"/helloworld.v2.Greeter/SayHello"},
+		PublicPaths:   []string{"/healthz"},
+	}, tokenAuthenticator{})
+
+	gwmux := runtime.NewServeMux(append(GatewayRouteOptions(), runtime.WithMiddlewares(GatewayAuthMiddleware()))...)
+	response.Setup(gwmux)
+	if err := helloworldv2.RegisterGreeterHandlerServer(context.Background(), gwmux, &greeterServer{}); err != nil {
+		t.Fatalf("注册 gRPC-Gateway 处理器失败: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.Use(AuthMiddleware(guard))
+	subject := func(w http.ResponseWriter, r *http.Request) {
+		p, _ := auth.FromContext(r.Context())
+		if p == nil {
+			p = &auth.Principal{}
+		}
+		response.WriteSuccess(w, p.Subject, "")
+	}
+	router.HandleFunc("/users/{id}", subject)
+	router.HandleFunc("/healthz", subject)
+	router.PathPrefix("/").Handler(gwmux).Name(GatewayRouteName)
+
+	// 定义测试用例，err 为 nil 表示请求通过认证
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+		err    *errors.Error
+	}{
+		{name: "自定义路由有效令牌", method: http.MethodGet, path: "/users/1", token: "valid", status: http.StatusOK},
+		{name: "自定义路由没有令牌", method: http.MethodGet, path: "/users/1", status: http.StatusUnauthorized, err: errors.ErrUnauthorized},
+		{name: "自定义路由无效令牌", method: http.MethodGet, path: "/users/1", token: "invalid", status: http.StatusUnauthorized, err: errors.ErrInvalidToken},
+		{name: "公开路径", method: http.MethodGet, path: "/healthz", status: http.StatusOK},
+		{name: "网关公开方法", method: http.MethodPost, path: "/v2/hello", status: http.StatusOK},
+		{name: "网关路由没有令牌", method: http.MethodGet, path: "/v2/hello/bob", status: http.StatusUnauthorized, err: errors.ErrUnauthorized},
+		{name: "网关路由有效令牌", method: http.MethodGet, path: "/v2/hello/bob", token: "valid", status: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"name":"bob"}`))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("状态码不匹配: 期望=%d, 实际=%d, 响应=%s", tc.status, rec.Code, rec.Body.String())
+			}
+			if tc.err == nil {
+				if tc.token == "valid" && strings.HasPrefix(tc.path, "/users/") && !strings.Contains(rec.Body.String(), "alice") {
+					t.Errorf("处理器没有获取到调用方: %s", rec.Body.String())
+				}
+				return
+			}
+
+			if rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q，期望 Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+			var resp response.Wrapper
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if resp.ErrorCode != tc.err.Code || resp.Reason != tc.err.Reason {
+				t.Errorf("错误响应 = %+v，期望 %s", resp, tc.err.Reason)
+			}
+		})
+	}
+}
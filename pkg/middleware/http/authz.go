@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/authz"
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// authorizerKey 是存放交给 GatewayAuthzMiddleware 授权的 Authorizer 的上下文键
+type authorizerKey struct{}
+
+// AuthzMiddleware 创建一个使用 authorizer 的授权中间件，必须位于 AuthMiddleware 之后
+// 自定义路由按 HTTP 方法和路由模板授权；gRPC-Gateway 路由把 authorizer 放入上下文，
+// 由 GatewayAuthzMiddleware 额外按对应的 gRPC 方法授权
+// 上下文中没有调用方的请求是认证中间件放行的公开请求，不需要授权；拒绝时返回 403
+func AuthzMiddleware(authorizer *authz.Authorizer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !authorizer.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// 网关路由的认证同样在 gRPC-Gateway 内层完成，此时上下文中还没有调用方
+			route := mux.CurrentRoute(r)
+			if route != nil && route.GetName() == GatewayRouteName {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authorizerKey{}, authorizer)))
+				return
+			}
+			p, ok := auth.FromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var template string
+			if route != nil {
+				template, _ = route.GetPathTemplate()
+			}
+			if authorizeRequest(w, r, authorizer, p, authz.Resource{HTTPMethod: r.Method, Route: template}) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// GatewayAuthzMiddleware 返回 gRPC-Gateway 的授权中间件，使用 AuthzMiddleware 放入上下文的 authorizer
+// 权限按路由模板（如 /v1/hello/{name}）或对应的 gRPC 方法匹配，与直接调用 gRPC 使用同一份策略
+func GatewayAuthzMiddleware() runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			authorizer, _ := r.Context().Value(authorizerKey{}).(*authz.Authorizer)
+			p, ok := auth.FromContext(r.Context())
+			if authorizer == nil || !ok {
+				next(w, r, pathParams)
+				return
+			}
+
+			res := authz.Resource{HTTPMethod: r.Method}
+			if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+				res.Route = bareTemplate(pattern.String())
+				if route := lookupGatewayRoute(r.Method, pattern.String()); route != nil {
+					res.Method = route.rpcMethod
+				}
+			}
+			if authorizeRequest(w, r, authorizer, p, res) {
+				next(w, r, pathParams)
+			}
+		}
+	}
+}
+
+// authorizeRequest 判断调用方能否访问资源，拒绝时写入 403 响应并返回 false
+func authorizeRequest(w http.ResponseWriter, r *http.Request, authorizer *authz.Authorizer, p *auth.Principal, res authz.Resource) bool {
+	if err := authorizer.Authorize(r.Context(), p, res); err != nil {
+		apiErr, ok := err.(*errors.Error)
+		if !ok {
+			apiErr = errors.ErrPermissionDenied
+		}
+		response.WriteAPIError(w, apiErr)
+		return false
+	}
+	return true
+}
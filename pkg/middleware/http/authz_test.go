@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	helloworldv2 "github.com/costa92/go-protoc/pkg/api/helloworld/v2"
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/authz"
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestAuthzMiddleware(t *testing.T) {
+	guard := auth.NewGuard(auth.Options{Enable: true, PublicPaths: []string{"/healthz"}}, tokenAuthenticator{})
+	authorizer, err := authz.NewAuthorizer(authz.Options{Enable: true}, &authz.Policy{
+		Roles: []authz.Role{{Name: "greeter", Permissions: []authz.Permission{
+			{Methods: []string{"/helloworld.v2.Greeter/SayHello"}, Routes: []string{"GET /users/{id}"}},
+		}}},
+		Bindings: []authz.Binding{{Roles: []string{"greeter"}, Subjects: []string{"alice"}}},
+	})
+	if err != nil {
+		t.Fatalf("创建授权器失败: %v", err)
+	}
+
+	gwmux := runtime.NewServeMux(append(GatewayRouteOptions(), runtime.WithMiddlewares(GatewayAuthMiddleware(), GatewayAuthzMiddleware()))...)
+	response.Setup(gwmux)
+	if err := helloworldv2.RegisterGreeterHandlerServer(context.Background(), gwmux, &greeterServer{}); err != nil {
+		t.Fatalf("注册 gRPC-Gateway 处理器失败: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.Use(AuthMiddleware(guard), AuthzMiddleware(authorizer))
+	ok := func(w http.ResponseWriter, r *http.Request) { response.WriteSuccess(w, nil, "") }
+	router.HandleFunc("/users/{id}", ok)
+	router.HandleFunc("/healthz", ok)
+	router.PathPrefix("/").Handler(gwmux).Name(GatewayRouteName)
+
+	// 定义测试用例，测试认证器只接受令牌 valid，对应的调用方为 alice
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{name: "自定义路由允许", method: http.MethodGet, path: "/users/1", status: http.StatusOK},
+		{name: "自定义路由 HTTP 方法不匹配", method: http.MethodDelete, path: "/users/1", status: http.StatusForbidden},
+		{name: "公开路径不需要授权", method: http.MethodGet, path: "/healthz", status: http.StatusOK},
+		{name: "网关路由按 gRPC 方法允许", method: http.MethodPost, path: "/v2/hello", status: http.StatusOK},
+		{name: "网关路由没有权限", method: http.MethodGet, path: "/v2/hello/bob", status: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"name":"bob"}`))
+			req.Header.Set("Authorization", "Bearer valid")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("状态码不匹配: 期望=%d, 实际=%d, 响应=%s", tc.status, rec.Code, rec.Body.String())
+			}
+			if tc.status != http.StatusForbidden {
+				return
+			}
+			var resp response.Wrapper
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if resp.Reason != errors.ErrPermissionDenied.Reason {
+				t.Errorf("错误响应 = %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	tlsutil "github.com/costa92/go-protoc/pkg/tls"
+	"github.com/gorilla/mux"
+)
+
+// ClientCertMiddleware 创建一个中间件，将经过验证的客户端证书身份放入请求上下文
+// 处理器可以通过 tls.IdentityFromContext 获取客户端身份，身份名称会作为 subject 附加到请求日志中
+func ClientCertMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id, ok := tlsutil.IdentityFromConnectionState(r.TLS); ok {
+				ctx := log.ContextWithSubject(tlsutil.NewContext(r.Context(), id), id.Name())
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
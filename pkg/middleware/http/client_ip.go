@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/costa92/go-protoc/pkg/clientip"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ClientIPMiddleware 创建一个客户端 IP 中间件
+// 使用 resolver 解析客户端 IP，放入上下文供限流、日志和 IP 过滤使用，并记录到当前 span 的 client.address 属性中
+// 应放在追踪中间件之后、日志和限流中间件之前
+func ClientIPMiddleware(resolver *clientip.Resolver) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if addr := resolver.FromRequest(r); addr.IsValid() {
+				ctx := clientip.NewContext(r.Context(), addr)
+				trace.SpanFromContext(ctx).SetAttributes(attribute.String(clientip.Attribute, addr.String()))
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IPFilterMiddleware 创建一个 IP 过滤中间件，客户端 IP 不被允许时返回 403
+// 客户端 IP 由 ClientIPMiddleware 解析，没有时使用对端地址
+func IPFilterMiddleware(filter *clientip.Filter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !filter.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			addr, _ := clientip.FromContext(r.Context())
+			if !addr.IsValid() {
+				addr, _ = clientip.ParseAddr(r.RemoteAddr)
+			}
+			if !filter.Allowed(addr) {
+				// 日志中间件已经在日志记录器中附加了 client_ip
+				ctxLogger(r.Context()).Infow("客户端 IP 不允许访问")
+				response.WriteForbidden(w, "禁止访问", nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP 返回请求的客户端 IP，没有经过 ClientIPMiddleware 时使用去掉端口的对端地址
+func clientIP(r *http.Request) string {
+	if addr, ok := clientip.FromContext(r.Context()); ok {
+		return addr.String()
+	}
+	if addr, ok := clientip.ParseAddr(r.RemoteAddr); ok {
+		return addr.String()
+	}
+	return r.RemoteAddr
+}
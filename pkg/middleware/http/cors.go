@@ -2,49 +2,304 @@ package http
 
 import (
 	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
-// CORSMiddleware 创建一个 CORS 中间件，接受明确的配置参数而不是依赖全局配置
-func CORSMiddleware(
-	allowOrigins []string,
-	allowMethods []string,
-	allowHeaders []string,
-	exposeHeaders []string,
-	allowCredentials bool,
-	maxAge time.Duration,
-) mux.MiddlewareFunc {
+// RegexOriginPrefix 是正则表达式形式的允许来源的前缀，如 regex:^https://app-\d+\.example\.com$
+const RegexOriginPrefix = "regex:"
+
+// defaultCORSMethods 是没有配置允许的方法时使用的简单方法
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// CORSPolicy 定义一组跨域策略
+type CORSPolicy struct {
+	// AllowOrigins 是允许的来源，支持以下形式:
+	//   - 精确匹配，如 https://example.com，不区分大小写
+	//   - 子域名通配，如 https://*.example.com，匹配任意层级的子域名，不匹配 https://example.com
+	//   - 正则表达式，以 regex: 开头，匹配完整的 Origin
+	//   - *，允许所有来源，此时不会发送 Access-Control-Allow-Credentials
+	AllowOrigins []string
+	// AllowMethods 是预检请求允许的方法，为空时允许 GET、HEAD 和 POST
+	AllowMethods []string
+	// AllowHeaders 是预检请求允许的请求头，不区分大小写，* 表示允许所有请求头
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge 是预检结果的缓存时间，以秒为单位发送，为 0 时不发送
+	MaxAge time.Duration
+}
+
+// CORSRoute 是按路径前缀覆盖的跨域策略，匹配的请求完全使用该策略
+type CORSRoute struct {
+	PathPrefix string
+	CORSPolicy
+}
+
+// CORSOptions 定义跨域中间件的配置
+type CORSOptions struct {
+	// CORSPolicy 是默认的跨域策略
+	CORSPolicy
+	// Routes 是按路径前缀覆盖的跨域策略，多个前缀匹配时使用最长的前缀
+	Routes []CORSRoute
+}
+
+// corsPolicy 是预处理后的跨域策略
+type corsPolicy struct {
+	allowAll       bool
+	origins        []string
+	wildcards      []wildcardOrigin
+	regexps        []*regexp.Regexp
+	methods        []string
+	allowedHeaders []string
+	allowAllHeader bool
+	// 预先拼接好的响应头
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	credentials   bool
+	maxAge        string
+}
+
+// wildcardOrigin 是子域名通配的来源，如 https://*.example.com 拆分为 https:// 和 .example.com
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+// match 判断 origin 是否匹配通配来源，通配部分只能包含域名字符
+func (w wildcardOrigin) match(origin string) bool {
+	if len(origin) <= len(w.prefix)+len(w.suffix) || !strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+		return false
+	}
+	sub := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+	return !strings.ContainsAny(sub, "/:@?#") && !strings.HasPrefix(sub, ".") && !strings.HasSuffix(sub, ".")
+}
+
+// newCORSPolicy 预处理跨域策略，无效的正则表达式会被忽略并记录日志
+func newCORSPolicy(p *CORSPolicy) *corsPolicy {
+	cp := &corsPolicy{credentials: p.AllowCredentials}
+	for _, origin := range p.AllowOrigins {
+		switch {
+		case origin == "*":
+			cp.allowAll = true
+		case strings.HasPrefix(origin, RegexOriginPrefix):
+			re, err := regexp.Compile(strings.TrimPrefix(origin, RegexOriginPrefix))
+			if err != nil {
+				logger().Errorw("忽略无效的 CORS 来源", "origin", origin, "error", err)
+				continue
+			}
+			cp.regexps = append(cp.regexps, re)
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(strings.ToLower(origin), "*")
+			cp.wildcards = append(cp.wildcards, wildcardOrigin{prefix: prefix, suffix: suffix})
+		default:
+			cp.origins = append(cp.origins, strings.ToLower(origin))
+		}
+	}
+	if cp.allowAll && cp.credentials {
+		logger().Warnw("CORS 允许所有来源时不能携带凭证，已忽略 allow_credentials")
+		cp.credentials = false
+	}
+
+	methods := p.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	for _, m := range methods {
+		cp.methods = append(cp.methods, strings.ToUpper(m))
+	}
+	cp.allowMethods = joinStrings(cp.methods)
+
+	var allowHeaders []string
+	for _, h := range p.AllowHeaders {
+		if h == "*" {
+			cp.allowAllHeader = true
+			continue
+		}
+		allowHeaders = append(allowHeaders, h)
+		cp.allowedHeaders = append(cp.allowedHeaders, http.CanonicalHeaderKey(h))
+	}
+	cp.allowHeaders = joinStrings(allowHeaders)
+	cp.exposeHeaders = joinStrings(p.ExposeHeaders)
+	if p.MaxAge > 0 {
+		cp.maxAge = strconv.FormatInt(int64(p.MaxAge/time.Second), 10)
+	}
+	return cp
+}
+
+// allowOrigin 判断是否允许 origin，返回 Access-Control-Allow-Origin 的值
+func (p *corsPolicy) allowOrigin(origin string) (string, bool) {
+	lower := strings.ToLower(origin)
+	if slices.Contains(p.origins, lower) {
+		return origin, true
+	}
+	for _, w := range p.wildcards {
+		if w.match(lower) {
+			return origin, true
+		}
+	}
+	for _, re := range p.regexps {
+		if re.MatchString(origin) {
+			return origin, true
+		}
+	}
+	if p.allowAll {
+		return "*", true
+	}
+	return "", false
+}
+
+// allowRequestHeaders 判断是否允许预检请求中的 Access-Control-Request-Headers
+func (p *corsPolicy) allowRequestHeaders(requested string) bool {
+	if p.allowAllHeader || requested == "" {
+		return true
+	}
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && !slices.Contains(p.allowedHeaders, http.CanonicalHeaderKey(h)) {
+			return false
+		}
+	}
+	return true
+}
+
+// corsRoute 是预处理后的按路径前缀覆盖的跨域策略
+type corsRoute struct {
+	prefix string
+	policy *corsPolicy
+}
+
+// corsState 是预处理后的跨域配置
+type corsState struct {
+	policy *corsPolicy
+	// routes 按前缀长度从长到短排序
+	routes []corsRoute
+}
+
+// newCORSState 预处理跨域配置
+func newCORSState(opts CORSOptions) *corsState {
+	s := &corsState{policy: newCORSPolicy(&opts.CORSPolicy)}
+	for i := range opts.Routes {
+		s.routes = append(s.routes, corsRoute{prefix: opts.Routes[i].PathPrefix, policy: newCORSPolicy(&opts.Routes[i].CORSPolicy)})
+	}
+	slices.SortStableFunc(s.routes, func(a, b corsRoute) int { return len(b.prefix) - len(a.prefix) })
+	return s
+}
+
+// policyFor 返回路径使用的跨域策略
+func (s *corsState) policyFor(path string) *corsPolicy {
+	for _, r := range s.routes {
+		if strings.HasPrefix(path, r.prefix) {
+			return r.policy
+		}
+	}
+	return s.policy
+}
+
+// CORS 是支持在运行时更新配置的跨域中间件
+type CORS struct {
+	state atomic.Pointer[corsState]
+}
+
+// NewCORS 创建一个新的 CORS 实例
+func NewCORS(opts CORSOptions) *CORS {
+	c := &CORS{}
+	c.state.Store(newCORSState(opts))
+	return c
+}
+
+// Update 更新跨域配置，对之后的请求生效
+func (c *CORS) Update(opts CORSOptions) {
+	c.state.Store(newCORSState(opts))
+}
+
+// Middleware 返回跨域中间件
+// 只处理带 Origin 请求头的请求；带 Access-Control-Request-Method 的 OPTIONS 请求视为预检请求，直接响应，
+// 来源、方法或请求头不被允许的预检请求返回 403，不被允许来源的普通请求不添加跨域响应头
+func (c *CORS) Middleware() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// 设置 CORS 头
-			if len(allowOrigins) > 0 {
-				w.Header().Set("Access-Control-Allow-Origin", allowOrigins[0])
-			}
-			if len(allowMethods) > 0 {
-				w.Header().Set("Access-Control-Allow-Methods", joinStrings(allowMethods))
-			}
-			if len(allowHeaders) > 0 {
-				w.Header().Set("Access-Control-Allow-Headers", joinStrings(allowHeaders))
-			}
-			if len(exposeHeaders) > 0 {
-				w.Header().Set("Access-Control-Expose-Headers", joinStrings(exposeHeaders))
+			policy := c.state.Load().policyFor(r.URL.Path)
+			header := w.Header()
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// 响应内容取决于 Origin，避免缓存把一个来源的响应返回给其他来源
+			header.Add("Vary", "Origin")
+			if preflight {
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
 			}
-			if allowCredentials {
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
 			}
-			if maxAge > 0 {
-				w.Header().Set("Access-Control-Max-Age", maxAge.String())
+
+			allowOrigin, ok := policy.allowOrigin(origin)
+			if !preflight {
+				if ok {
+					header.Set("Access-Control-Allow-Origin", allowOrigin)
+					if policy.credentials {
+						header.Set("Access-Control-Allow-Credentials", "true")
+					}
+					if policy.exposeHeaders != "" {
+						header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+					}
+				}
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			// 处理预检请求
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
+			method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+			if !ok || !slices.Contains(policy.methods, method) || !policy.allowRequestHeaders(requestHeaders) {
+				ctxLogger(r.Context()).Debugw("拒绝跨域预检请求", "origin", origin, "method", method, "headers", requestHeaders)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			header.Set("Access-Control-Allow-Origin", allowOrigin)
+			header.Set("Access-Control-Allow-Methods", policy.allowMethods)
+			if policy.allowAllHeader && requestHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", requestHeaders)
+			} else if policy.allowHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+			}
+			if policy.credentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if policy.maxAge != "" {
+				header.Set("Access-Control-Max-Age", policy.maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
 		})
 	}
 }
+
+// CORSMiddleware 创建一个 CORS 中间件，接受明确的配置参数而不是依赖全局配置
+func CORSMiddleware(
+	allowOrigins []string,
+	allowMethods []string,
+	allowHeaders []string,
+	exposeHeaders []string,
+	allowCredentials bool,
+	maxAge time.Duration,
+) mux.MiddlewareFunc {
+	return NewCORS(CORSOptions{
+		CORSPolicy: CORSPolicy{
+			AllowOrigins:     allowOrigins,
+			AllowMethods:     allowMethods,
+			AllowHeaders:     allowHeaders,
+			ExposeHeaders:    exposeHeaders,
+			AllowCredentials: allowCredentials,
+			MaxAge:           maxAge,
+		},
+	}).Middleware()
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	cors := NewCORS(CORSOptions{
+		CORSPolicy: CORSPolicy{
+			AllowOrigins: []string{
+				"https://example.com",
+				"https://*.example.org",
+				`regex:^https://app-\d+\.example\.net$`,
+			},
+			AllowMethods:     []string{"GET", "PUT"},
+			AllowHeaders:     []string{"Authorization", "content-type"},
+			ExposeHeaders:    []string{"X-Request-ID"},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		},
+		Routes: []CORSRoute{
+			{PathPrefix: "/public/", CORSPolicy: CORSPolicy{AllowOrigins: []string{"*"}, AllowCredentials: true}},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		// wantStatus 为 0 表示请求交给下一个处理器
+		wantStatus int
+		want       map[string]string
+	}{
+		{
+			name:    "精确匹配的来源",
+			method:  http.MethodGet,
+			path:    "/v1/hello",
+			headers: map[string]string{"Origin": "https://example.com"},
+			want:    map[string]string{"Access-Control-Allow-Origin": "https://example.com", "Access-Control-Allow-Credentials": "true", "Access-Control-Expose-Headers": "X-Request-ID", "Vary": "Origin"},
+		},
+		{
+			name:    "子域名通配",
+			method:  http.MethodGet,
+			path:    "/v1/hello",
+			headers: map[string]string{"Origin": "https://a.b.example.org"},
+			want:    map[string]string{"Access-Control-Allow-Origin": "https://a.b.example.org"},
+		},
+		{
+			name:    "子域名通配不匹配根域名",
+			method:  http.MethodGet,
+			path:    "/v1/hello",
+			headers: map[string]string{"Origin": "https://example.org"},
+			want:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:    "正则表达式",
+			method:  http.MethodGet,
+			path:    "/v1/hello",
+			headers: map[string]string{"Origin": "https://app-12.example.net"},
+			want:    map[string]string{"Access-Control-Allow-Origin": "https://app-12.example.net"},
+		},
+		{
+			name:    "不允许的来源",
+			method:  http.MethodGet,
+			path:    "/v1/hello",
+			headers: map[string]string{"Origin": "https://evil.com"},
+			want:    map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Credentials": "", "Vary": "Origin"},
+		},
+		{
+			name:   "预检请求",
+			method: http.MethodOptions,
+			path:   "/v1/hello",
+			headers: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "authorization, Content-Type",
+			},
+			wantStatus: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "https://example.com",
+				"Access-Control-Allow-Methods": "GET, PUT",
+				"Access-Control-Allow-Headers": "Authorization, content-type",
+				"Access-Control-Max-Age":       "43200",
+			},
+		},
+		{
+			name:   "预检请求的方法不被允许",
+			method: http.MethodOptions,
+			path:   "/v1/hello",
+			headers: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			wantStatus: http.StatusForbidden,
+			want:       map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "预检请求的请求头不被允许",
+			method: http.MethodOptions,
+			path:   "/v1/hello",
+			headers: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:    "没有 Access-Control-Request-Method 的 OPTIONS 不是预检请求",
+			method:  http.MethodOptions,
+			path:    "/v1/hello",
+			headers: map[string]string{"Origin": "https://example.com"},
+			want:    map[string]string{"Access-Control-Allow-Methods": ""},
+		},
+		{
+			name:    "路由策略允许所有来源时不携带凭证",
+			method:  http.MethodGet,
+			path:    "/public/docs",
+			headers: map[string]string{"Origin": "https://evil.com"},
+			want:    map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Credentials": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextCalled := false
+			handler := cors.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+			}))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if nextCalled != (tt.wantStatus == 0) {
+				t.Errorf("调用下一个处理器 = %v", nextCalled)
+			}
+			if tt.wantStatus != 0 && rec.Code != tt.wantStatus {
+				t.Errorf("状态码 = %d，期望 %d", rec.Code, tt.wantStatus)
+			}
+			for k, want := range tt.want {
+				if got := rec.Header().Get(k); got != want {
+					t.Errorf("%s = %q，期望 %q", k, got, want)
+				}
+			}
+		})
+	}
+}
@@ -2,59 +2,37 @@ package http
 
 import (
 	"net/http"
-	"slices"
-	"strconv"
 	"time"
 
 	"github.com/costa92/go-protoc/pkg/log"
-	"github.com/costa92/go-protoc/pkg/metrics"
 	"github.com/gorilla/mux"
-	"go.opentelemetry.io/otel/trace"
 )
 
 const UnknownTraceID = "unknown"
 
 // LoggingMiddleware 创建一个 HTTP 日志中间件
+// 中间件为请求注入携带 method、path 和 client_ip 的日志记录器，处理器通过 log.FromContext 获取，
+// 记录的日志会自动附加 trace_id、span_id、request_id 和 subject
 func LoggingMiddleware(skipPaths []string) mux.MiddlewareFunc {
-	log.Infow("LoggingMiddleware", "skipPaths", skipPaths)
+	logger().Infow("LoggingMiddleware", "skipPaths", skipPaths)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			rw := &responseWriter{w, http.StatusOK}
 
-			log.Infow("LoggingMiddleware", "r.URL.Path", r.URL.Path)
-			traceID := UnknownTraceID
-			if !slices.Contains(skipPaths, r.URL.Path) {
-				spanCtx := trace.SpanContextFromContext(r.Context())
-				if spanCtx.IsValid() {
-					traceID = spanCtx.TraceID().String()
-				}
-			}
+			logger().Infow("LoggingMiddleware", "r.URL.Path", r.URL.Path)
+			ctx := log.WithContext(r.Context(), "method", r.Method, "path", r.URL.Path, "client_ip", clientIP(r))
+			r = r.WithContext(ctx)
 
 			next.ServeHTTP(rw, r)
 
 			duration := time.Since(start)
 
-			// 记录Prometheus指标
-			metrics.HTTPRequestsTotal.WithLabelValues(
-				r.Method,
-				r.URL.Path,
-				strconv.Itoa(rw.status),
-			).Inc()
-			metrics.HTTPRequestDuration.WithLabelValues(
-				r.Method,
-				r.URL.Path,
-			).Observe(duration.Seconds())
-
 			// 记录请求信息
-			log.L().WithValues(
-				"method", r.Method,
-				"path", r.URL.Path,
+			log.FromContext(ctx).WithValues(
 				"status", rw.status,
-				"remote_addr", r.RemoteAddr,
 				"user_agent", r.UserAgent(),
 				"duration", duration,
-				"trace_id", traceID,
 			).Infof("http request")
 		})
 	}
@@ -78,16 +56,9 @@ func RecoveryMiddleware() mux.MiddlewareFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					spanCtx := trace.SpanContextFromContext(r.Context())
-					traceID := UnknownTraceID
-					if spanCtx.IsValid() {
-						traceID = spanCtx.TraceID().String()
-					}
-
-					log.L().WithValues(
+					log.FromContext(r.Context()).WithValues(
 						"error", err,
 						"path", r.URL.Path,
-						"trace_id", traceID,
 					).Errorf("http panic recovered")
 
 					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"github.com/gorilla/mux"
+)
+
+// MetricsMiddleware 创建一个 HTTP 指标中间件
+// 指标以匹配到的路由模板（而不是原始路径）作为 path 标签，避免路径参数导致的基数爆炸；
+// gRPC-Gateway 路由额外记录 gRPC 全方法名，未匹配任何路由的请求归入 UnmatchedRoute。
+func MetricsMiddleware(skipPaths []string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shouldSkipPath(skipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := &responseWriter{w, http.StatusOK}
+			r, _ = withRouteInfo(r)
+
+			next.ServeHTTP(rw, r)
+
+			template, rpcMethod := RouteTemplate(r)
+			metrics.HTTPRequestsTotal.WithLabelValues(
+				r.Method,
+				template,
+				strconv.Itoa(rw.status),
+				rpcMethod,
+			).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(
+				r.Method,
+				template,
+				rpcMethod,
+			).Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -1,43 +1,102 @@
 package http
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"net/http"
-	"sync"
 	"time"
 
+	"github.com/costa92/go-protoc/pkg/auth"
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/ratelimit"
+	"github.com/costa92/go-protoc/pkg/response"
 	"github.com/gorilla/mux"
-	"golang.org/x/time/rate"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 )
 
-// rateLimiter 限流器
-type rateLimiter struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+// limiterKey 是存放交给 GatewayRateLimitMiddleware 限流的 Limiter 的上下文键
+type limiterKey struct{}
+
+// LimiterMiddleware 创建一个使用 limiter 的限流中间件
+// 自定义路由按路由模板匹配策略；gRPC-Gateway 在内层处理器中才完成路由匹配，
+// 因此网关路由把 limiter 放入上下文，由 GatewayRateLimitMiddleware 按路由模板和 gRPC 方法匹配策略
+// 响应中带有 X-RateLimit-Limit、X-RateLimit-Remaining 和 X-RateLimit-Reset 头，被拒绝的请求返回 errors.ErrRateLimit 和 Retry-After 头
+func LimiterMiddleware(limiter *ratelimit.Limiter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Enabled() || limiter.Skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			route := mux.CurrentRoute(r)
+			if route != nil && route.GetName() == GatewayRouteName {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), limiterKey{}, limiter)))
+				return
+			}
+
+			var template string
+			if route != nil {
+				template, _ = route.GetPathTemplate()
+			}
+			if !allowRequest(w, r, limiter, template, "") {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-// rateLimiters 存储所有限流器
-var (
-	rateLimiters = make(map[string]*rateLimiter)
-	mu           sync.Mutex
-)
+// GatewayRateLimitMiddleware 返回 gRPC-Gateway 的限流中间件，使用 LimiterMiddleware 放入上下文的 limiter
+// 策略按路由模板（如 /v1/hello/{name}）或对应的 gRPC 方法匹配，与 gRPC 拦截器共用同一个 limiter 时计数也是共享的
+func GatewayRateLimitMiddleware() runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			limiter, _ := r.Context().Value(limiterKey{}).(*ratelimit.Limiter)
+			pattern, ok := runtime.HTTPPattern(r.Context())
+			if limiter == nil || !ok {
+				next(w, r, pathParams)
+				return
+			}
 
-// cleanup 清理过期的限流器
-func cleanup(cleanupInterval time.Duration) {
-	ticker := time.NewTicker(cleanupInterval)
-	go func() {
-		for range ticker.C {
-			mu.Lock()
-			for ip, rl := range rateLimiters {
-				if time.Since(rl.lastSeen) > cleanupInterval {
-					delete(rateLimiters, ip)
-				}
+			var method string
+			if route := lookupGatewayRoute(r.Method, pattern.String()); route != nil {
+				method = route.rpcMethod
+			}
+			if !allowRequest(w, r, limiter, bareTemplate(pattern.String()), method) {
+				return
 			}
-			mu.Unlock()
+			next(w, r, pathParams)
 		}
-	}()
+	}
 }
 
-// RateLimitMiddleware 创建一个限流中间件，接受明确的配置参数而不是依赖全局配置
+// allowRequest 检查请求是否被允许并写入限流响应头，被拒绝时写入 429 响应并返回 false
+func allowRequest(w http.ResponseWriter, r *http.Request, limiter *ratelimit.Limiter, route, method string) bool {
+	res := limiter.Allow(r.Context(), route, method, ratelimit.Request{
+		ClientIP: clientIP(r),
+		Subject:  log.SubjectFromContext(r.Context()),
+		Header:   r.Header.Values,
+		Policy:   boundRateLimitPolicy(r.Context()),
+	})
+	for k, v := range res.Headers() {
+		w.Header().Set(k, v)
+	}
+	if res.Allowed {
+		return true
+	}
+
+	ctxLogger(r.Context()).Debugw("请求被限流", "policy", res.Policy)
+	response.WriteAPIError(w, errors.ErrRateLimit.
+		WithMetadata("policy", res.Policy).
+		WithMetadata("retry_after", w.Header().Get(ratelimit.HeaderRetryAfter)))
+	return false
+}
+
+// RateLimitMiddleware 创建一个按客户端 IP 限流的中间件，接受明确的配置参数而不是依赖全局配置
+// limit 是每秒允许的请求数，可以是小数，如 0.5 表示每 2 秒 1 个请求，必须大于 0；需要按路由或其他键限流时使用 LimiterMiddleware
 func RateLimitMiddleware(
 	enable bool,
 	limit float64,
@@ -50,44 +109,31 @@ func RateLimitMiddleware(
 		}
 	}
 
-	// 启动清理过期限流器的协程
-	cleanup(time.Hour)
-
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// 检查是否在白名单中
-			for _, path := range skipPaths {
-				if r.URL.Path == path {
-					next.ServeHTTP(w, r)
-					return
-				}
-			}
-
-			// 获取客户端 IP
-			ip := r.RemoteAddr
-			if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
-				ip = forwardedFor
-			}
-
-			// 获取或创建限流器
-			mu.Lock()
-			limiter, exists := rateLimiters[ip]
-			if !exists {
-				limiter = &rateLimiter{
-					limiter: rate.NewLimiter(rate.Limit(limit), burst),
-				}
-				rateLimiters[ip] = limiter
-			}
-			limiter.lastSeen = time.Now()
-			mu.Unlock()
-
-			// 检查是否允许请求
-			if !limiter.limiter.Allow() {
-				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
-				return
-			}
+	if !(limit > 0) {
+		panic(fmt.Sprintf("限流速率必须大于 0，实际为 %v", limit))
+	}
+	// 策略的 limit 是整数，小数速率换算为每个窗口 1 个请求
+	policy := ratelimit.Policy{Key: ratelimit.KeyIP, Limit: int(limit), Burst: burst, Window: time.Second}
+	if limit != math.Trunc(limit) {
+		policy.Limit = 1
+		policy.Window = time.Duration(float64(time.Second) / limit)
+	}
+	limiter, err := ratelimit.NewLimiter(ratelimit.Options{
+		Enable:    enable,
+		Default:   policy,
+		SkipPaths: skipPaths,
+	}, nil)
+	if err != nil {
+		// 内置的 ip 键总是可用，速率已经校验过，不会走到这里
+		panic(err)
+	}
+	return LimiterMiddleware(limiter)
+}
 
-			next.ServeHTTP(w, r)
-		})
+// boundRateLimitPolicy 返回调用方凭证绑定的限流策略，没有调用方时返回空字符串
+func boundRateLimitPolicy(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.RateLimitPolicy
 	}
+	return ""
 }
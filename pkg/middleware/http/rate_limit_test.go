@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	helloworldv2 "github.com/costa92/go-protoc/pkg/api/helloworld/v2"
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/ratelimit"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func TestLimiterMiddleware(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.Options{
+		Enable:  true,
+		Default: ratelimit.Policy{Limit: 100},
+		Policies: []ratelimit.Policy{
+			{Name: "users", Routes: []string{"/users/{id}"}, Limit: 1},
+			{Name: "hello", Routes: []string{"/v2/hello/{name}"}, Limit: 1},
+			{Name: "say-hello", Methods: []string{"/helloworld.v2.Greeter/SayHello"}, Limit: 1},
+		},
+		SkipPaths: []string{"/healthz"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("创建限流器失败: %v", err)
+	}
+
+	gwmux := runtime.NewServeMux(append(GatewayRouteOptions(), runtime.WithMiddlewares(GatewayRateLimitMiddleware()))...)
+	response.Setup(gwmux)
+	if err := helloworldv2.RegisterGreeterHandlerServer(context.Background(), gwmux, &greeterServer{}); err != nil {
+		t.Fatalf("注册 gRPC-Gateway 处理器失败: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.Use(LimiterMiddleware(limiter))
+	ok := func(w http.ResponseWriter, r *http.Request) { response.WriteSuccess(w, nil, "") }
+	router.HandleFunc("/users/{id}", ok)
+	router.HandleFunc("/healthz", ok)
+	router.PathPrefix("/").Handler(gwmux).Name(GatewayRouteName)
+
+	// 定义测试用例，按顺序执行，共享同一个限流器
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		policy string
+	}{
+		{name: "自定义路由", method: http.MethodGet, path: "/users/1", status: http.StatusOK, policy: "users"},
+		{name: "同一路由模板共享计数", method: http.MethodGet, path: "/users/2", status: http.StatusTooManyRequests, policy: "users"},
+		{name: "跳过的路径", method: http.MethodGet, path: "/healthz", status: http.StatusOK},
+		{name: "网关路由模板", method: http.MethodGet, path: "/v2/hello/bob", status: http.StatusOK, policy: "hello"},
+		{name: "网关路由模板超出限制", method: http.MethodGet, path: "/v2/hello/alice", status: http.StatusTooManyRequests, policy: "hello"},
+		{name: "网关路由按 gRPC 方法匹配", method: http.MethodPost, path: "/v2/hello", status: http.StatusOK, policy: "say-hello"},
+		{name: "网关路由按 gRPC 方法匹配超出限制", method: http.MethodPost, path: "/v2/hello", status: http.StatusTooManyRequests, policy: "say-hello"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"name":"bob"}`))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("状态码不匹配: 期望=%d, 实际=%d, 响应=%s", tc.status, rec.Code, rec.Body.String())
+			}
+			if tc.policy == "" {
+				if rec.Header().Get(ratelimit.HeaderLimit) != "" {
+					t.Errorf("跳过的路径不应该返回限流头")
+				}
+				return
+			}
+			if rec.Header().Get(ratelimit.HeaderLimit) != "1" {
+				t.Errorf("%s = %q，期望 1", ratelimit.HeaderLimit, rec.Header().Get(ratelimit.HeaderLimit))
+			}
+			if tc.status != http.StatusTooManyRequests {
+				return
+			}
+
+			if rec.Header().Get(ratelimit.HeaderRetryAfter) == "" {
+				t.Errorf("被拒绝的请求缺少 %s 头", ratelimit.HeaderRetryAfter)
+			}
+			var resp response.Wrapper
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if resp.ErrorCode != errors.ErrRateLimit.Code || resp.Reason != errors.ErrRateLimit.Reason || resp.Metadata["policy"] != tc.policy {
+				t.Errorf("错误响应 = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	// 每秒 0.5 个请求，即每 2 秒 1 个请求，小数速率不能被截断为 0
+	router := mux.NewRouter()
+	router.Use(RateLimitMiddleware(true, 0.5, 0, nil))
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { response.WriteSuccess(w, nil, "") })
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != want {
+			t.Fatalf("第 %d 个请求状态码 = %d，期望 %d", i+1, rec.Code, want)
+		}
+		if i == 1 && rec.Header().Get(ratelimit.HeaderRetryAfter) != "2" {
+			t.Errorf("%s = %q，期望 2", ratelimit.HeaderRetryAfter, rec.Header().Get(ratelimit.HeaderRetryAfter))
+		}
+	}
+}
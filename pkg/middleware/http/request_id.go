@@ -0,0 +1,46 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/requestid"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDMiddleware 创建一个请求 ID 中间件
+// 使用客户端通过 X-Request-ID 传入的合法请求 ID，没有或不合法时生成一个新的，
+// 请求 ID 会写回响应头、放入上下文供日志和错误响应使用，并记录到当前 span 中
+// 应放在追踪中间件之后、日志中间件之前
+func RequestIDMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := requestid.Ensure(r.Header.Get(requestid.Header))
+
+			// 规范化请求头，后续处理器和转发逻辑读取到的都是实际使用的请求 ID
+			r.Header.Set(requestid.Header, id)
+			w.Header().Set(requestid.Header, id)
+
+			ctx := log.ContextWithRequestID(r.Context(), id)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String(requestid.Attribute, id))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GatewayRequestIDMetadata 返回 gRPC-Gateway ServeMux 的选项，
+// 将请求 ID 作为 x-request-id 元数据转发给 gRPC 服务
+func GatewayRequestIDMetadata() runtime.ServeMuxOption {
+	return runtime.WithMetadata(func(_ context.Context, r *http.Request) metadata.MD {
+		if id := log.RequestIDFromContext(r.Context()); id != "" {
+			return metadata.Pairs(requestid.MetadataKey, id)
+		}
+		return nil
+	})
+}
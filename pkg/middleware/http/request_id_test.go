@@ -0,0 +1,63 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/requestid"
+	"github.com/costa92/go-protoc/pkg/response"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "使用客户端传入的请求 ID", incoming: "req-123", keep: true},
+		{name: "没有请求 ID 时生成", incoming: ""},
+		{name: "不合法的请求 ID 被替换", incoming: "bad id\r\n", keep: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID string
+			handler := RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = log.RequestIDFromContext(r.Context())
+				response.WriteBadRequest(w, "请求失败", nil)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.Header, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(requestid.Header)
+			if !requestid.Valid(got) {
+				t.Fatalf("响应头中的请求 ID %q 不合法", got)
+			}
+			if tt.keep && got != tt.incoming {
+				t.Errorf("响应头中的请求 ID = %q，期望 %q", got, tt.incoming)
+			}
+			if !tt.keep && got == tt.incoming {
+				t.Errorf("请求 ID %q 应该被替换", tt.incoming)
+			}
+			if ctxID != got {
+				t.Errorf("上下文中的请求 ID = %q，期望 %q", ctxID, got)
+			}
+
+			var body response.Wrapper
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if body.RequestID != got {
+				t.Errorf("错误响应中的 request_id = %q，期望 %q", body.RequestID, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// UnmatchedRoute 是没有匹配到任何路由的请求使用的路由标签
+	UnmatchedRoute = "unmatched"
+	// GatewayRouteName 是 gRPC-Gateway 默认处理器在 mux.Router 中的路由名称
+	GatewayRouteName = "grpc-gateway"
+)
+
+// routeInfo 记录请求匹配到的路由信息
+// gRPC-Gateway 在内层处理器中才完成路由匹配，因此外层中间件在上下文中放入该结构，由内层填充
+type routeInfo struct {
+	mu        sync.Mutex
+	template  string
+	rpcMethod string
+}
+
+// routeInfoKey 是存放 routeInfo 的上下文键
+type routeInfoKey struct{}
+
+// withRouteInfo 确保请求上下文中存在 routeInfo，并返回新的请求和 routeInfo
+func withRouteInfo(r *http.Request) (*http.Request, *routeInfo) {
+	if info := routeInfoFromContext(r.Context()); info != nil {
+		return r, info
+	}
+	info := &routeInfo{}
+	return r.WithContext(context.WithValue(r.Context(), routeInfoKey{}, info)), info
+}
+
+// routeInfoFromContext 从上下文中获取 routeInfo
+func routeInfoFromContext(ctx context.Context) *routeInfo {
+	info, _ := ctx.Value(routeInfoKey{}).(*routeInfo)
+	return info
+}
+
+// set 更新路由信息，空值不会覆盖已有的值
+func (i *routeInfo) set(template, rpcMethod string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if template != "" {
+		i.template = template
+	}
+	if rpcMethod != "" {
+		i.rpcMethod = rpcMethod
+	}
+}
+
+// get 返回路由模板和 gRPC 方法
+func (i *routeInfo) get() (template, rpcMethod string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.template, i.rpcMethod
+}
+
+// GatewayRouteOptions 返回 gRPC-Gateway ServeMux 的选项，
+// 用于把匹配到的路由模板（如 /v1/hello/{name}）和 gRPC 全方法名记录到外层中间件的请求上下文中
+func GatewayRouteOptions() []runtime.ServeMuxOption {
+	return []runtime.ServeMuxOption{
+		runtime.WithMiddlewares(func(next runtime.HandlerFunc) runtime.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+				if info := routeInfoFromContext(r.Context()); info != nil {
+					if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+						info.set(pattern.String(), "")
+					}
+				}
+				next(w, r, pathParams)
+			}
+		}),
+		// 元数据注解器在上下文注解完成后调用，此时可以拿到原始的路径模板和 gRPC 方法名
+		runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+			if info := routeInfoFromContext(r.Context()); info != nil {
+				template, _ := runtime.HTTPPathPattern(ctx)
+				method, _ := runtime.RPCMethod(ctx)
+				info.set(template, method)
+			}
+			return nil
+		}),
+	}
+}
+
+// RouteTemplate 返回请求匹配到的路由模板和对应的 gRPC 方法（非 gRPC-Gateway 路由为空）
+// 未匹配到任何路由时返回 UnmatchedRoute
+func RouteTemplate(r *http.Request) (template, rpcMethod string) {
+	if info := routeInfoFromContext(r.Context()); info != nil {
+		if template, rpcMethod = info.get(); template != "" {
+			return template, rpcMethod
+		}
+	}
+
+	route := mux.CurrentRoute(r)
+	if route == nil || route.GetName() == GatewayRouteName {
+		return UnmatchedRoute, ""
+	}
+	if template, err := route.GetPathTemplate(); err == nil {
+		return template, ""
+	}
+	if template, err := route.GetPathRegexp(); err == nil {
+		return template, ""
+	}
+	return UnmatchedRoute, ""
+}
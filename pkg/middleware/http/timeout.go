@@ -1,54 +1,245 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"github.com/costa92/go-protoc/pkg/response"
 	"github.com/gorilla/mux"
 )
 
-// TimeoutMiddleware 创建一个超时中间件，接受明确的配置参数而不是依赖全局配置
-func TimeoutMiddleware(timeout time.Duration) mux.MiddlewareFunc {
-	return TimeoutMiddlewareWithSkipPaths(timeout, nil)
+// TimeoutRoute 是按路径前缀覆盖的超时时间
+type TimeoutRoute struct {
+	PathPrefix string
+	Timeout    time.Duration
 }
 
-// TimeoutMiddlewareWithSkipPaths 创建一个带跳过路径的超时中间件
-func TimeoutMiddlewareWithSkipPaths(timeout time.Duration, skipPaths []string) mux.MiddlewareFunc {
-	log.Infow("TimeoutMiddlewareWithSkipPaths", "skipPaths", skipPaths)
+// TimeoutOptions 定义超时中间件的配置
+type TimeoutOptions struct {
+	// Timeout 是默认的超时时间，不大于 0 时不限制
+	Timeout time.Duration
+	// Routes 是按路径前缀覆盖的超时时间，多个前缀匹配时使用最长的前缀
+	Routes []TimeoutRoute
+	// StreamingPaths 是流式响应的路径前缀，这些请求不受超时限制，响应也不会被缓冲
+	// 除此之外，SSE（Accept: text/event-stream）、协议升级（如 WebSocket）和 gRPC 请求总是不受超时限制
+	StreamingPaths []string
+	// SkipPaths 是不受超时限制的路径前缀，通常是健康检查和指标接口
+	SkipPaths []string
+}
+
+// timeoutState 是预处理后的超时配置
+type timeoutState struct {
+	timeout time.Duration
+	// routes 按前缀长度从长到短排序
+	routes         []TimeoutRoute
+	streamingPaths []string
+	skipPaths      []string
+}
+
+// newTimeoutState 预处理超时配置
+func newTimeoutState(opts TimeoutOptions) *timeoutState {
+	s := &timeoutState{
+		timeout:        opts.Timeout,
+		routes:         slices.Clone(opts.Routes),
+		streamingPaths: opts.StreamingPaths,
+		skipPaths:      opts.SkipPaths,
+	}
+	slices.SortStableFunc(s.routes, func(a, b TimeoutRoute) int { return len(b.PathPrefix) - len(a.PathPrefix) })
+	return s
+}
+
+// timeoutFor 返回请求的超时时间，不受超时限制的请求返回 0
+func (s *timeoutState) timeoutFor(r *http.Request) time.Duration {
+	if shouldSkipPath(s.skipPaths, r.URL.Path) || shouldSkipPath(s.streamingPaths, r.URL.Path) || isStreamingRequest(r) {
+		return 0
+	}
+	for _, route := range s.routes {
+		if strings.HasPrefix(r.URL.Path, route.PathPrefix) {
+			return route.Timeout
+		}
+	}
+	return s.timeout
+}
+
+// isStreamingRequest 判断请求是否是 SSE、协议升级或 gRPC 请求，这些请求的响应是流式的，不能被缓冲
+func isStreamingRequest(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" {
+		return true
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		return true
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(accept, "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
+// Timeout 是支持在运行时更新配置的超时中间件
+type Timeout struct {
+	state atomic.Pointer[timeoutState]
+}
+
+// NewTimeout 创建一个新的 Timeout 实例
+func NewTimeout(opts TimeoutOptions) *Timeout {
+	t := &Timeout{}
+	t.state.Store(newTimeoutState(opts))
+	return t
+}
+
+// Update 更新超时配置，对之后的请求生效
+func (t *Timeout) Update(opts TimeoutOptions) {
+	t.state.Store(newTimeoutState(opts))
+}
+
+// Middleware 返回超时中间件
+// 处理器在单独的 goroutine 中运行，响应先写入缓冲区，处理完成后再写给客户端；
+// 超时后丢弃缓冲的响应，返回 errors.ErrTimeout，之后处理器的写入返回 http.ErrHandlerTimeout。
+// 处理器通过请求上下文得知超时，处理器中的 panic 会在当前 goroutine 中重新抛出，交给外层的 RecoveryMiddleware
+func (t *Timeout) Middleware() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// 检查是否在白名单中
-			if shouldSkipPath(skipPaths, r.URL.Path) {
+			timeout := t.state.Load().timeoutFor(r)
+			if timeout <= 0 {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			ctx := r.Context()
-			ctx, cancel := context.WithTimeout(ctx, timeout)
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
-
 			r = r.WithContext(ctx)
-			done := make(chan struct{})
 
+			// 缓冲的响应头以外层中间件已经设置的响应头为初始值，内层可以读取（如请求 ID）
+			tw := &timeoutWriter{w: w, header: w.Header().Clone()}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
 			go func() {
-				next.ServeHTTP(w, r)
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
 				close(done)
 			}()
 
 			select {
+			case p := <-panicChan:
+				panic(p)
 			case <-done:
-				return
+				tw.flush()
 			case <-ctx.Done():
-				w.WriteHeader(http.StatusGatewayTimeout)
-				return
+				tw.timeout()
+				if ctx.Err() != context.DeadlineExceeded {
+					// 客户端断开连接，不需要写响应
+					return
+				}
+				template, rpcMethod := RouteTemplate(r)
+				metrics.HTTPRequestTimeouts.WithLabelValues(r.Method, template, rpcMethod).Inc()
+				ctxLogger(r.Context()).Warnw("请求处理超时", "timeout", timeout.String())
+				response.WriteAPIError(w, errors.ErrTimeout.WithMetadata("timeout", timeout.String()))
 			}
 		})
 	}
 }
 
+// timeoutWriter 缓冲处理器写入的响应，超时后拒绝之后的写入
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header 实现 http.ResponseWriter 接口
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write 实现 http.ResponseWriter 接口
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+// WriteHeader 实现 http.ResponseWriter 接口
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("invalid WriteHeader code %v", code))
+	}
+	tw.wroteHeader = true
+	tw.status = code
+}
+
+// timeout 标记已经超时，丢弃缓冲的响应
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+	tw.buf = bytes.Buffer{}
+}
+
+// flush 把缓冲的响应写给客户端，只在处理器返回后调用
+func (tw *timeoutWriter) flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	dst := tw.w.Header()
+	for k := range dst {
+		if _, ok := tw.header[k]; !ok {
+			delete(dst, k)
+		}
+	}
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.status)
+	tw.w.Write(tw.buf.Bytes())
+}
+
+// TimeoutMiddleware 创建一个超时中间件，接受明确的配置参数而不是依赖全局配置
+func TimeoutMiddleware(timeout time.Duration) mux.MiddlewareFunc {
+	return TimeoutMiddlewareWithSkipPaths(timeout, nil)
+}
+
+// TimeoutMiddlewareWithSkipPaths 创建一个带跳过路径的超时中间件
+func TimeoutMiddlewareWithSkipPaths(timeout time.Duration, skipPaths []string) mux.MiddlewareFunc {
+	return NewTimeout(TimeoutOptions{Timeout: timeout, SkipPaths: skipPaths}).Middleware()
+}
+
 // shouldSkipPath 检查是否应该跳过该路径
 func shouldSkipPath(skipPaths []string, path string) bool {
 	if skipPaths == nil {
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/metrics"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	timeout := NewTimeout(TimeoutOptions{
+		Timeout:        20 * time.Millisecond,
+		Routes:         []TimeoutRoute{{PathPrefix: "/slow/", Timeout: time.Second}},
+		StreamingPaths: []string{"/events"},
+		SkipPaths:      []string{"/healthz"},
+	})
+
+	// 处理器在超时后继续写入，写入的结果通过 writeErr 返回
+	// 处理器与中间件同时得知超时，等待一段时间确保中间件已经处理超时
+	writeErr := make(chan error, 1)
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("X-Handler", "slow")
+		w.WriteHeader(http.StatusCreated)
+		_, err := w.Write([]byte("late"))
+		select {
+		case writeErr <- err:
+		default:
+		}
+	}
+	sleep := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("X-Handler", "sleep")
+		w.Write([]byte("ok"))
+	}
+
+	router := mux.NewRouter()
+	router.Use(MetricsMiddleware(nil), timeout.Middleware())
+	router.HandleFunc("/users/{id}", slow)
+	router.HandleFunc("/slow/report", sleep)
+	router.HandleFunc("/events", sleep)
+	router.HandleFunc("/healthz", sleep)
+	router.HandleFunc("/stream", sleep)
+	router.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	// 定义测试用例
+	testCases := []struct {
+		name   string
+		path   string
+		header http.Header
+		status int
+		body   string
+	}{
+		{name: "按路由覆盖超时时间", path: "/slow/report", status: http.StatusOK, body: "ok"},
+		{name: "流式路径", path: "/events", status: http.StatusOK, body: "ok"},
+		{name: "跳过的路径", path: "/healthz", status: http.StatusOK, body: "ok"},
+		{name: "SSE请求", path: "/stream", header: http.Header{"Accept": {"text/event-stream"}}, status: http.StatusOK, body: "ok"},
+		{name: "WebSocket请求", path: "/stream", header: http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}}, status: http.StatusOK, body: "ok"},
+		{name: "超时", path: "/stream", status: http.StatusGatewayTimeout},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("状态码不匹配: 期望=%d, 实际=%d, 响应=%s", tc.status, rec.Code, rec.Body.String())
+			}
+			if tc.body != "" && (rec.Body.String() != tc.body || rec.Header().Get("X-Handler") != "sleep") {
+				t.Errorf("响应 = %q，响应头 = %v", rec.Body.String(), rec.Header())
+			}
+		})
+	}
+
+	t.Run("超时后丢弃处理器的响应", func(t *testing.T) {
+		before := testutil.ToFloat64(metrics.HTTPRequestTimeouts.WithLabelValues(http.MethodGet, "/users/{id}", ""))
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+		if rec.Code != http.StatusGatewayTimeout || rec.Header().Get("X-Handler") != "" {
+			t.Fatalf("状态码 = %d，响应头 = %v", rec.Code, rec.Header())
+		}
+		var resp response.Wrapper
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v, 响应=%s", err, rec.Body.String())
+		}
+		if resp.ErrorCode != errors.ErrTimeout.Code || resp.Reason != errors.ErrTimeout.Reason || resp.Metadata["timeout"] != "20ms" {
+			t.Errorf("错误响应 = %+v", resp)
+		}
+		if err := <-writeErr; err != http.ErrHandlerTimeout {
+			t.Errorf("超时后写入返回 %v，期望 http.ErrHandlerTimeout", err)
+		}
+
+		after := testutil.ToFloat64(metrics.HTTPRequestTimeouts.WithLabelValues(http.MethodGet, "/users/{id}", ""))
+		if after != before+1 {
+			t.Errorf("超时计数 = %v，期望 %v", after, before+1)
+		}
+	})
+
+	t.Run("处理器中的panic", func(t *testing.T) {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recover() = %v，期望 boom", p)
+			}
+		}()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+
+	t.Run("更新配置", func(t *testing.T) {
+		timeout.Update(TimeoutOptions{Timeout: time.Second})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("更新后状态码 = %d，期望 200", rec.Code)
+		}
+	})
+}
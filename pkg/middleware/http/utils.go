@@ -1,6 +1,24 @@
 package http
 
-import "strings"
+import (
+	"context"
+	"strings"
+
+	"github.com/costa92/go-protoc/pkg/log"
+)
+
+// loggerName 是本包日志记录器的名称，可以通过 log.SetLoggerLevel 单独调整级别
+const loggerName = "middleware.http"
+
+// logger 返回本包的命名日志记录器
+func logger() log.Logger {
+	return log.Named(loggerName)
+}
+
+// ctxLogger 返回附加了请求上下文字段的本包命名日志记录器
+func ctxLogger(ctx context.Context) log.Logger {
+	return log.FromContextNamed(ctx, loggerName)
+}
 
 // joinStrings 将字符串切片用逗号连接
 func joinStrings(strs []string) string {
@@ -1,37 +1,317 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
 	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
 
-	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/costa92/go-protoc/pkg/validation"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 var validate = validator.New()
 
+func init() {
+	// 校验错误中使用 JSON 字段名，与请求体保持一致
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+}
+
+// NewValidator 创建一个新的验证器实例
+func NewValidator() *validator.Validate {
+	return validate
+}
+
+// requestBodies 存储自定义路由注册的请求体结构，键为 "方法 路由模板"
+var (
+	requestBodies   = make(map[string]reflect.Type)
+	requestBodiesMu sync.RWMutex
+)
+
+// requestBodyKey 是存放已校验请求体的上下文键
+type requestBodyKey struct{}
+
+// RegisterRequestBody 为通过 AddRoute 添加的自定义路由注册请求体结构
+// ValidationMiddleware 会将 JSON 请求体解码到 body 类型的新实例中并按 validate 标签校验，
+// 校验通过后可以在处理器中通过 RequestBody 获取解码后的值
+// method 为空时匹配所有方法，path 是注册路由时使用的路由模板
+func RegisterRequestBody(method, path string, body any) {
+	t := reflect.TypeOf(body)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	requestBodiesMu.Lock()
+	defer requestBodiesMu.Unlock()
+	requestBodies[strings.ToUpper(method)+" "+path] = t
+}
+
+// lookupRequestBody 查找路由注册的请求体类型
+func lookupRequestBody(method, path string) reflect.Type {
+	requestBodiesMu.RLock()
+	defer requestBodiesMu.RUnlock()
+	if t, ok := requestBodies[method+" "+path]; ok {
+		return t
+	}
+	return requestBodies[" "+path]
+}
+
+// RequestBody 返回 ValidationMiddleware 解码并校验过的请求体，类型为注册结构的指针
+func RequestBody(r *http.Request) any {
+	return r.Context().Value(requestBodyKey{})
+}
+
 // ValidationMiddleware 创建一个验证中间件
+// 对注册了请求体结构的自定义路由，解码 JSON 请求体并校验，校验失败时返回包含所有字段错误的 400 响应
+// gRPC-Gateway 路由的校验由 GatewayValidationMiddleware 完成
 func ValidationMiddleware() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if err := r.ParseForm(); err != nil {
-				log.L().Errorf("Failed to parse form: %v", err)
-				http.Error(w, "Bad Request", http.StatusBadRequest)
+			route := mux.CurrentRoute(r)
+			if route == nil || route.GetName() == GatewayRouteName {
+				next.ServeHTTP(w, r)
 				return
 			}
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			bodyType := lookupRequestBody(r.Method, template)
+			if bodyType == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			data, err := readBody(r)
+			if err != nil {
+				response.WriteBadRequest(w, "读取请求体失败", err)
+				return
+			}
+
+			body := reflect.New(bodyType).Interface()
+			if len(bytes.TrimSpace(data)) > 0 {
+				if err := json.Unmarshal(data, body); err != nil {
+					ctxLogger(r.Context()).Debugw("解析请求体失败", "error", err)
+					response.WriteBadRequest(w, "请求体格式错误", err)
+					return
+				}
+			}
 
-			if err := validate.Struct(r); err != nil {
-				log.L().Errorf("Validation failed: %v", err)
-				http.Error(w, err.Error(), http.StatusBadRequest)
+			if err := validate.Struct(body); err != nil {
+				ctxLogger(r.Context()).Debugw("请求参数校验失败", "error", err)
+				response.WriteValidationError(w, validation.Violations(err))
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestBodyKey{}, body)))
 		})
 	}
 }
 
-// NewValidator 创建一个新的验证器实例
-func NewValidator() *validator.Validate {
-	return validate
+// GatewayValidationMiddleware 返回 gRPC-Gateway 的校验中间件
+// 按照匹配路由的 google.api.http 规则，将请求体、路径参数和查询参数解码到目标 proto 消息中，
+// 调用 protoc-gen-validate 生成的 ValidateAll/Validate 方法，校验失败时返回包含所有字段错误的 400 响应
+func GatewayValidationMiddleware() runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			pattern, ok := runtime.HTTPPattern(r.Context())
+			if !ok {
+				next(w, r, pathParams)
+				return
+			}
+			route := lookupGatewayRoute(r.Method, pattern.String())
+			if route == nil {
+				next(w, r, pathParams)
+				return
+			}
+
+			msg, err := route.decode(r, pathParams)
+			if err != nil {
+				ctxLogger(r.Context()).Debugw("解析请求失败", "error", err)
+				response.WriteBadRequest(w, "请求格式错误", err)
+				return
+			}
+
+			if err := validation.Validate(msg); err != nil {
+				ctxLogger(r.Context()).Debugw("请求参数校验失败", "error", err)
+				response.WriteValidationError(w, validation.ProtoViolations(msg, err))
+				return
+			}
+
+			next(w, r, pathParams)
+		}
+	}
+}
+
+// gatewayRoute 描述一个 gRPC-Gateway 路由对应的请求消息
+type gatewayRoute struct {
+	msgType protoreflect.MessageType
+	// body 是 google.api.http 规则中的 body 字段: 空表示没有请求体，* 表示整个消息
+	body string
+	// rpcMethod 是对应的 gRPC 全方法名，如 /helloworld.v1.Greeter/SayHello
+	rpcMethod string
+}
+
+// decode 按照 gRPC-Gateway 的规则构造请求消息，请求体被读取后会重新放回请求中
+func (g *gatewayRoute) decode(r *http.Request, pathParams map[string]string) (proto.Message, error) {
+	msg := g.msgType.New().Interface()
+	unmarshal := protojson.UnmarshalOptions{DiscardUnknown: true}
+
+	if g.body != "" {
+		data, err := readBody(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			if g.body != "*" {
+				// 请求体对应单个字段时，将其包装为只包含该字段的 JSON 对象再解码
+				fd := msg.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(g.body))
+				if fd == nil {
+					return msg, nil
+				}
+				data = append(append([]byte(`{"`+fd.JSONName()+`":`), data...), '}')
+			}
+			if err := unmarshal.Unmarshal(data, msg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 路径参数覆盖请求体中的同名字段
+	filter := make([][]string, 0, len(pathParams)+1)
+	for key, value := range pathParams {
+		if err := runtime.PopulateFieldFromPath(msg, key, value); err != nil {
+			return nil, err
+		}
+		filter = append(filter, strings.Split(key, "."))
+	}
+
+	// body 为 * 时查询参数不会被解析
+	if g.body != "*" {
+		if g.body != "" {
+			filter = append(filter, []string{g.body})
+		}
+		if err := runtime.PopulateQueryParameters(msg, r.URL.Query(), utilities.NewDoubleArray(filter)); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// gatewayRoutes 是从已注册的 proto 文件中收集的 gRPC-Gateway 路由，键为 "方法 路由模式"
+var gatewayRoutes = sync.OnceValue(loadGatewayRoutes)
+
+// lookupGatewayRoute 查找路由模式对应的请求消息
+func lookupGatewayRoute(method, pattern string) *gatewayRoute {
+	return gatewayRoutes()[method+" "+pattern]
+}
+
+// loadGatewayRoutes 遍历所有已注册服务方法的 google.api.http 规则，建立路由到请求消息的映射
+func loadGatewayRoutes() map[string]*gatewayRoute {
+	routes := make(map[string]*gatewayRoute)
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			service := services.Get(i)
+			methods := service.Methods()
+			for j := 0; j < methods.Len(); j++ {
+				md := methods.Get(j)
+				rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+				if !ok || rule == nil {
+					continue
+				}
+				msgType, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+				if err != nil {
+					continue
+				}
+				for _, binding := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+					method, path := httpRulePattern(binding)
+					if method == "" {
+						continue
+					}
+					routes[method+" "+normalizePathTemplate(path)] = &gatewayRoute{
+						msgType:   msgType,
+						body:      binding.GetBody(),
+						rpcMethod: "/" + string(service.FullName()) + "/" + string(md.Name()),
+					}
+				}
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+// httpRulePattern 返回 HTTP 规则的方法和路径模板
+func httpRulePattern(rule *annotations.HttpRule) (method, path string) {
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return "", ""
+	}
+}
+
+// bareVariable 匹配没有指定模式的路径变量，如 {name}
+var bareVariable = regexp.MustCompile(`\{([^=}]+)\}`)
+
+// normalizePathTemplate 将路径模板转换为 runtime.Pattern.String() 的格式，如 /v1/hello/{name} 转换为 /v1/hello/{name=*}
+func normalizePathTemplate(path string) string {
+	return bareVariable.ReplaceAllString(path, "{$1=*}")
+}
+
+// defaultVariable 匹配 runtime.Pattern.String() 中使用默认模式的路径变量，如 {name=*}
+var defaultVariable = regexp.MustCompile(`\{([^=}]+)=\*\}`)
+
+// bareTemplate 将 runtime.Pattern.String() 的格式转换回 proto 中的路径模板，是 normalizePathTemplate 的逆操作
+func bareTemplate(pattern string) string {
+	return defaultVariable.ReplaceAllString(pattern, "{$1}")
+}
+
+// readBody 读取请求体并重新放回请求中，以便后续处理器再次读取
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
 }
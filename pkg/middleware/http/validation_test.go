@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	helloworldv2 "github.com/costa92/go-protoc/pkg/api/helloworld/v2"
+	"github.com/costa92/go-protoc/pkg/response"
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// greeterServer 是测试用的 Greeter 服务实现
+type greeterServer struct {
+	helloworldv2.UnimplementedGreeterServer
+}
+
+func (s *greeterServer) SayHello(ctx context.Context, req *helloworldv2.HelloRequest) (*helloworldv2.HelloReply, error) {
+	return &helloworldv2.HelloReply{Message: "Hello " + req.GetName()}, nil
+}
+
+func (s *greeterServer) SayHelloAgain(ctx context.Context, req *helloworldv2.HelloRequest) (*helloworldv2.HelloReply, error) {
+	return &helloworldv2.HelloReply{Message: "Hello again " + req.GetName()}, nil
+}
+
+type createUserRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	gwmux := runtime.NewServeMux(runtime.WithMiddlewares(GatewayValidationMiddleware()))
+	response.Setup(gwmux)
+	if err := helloworldv2.RegisterGreeterHandlerServer(context.Background(), gwmux, &greeterServer{}); err != nil {
+		t.Fatalf("注册 gRPC-Gateway 处理器失败: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.Use(ValidationMiddleware())
+	RegisterRequestBody(http.MethodPost, "/users", &createUserRequest{})
+	router.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		body := RequestBody(r).(*createUserRequest)
+		response.WriteSuccess(w, body.Name, "")
+	}).Methods(http.MethodPost)
+	router.PathPrefix("/").Handler(gwmux).Name(GatewayRouteName)
+
+	// 定义测试用例
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		status  int
+		details []string
+	}{
+		{name: "网关请求体校验失败", method: http.MethodPost, path: "/v2/hello", body: `{"name":""}`, status: http.StatusBadRequest, details: []string{"name"}},
+		{name: "网关请求体校验通过", method: http.MethodPost, path: "/v2/hello", body: `{"name":"bob"}`, status: http.StatusOK},
+		{name: "网关请求体格式错误", method: http.MethodPost, path: "/v2/hello", body: `{bad`, status: http.StatusBadRequest},
+		{name: "网关路径参数校验失败", method: http.MethodGet, path: "/v2/hello/" + strings.Repeat("a", 101), status: http.StatusBadRequest, details: []string{"name"}},
+		{name: "网关路径参数校验通过", method: http.MethodGet, path: "/v2/hello/bob", status: http.StatusOK},
+		{name: "自定义路由校验失败", method: http.MethodPost, path: "/users", body: `{"email":"foo"}`, status: http.StatusBadRequest, details: []string{"name", "email"}},
+		{name: "自定义路由校验通过", method: http.MethodPost, path: "/users", body: `{"name":"bob","email":"bob@example.com"}`, status: http.StatusOK},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("状态码不匹配: 期望=%d, 实际=%d, 响应=%s", tc.status, rec.Code, rec.Body.String())
+			}
+
+			var resp response.Wrapper
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if len(resp.Details) != len(tc.details) {
+				t.Fatalf("字段错误数量不匹配: 期望=%v, 实际=%v", tc.details, resp.Details)
+			}
+			for i, field := range tc.details {
+				if resp.Details[i].Field != field {
+					t.Errorf("字段路径不匹配: 期望=%s, 实际=%s", field, resp.Details[i].Field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 内置的限流键
+const (
+	// KeyIP 按客户端 IP 限流
+	KeyIP = "ip"
+	// KeyAPIKey 按 API Key 限流，计数时使用 API Key 的哈希，不保存原始值
+	KeyAPIKey = "api_key"
+	// KeyUser 按已认证的用户限流，用户由认证中间件通过 log.ContextWithSubject 设置
+	KeyUser = "user"
+	// KeyTenant 按租户请求头限流
+	KeyTenant = "tenant"
+)
+
+// Request 是提取限流键需要的请求信息，HTTP 中间件和 gRPC 拦截器分别从请求头和元数据构造
+type Request struct {
+	// ClientIP 是解析后的客户端 IP
+	ClientIP string
+	// Subject 是已认证的用户，匿名请求为空
+	Subject string
+	// Header 返回指定请求头（gRPC 为元数据）的所有值
+	Header func(name string) []string
+	// Policy 是调用方凭证绑定的策略名称（如 API Key 绑定的策略），为空时按路由和方法匹配策略
+	Policy string
+}
+
+// header 返回请求头的第一个非空值
+func (r Request) header(name string) string {
+	if r.Header == nil {
+		return ""
+	}
+	for _, v := range r.Header(name) {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// KeyFunc 从请求中提取限流键，返回空字符串表示无法提取，此时按客户端 IP 计数
+type KeyFunc func(req Request) string
+
+var (
+	keysMu sync.RWMutex
+	keys   = make(map[string]KeyFunc)
+)
+
+// RegisterKey 注册自定义的限流键，之后可以在策略的 key 中使用该名称，不能覆盖内置的键
+func RegisterKey(name string, fn KeyFunc) {
+	if isBuiltinKey(name) {
+		panic("ratelimit: 不能覆盖内置的限流键 " + name)
+	}
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keys[name] = fn
+}
+
+// lookupKey 查找注册的自定义限流键
+func lookupKey(name string) KeyFunc {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return keys[name]
+}
+
+// KeyNames 返回所有可用的限流键名称
+func KeyNames() []string {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	names := []string{KeyIP, KeyAPIKey, KeyUser, KeyTenant}
+	custom := make([]string, 0, len(keys))
+	for name := range keys {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// KeyRegistered 判断限流键是否可用
+func KeyRegistered(name string) bool {
+	return isBuiltinKey(name) || lookupKey(name) != nil
+}
+
+// isBuiltinKey 判断是否是内置的限流键
+func isBuiltinKey(name string) bool {
+	switch name {
+	case KeyIP, KeyAPIKey, KeyUser, KeyTenant:
+		return true
+	}
+	return false
+}
+
+// builtinKeys 按配置创建内置的限流键
+func builtinKeys(opts Options) map[string]KeyFunc {
+	apiKeyHeader := opts.APIKeyHeader
+	if apiKeyHeader == "" {
+		apiKeyHeader = DefaultAPIKeyHeader
+	}
+	tenantHeader := opts.TenantHeader
+	if tenantHeader == "" {
+		tenantHeader = DefaultTenantHeader
+	}
+	return map[string]KeyFunc{
+		KeyIP:   func(req Request) string { return req.ClientIP },
+		KeyUser: func(req Request) string { return req.Subject },
+		KeyAPIKey: func(req Request) string {
+			key := req.header(apiKeyHeader)
+			if key == "" {
+				return ""
+			}
+			sum := sha256.Sum256([]byte(key))
+			return hex.EncodeToString(sum[:16])
+		},
+		KeyTenant: func(req Request) string { return req.header(tenantHeader) },
+	}
+}
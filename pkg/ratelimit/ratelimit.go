@@ -0,0 +1,337 @@
+// Package ratelimit 实现按策略和键限流的令牌桶限流器。
+// 策略按 HTTP 路由模板或 gRPC 方法匹配，没有匹配的请求使用默认策略；
+// 每个策略按键（客户端 IP、API Key、已认证用户或租户）分别计数，HTTP 中间件和 gRPC 拦截器共用同一个 Limiter。
+// 令牌桶保存在 Store 中：MemoryStore 在进程内保存，RedisStore 在 Redis 中保存，使多个副本共享计数。
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+)
+
+const (
+	// DefaultPolicyName 是默认策略的名称
+	DefaultPolicyName = "default"
+	// DefaultWindow 是未配置时间窗口时使用的窗口
+	DefaultWindow = time.Second
+	// DefaultAPIKeyHeader 是默认读取 API Key 的请求头
+	DefaultAPIKeyHeader = "X-API-Key"
+	// DefaultTenantHeader 是默认读取租户的请求头
+	DefaultTenantHeader = "X-Tenant-ID"
+
+	// HeaderLimit 是返回令牌桶容量的响应头
+	HeaderLimit = "X-RateLimit-Limit"
+	// HeaderRemaining 是返回剩余令牌数的响应头
+	HeaderRemaining = "X-RateLimit-Remaining"
+	// HeaderReset 是返回令牌桶恢复到满还需要多少秒的响应头
+	HeaderReset = "X-RateLimit-Reset"
+	// HeaderRetryAfter 是请求被拒绝时返回多少秒后可以重试的响应头
+	HeaderRetryAfter = "Retry-After"
+
+	// loggerName 是限流器日志记录器的名称
+	loggerName = "ratelimit"
+)
+
+// Policy 定义一个限流策略：每个键在 Window 内最多 Limit 个请求，最多允许 Burst 个请求的突发
+type Policy struct {
+	// Name 是策略名称，用于区分不同策略的计数和响应中的附加信息
+	Name string
+	// Routes 是匹配的 HTTP 路由模板，如 /v1/hello/{name}
+	Routes []string
+	// Methods 是匹配的 gRPC 全方法名，如 /helloworld.v1.Greeter/SayHello，支持 /helloworld.v1.Greeter/* 匹配服务的所有方法
+	Methods []string
+	// Key 是限流键的名称，为空时使用默认策略的键
+	Key string
+	// Limit 是每个时间窗口允许的请求数
+	Limit int
+	// Burst 是令牌桶的容量，为 0 时等于 Limit
+	Burst int
+	// Window 是时间窗口，为 0 时使用默认策略的窗口
+	Window time.Duration
+}
+
+// Options 定义限流器的配置
+type Options struct {
+	Enable bool
+	// Default 是没有匹配任何策略的请求使用的策略，名称固定为 DefaultPolicyName
+	Default Policy
+	// Policies 是按顺序匹配的策略，使用第一个匹配的策略
+	Policies []Policy
+	// APIKeyHeader 是 api_key 键读取的请求头，为空时使用 DefaultAPIKeyHeader
+	APIKeyHeader string
+	// TenantHeader 是 tenant 键读取的请求头，为空时使用 DefaultTenantHeader
+	TenantHeader string
+	// SkipPaths 是不限流的 HTTP 路径
+	SkipPaths []string
+}
+
+// Result 是一次限流检查的结果
+type Result struct {
+	// Allowed 表示请求是否被允许
+	Allowed bool
+	// Policy 是使用的策略名称，限流未启用时为空
+	Policy string
+	// Limit 是令牌桶的容量，即最多可以连续发出的请求数
+	Limit int
+	// Remaining 是本次请求之后剩余的令牌数
+	Remaining int
+	// Reset 是令牌桶恢复到满的时间
+	Reset time.Duration
+	// RetryAfter 是请求被拒绝时，到下一个令牌可用的时间
+	RetryAfter time.Duration
+}
+
+// Headers 返回需要写入响应的限流头，限流未启用时返回 nil，请求被拒绝时包含 Retry-After
+// 秒数向上取整，Retry-After 至少为 1 秒
+func (r Result) Headers() map[string]string {
+	if r.Policy == "" {
+		return nil
+	}
+	headers := map[string]string{
+		HeaderLimit:     strconv.Itoa(r.Limit),
+		HeaderRemaining: strconv.Itoa(r.Remaining),
+		HeaderReset:     strconv.Itoa(ceilSeconds(r.Reset)),
+	}
+	if !r.Allowed {
+		headers[HeaderRetryAfter] = strconv.Itoa(max(ceilSeconds(r.RetryAfter), 1))
+	}
+	return headers
+}
+
+// ceilSeconds 返回向上取整的秒数
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// policy 是预处理后的策略
+type policy struct {
+	name    string
+	routes  []string
+	methods []string
+	key     KeyFunc
+	keyName string
+	bucket  Bucket
+}
+
+// matchMethod 判断 gRPC 方法是否匹配策略
+func (p *policy) matchMethod(method string) bool {
+	for _, m := range p.methods {
+		if prefix, ok := strings.CutSuffix(m, "*"); ok {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		} else if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute 判断 HTTP 路由模板是否匹配策略
+func (p *policy) matchRoute(route string) bool {
+	for _, r := range p.routes {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+// state 是预处理后的限流配置
+type state struct {
+	enable    bool
+	def       *policy
+	policies  []*policy
+	skipPaths []string
+}
+
+// bound 返回名称为 name 的策略，不存在时返回 nil
+func (s *state) bound(name string) *policy {
+	switch name {
+	case "":
+		return nil
+	case DefaultPolicyName:
+		return s.def
+	}
+	for _, p := range s.policies {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Limiter 是支持在运行时更新配置的限流器
+type Limiter struct {
+	state atomic.Pointer[state]
+	store Store
+}
+
+// NewLimiter 创建一个新的 Limiter 实例，令牌桶保存在 store 中，store 为 nil 时使用默认容量的 MemoryStore
+func NewLimiter(opts Options, store Store) (*Limiter, error) {
+	if store == nil {
+		store = NewMemoryStore(0)
+	}
+	l := &Limiter{store: store}
+	if err := l.Update(opts); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Update 更新限流配置，已存在的令牌桶在下一次请求时使用新的速率和容量，配置无效时保持原配置并返回错误
+// 存储不随配置更新，限流未启用时不使用也不校验策略
+func (l *Limiter) Update(opts Options) error {
+	if !opts.Enable {
+		l.state.Store(&state{})
+		return nil
+	}
+	keys := builtinKeys(opts)
+
+	def := opts.Default
+	def.Name = DefaultPolicyName
+	if def.Key == "" {
+		def.Key = KeyIP
+	}
+	if def.Window <= 0 {
+		def.Window = DefaultWindow
+	}
+	defPolicy, err := newPolicy(def, keys)
+	if err != nil {
+		return err
+	}
+
+	s := &state{enable: opts.Enable, def: defPolicy, skipPaths: opts.SkipPaths}
+	names := map[string]bool{DefaultPolicyName: true}
+	for _, p := range opts.Policies {
+		if names[p.Name] {
+			return fmt.Errorf("限流策略名称 %q 重复", p.Name)
+		}
+		names[p.Name] = true
+		if p.Key == "" {
+			p.Key = def.Key
+		}
+		if p.Window <= 0 {
+			p.Window = def.Window
+		}
+		compiled, err := newPolicy(p, keys)
+		if err != nil {
+			return err
+		}
+		s.policies = append(s.policies, compiled)
+	}
+	l.state.Store(s)
+	return nil
+}
+
+// newPolicy 预处理一个策略
+func newPolicy(p Policy, keys map[string]KeyFunc) (*policy, error) {
+	if p.Name == "" {
+		return nil, fmt.Errorf("限流策略名称不能为空")
+	}
+	key := keys[p.Key]
+	if key == nil {
+		key = lookupKey(p.Key)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("限流策略 %q 使用了未注册的键 %q", p.Name, p.Key)
+	}
+	if p.Limit <= 0 {
+		return nil, fmt.Errorf("限流策略 %q 的 limit 必须大于 0", p.Name)
+	}
+	if p.Window <= 0 {
+		return nil, fmt.Errorf("限流策略 %q 的 window 必须大于 0", p.Name)
+	}
+	burst := p.Burst
+	if burst <= 0 {
+		burst = p.Limit
+	}
+	return &policy{
+		name:    p.Name,
+		routes:  p.Routes,
+		methods: p.Methods,
+		key:     key,
+		keyName: p.Key,
+		bucket:  Bucket{Rate: float64(p.Limit) / p.Window.Seconds(), Burst: burst},
+	}, nil
+}
+
+// Enabled 判断是否启用了限流
+func (l *Limiter) Enabled() bool {
+	return l.state.Load().enable
+}
+
+// Skip 判断 HTTP 路径是否不限流
+func (l *Limiter) Skip(path string) bool {
+	for _, p := range l.state.Load().skipPaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow 检查请求是否被允许，并消耗一个令牌
+// req.Policy 指定的策略存在时使用该策略，否则使用第一个匹配 route（HTTP 路由模板）或 method（gRPC 全方法名）的策略，
+// 都没有匹配时使用默认策略
+// 策略的键无法从请求中提取时（如匿名请求使用 user 键）按客户端 IP 计数
+// 存储不可用时放行请求并记录错误日志，此时结果中没有策略，不返回限流响应头
+func (l *Limiter) Allow(ctx context.Context, route, method string, req Request) Result {
+	s := l.state.Load()
+	if !s.enable {
+		return Result{Allowed: true}
+	}
+
+	p := s.bound(req.Policy)
+	if p == nil {
+		p = s.def
+		for _, candidate := range s.policies {
+			if (route != "" && candidate.matchRoute(route)) || (method != "" && candidate.matchMethod(method)) {
+				p = candidate
+				break
+			}
+		}
+	}
+
+	keyName, value := p.keyName, p.key(req)
+	if value == "" {
+		keyName, value = KeyIP, req.ClientIP
+	}
+	allowed, tokens, err := l.store.Take(ctx, p.name+"|"+keyName+":"+value, p.bucket, time.Now())
+	if err != nil {
+		log.FromContextNamed(ctx, loggerName).Errorw("限流存储不可用，放行请求", "policy", p.name, "error", err)
+		return Result{Allowed: true}
+	}
+
+	res := Result{
+		Allowed:   allowed,
+		Policy:    p.name,
+		Limit:     p.bucket.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     tokenDuration(float64(p.bucket.Burst)-tokens, p.bucket.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = tokenDuration(1-tokens, p.bucket.Rate)
+	}
+	return res
+}
+
+// Close 关闭限流器使用的存储
+func (l *Limiter) Close() error {
+	return l.store.Close()
+}
+
+// tokenDuration 返回按每秒 rate 个的速率生成 n 个令牌需要的时间
+func tokenDuration(n, rate float64) time.Duration {
+	if n <= 0 || rate <= 0 {
+		return 0
+	}
+	return time.Duration(n / rate * float64(time.Second))
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLimiter(Options{
+		Enable:  true,
+		Default: Policy{Limit: 2, Burst: 2},
+		Policies: []Policy{
+			{Name: "users", Routes: []string{"/users/{id}"}, Key: KeyUser, Limit: 1, Window: time.Minute},
+			{Name: "greeter", Methods: []string{"/helloworld.v2.Greeter/*"}, Key: KeyTenant, Limit: 3},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("创建限流器失败: %v", err)
+	}
+
+	tenant := func(name string) func(string) []string {
+		return func(key string) []string { return http.Header{"X-Tenant-Id": {name}}.Values(key) }
+	}
+
+	steps := []struct {
+		name    string
+		route   string
+		method  string
+		req     Request
+		allowed bool
+		policy  string
+		remain  int
+	}{
+		{name: "默认策略", route: "/other", req: Request{ClientIP: "1.1.1.1"}, allowed: true, policy: DefaultPolicyName, remain: 1},
+		{name: "默认策略第二次", route: "/other", req: Request{ClientIP: "1.1.1.1"}, allowed: true, policy: DefaultPolicyName, remain: 0},
+		{name: "默认策略超出限制", route: "/other", req: Request{ClientIP: "1.1.1.1"}, allowed: false, policy: DefaultPolicyName, remain: 0},
+		{name: "其他 IP 单独计数", route: "/other", req: Request{ClientIP: "2.2.2.2"}, allowed: true, policy: DefaultPolicyName, remain: 1},
+		{name: "按用户限流", route: "/users/{id}", req: Request{ClientIP: "1.1.1.1", Subject: "alice"}, allowed: true, policy: "users", remain: 0},
+		{name: "同一用户不同 IP", route: "/users/{id}", req: Request{ClientIP: "3.3.3.3", Subject: "alice"}, allowed: false, policy: "users", remain: 0},
+		{name: "匿名用户按 IP 计数", route: "/users/{id}", req: Request{ClientIP: "3.3.3.3"}, allowed: true, policy: "users", remain: 0},
+		{name: "按 gRPC 方法匹配", method: "/helloworld.v2.Greeter/SayHello", req: Request{ClientIP: "1.1.1.1", Header: tenant("acme")}, allowed: true, policy: "greeter", remain: 2},
+		{name: "同一租户共享计数", method: "/helloworld.v2.Greeter/SayHelloAgain", req: Request{ClientIP: "2.2.2.2", Header: tenant("acme")}, allowed: true, policy: "greeter", remain: 1},
+		{name: "凭证绑定的策略优先", route: "/other", req: Request{ClientIP: "4.4.4.4", Subject: "partner", Policy: "users"}, allowed: true, policy: "users", remain: 0},
+		{name: "绑定的策略不存在", route: "/other", req: Request{ClientIP: "5.5.5.5", Policy: "missing"}, allowed: true, policy: DefaultPolicyName, remain: 1},
+	}
+	for _, s := range steps {
+		res := l.Allow(ctx, s.route, s.method, s.req)
+		if res.Allowed != s.allowed || res.Policy != s.policy || res.Remaining != s.remain {
+			t.Errorf("%s: 结果 = %+v，期望 allowed=%v policy=%s remaining=%d", s.name, res, s.allowed, s.policy, s.remain)
+		}
+	}
+
+	res := l.Allow(ctx, "/other", "", Request{ClientIP: "1.1.1.1"})
+	headers := res.Headers()
+	if headers[HeaderLimit] != "2" || headers[HeaderRemaining] != "0" || headers[HeaderRetryAfter] == "" {
+		t.Errorf("被拒绝时的响应头 = %v", headers)
+	}
+
+	// 更新配置后立即使用新的容量
+	if err := l.Update(Options{Enable: true, Default: Policy{Limit: 2, Burst: 10}}); err != nil {
+		t.Fatalf("更新限流配置失败: %v", err)
+	}
+	if res := l.Allow(ctx, "/other", "", Request{ClientIP: "1.1.1.1"}); res.Limit != 10 {
+		t.Errorf("更新后令牌桶容量 = %d，期望 10", res.Limit)
+	}
+
+	// 限流未启用时不返回响应头
+	if err := l.Update(Options{}); err != nil {
+		t.Fatalf("更新限流配置失败: %v", err)
+	}
+	if res := l.Allow(ctx, "/other", "", Request{ClientIP: "1.1.1.1"}); !res.Allowed || res.Headers() != nil {
+		t.Errorf("限流未启用时结果 = %+v", res)
+	}
+
+	if err := l.Update(Options{Enable: true, Default: Policy{Limit: 1, Key: "session"}}); err == nil {
+		t.Error("未注册的限流键应该返回错误")
+	}
+	if err := l.Update(Options{Enable: true, Default: Policy{Limit: 0}}); err == nil {
+		t.Error("limit 为 0 应该返回错误")
+	}
+	if err := l.Update(Options{Enable: true, Default: Policy{Limit: 1}, Policies: []Policy{
+		{Name: "login", Routes: []string{"/login"}, Limit: -1},
+	}}); err == nil {
+		t.Error("策略的 limit 为负数应该返回错误")
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0)
+	l, err := NewLimiter(Options{
+		Enable:       true,
+		Default:      Policy{Limit: 1, Key: KeyAPIKey},
+		APIKeyHeader: "X-Partner-Key",
+	}, store)
+	if err != nil {
+		t.Fatalf("创建限流器失败: %v", err)
+	}
+
+	header := func(key string) func(string) []string {
+		return func(name string) []string { return http.Header{"X-Partner-Key": {key}}.Values(name) }
+	}
+	if !l.Allow(ctx, "", "", Request{ClientIP: "1.1.1.1", Header: header("k1")}).Allowed {
+		t.Error("第一次请求应该被允许")
+	}
+	if l.Allow(ctx, "", "", Request{ClientIP: "2.2.2.2", Header: header("k1")}).Allowed {
+		t.Error("同一个 API Key 应该共享计数")
+	}
+	if !l.Allow(ctx, "", "", Request{ClientIP: "1.1.1.1", Header: header("k2")}).Allowed {
+		t.Error("不同的 API Key 应该单独计数")
+	}
+
+	for i := range store.shards {
+		for key := range store.shards[i].items {
+			if strings.Contains(key, "k1") || strings.Contains(key, "k2") {
+				t.Errorf("计数键 %q 不应该包含原始 API Key", key)
+			}
+		}
+	}
+}
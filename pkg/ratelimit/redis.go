@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultRedisKeyPrefix 是 Redis 存储默认的键前缀
+	DefaultRedisKeyPrefix = "ratelimit:"
+	// DefaultRedisTimeout 是 Redis 存储默认的单次操作超时
+	DefaultRedisTimeout = 100 * time.Millisecond
+)
+
+// takeScript 原子地补充并消耗令牌，令牌桶保存在哈希的 tokens 和 ts（毫秒）字段中
+// 与 MemoryStore 使用相同的算法；键在令牌桶恢复到满之后过期，与不存在的键等价
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(now - ts, 0) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(math.max(now, ts)))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisOptions 定义 Redis 存储的配置
+type RedisOptions struct {
+	// Addrs 是 Redis 地址，多个地址时使用集群模式
+	Addrs    []string
+	Username string
+	Password string
+	DB       int
+	// KeyPrefix 是令牌桶键的前缀，为空时使用 DefaultRedisKeyPrefix
+	KeyPrefix string
+	// Timeout 是单次操作的超时，为 0 时使用 DefaultRedisTimeout
+	Timeout time.Duration
+}
+
+// RedisStore 是在 Redis（或兼容 Redis 协议的服务）中保存令牌桶的存储，所有副本共享计数
+// 令牌补充使用调用方的时钟，副本之间的时钟偏差会使令牌补充略快或略慢，但不会出现负的补充
+type RedisStore struct {
+	client  redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+}
+
+// NewRedisStore 创建一个新的 RedisStore 实例，连接在第一次使用时建立
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRedisTimeout
+	}
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:        opts.Addrs,
+		Username:     opts.Username,
+		Password:     opts.Password,
+		DB:           opts.DB,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	})
+	return NewRedisStoreWithClient(client, opts.KeyPrefix, timeout)
+}
+
+// NewRedisStoreWithClient 使用已有的 Redis 客户端创建 RedisStore，Close 时会关闭该客户端
+func NewRedisStoreWithClient(client redis.UniversalClient, prefix string, timeout time.Duration) *RedisStore {
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	if timeout <= 0 {
+		timeout = DefaultRedisTimeout
+	}
+	return &RedisStore{client: client, prefix: prefix, timeout: timeout}
+}
+
+// Take 实现 Store 接口
+func (s *RedisStore) Take(ctx context.Context, key string, b Bucket, now time.Time) (bool, float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// 令牌桶恢复到满之后的状态与不存在等价，多保留一秒避免边界上的误差
+	ttl := int64(math.Ceil(float64(b.Burst)/b.Rate*1000)) + 1000
+	res, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		b.Rate, b.Burst, now.UnixMilli(), ttl).Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("执行限流脚本失败: %w", err)
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("限流脚本返回了意外的结果: %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	text, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("解析剩余令牌数 %q 失败: %w", text, err)
+	}
+	return allowed == 1, tokens, nil
+}
+
+// Ping 检查 Redis 是否可用
+func (s *RedisStore) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.client.Ping(ctx).Err()
+}
+
+// Close 实现 Store 接口，关闭 Redis 客户端
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
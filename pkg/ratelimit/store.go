@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// BackendMemory 在进程内保存令牌桶，每个副本单独计数
+	BackendMemory = "memory"
+	// BackendRedis 在 Redis 中保存令牌桶，所有副本共享计数
+	BackendRedis = "redis"
+
+	// DefaultMaxKeys 是内存存储默认最多保存的令牌桶数量
+	DefaultMaxKeys = 100000
+
+	// memoryShards 是内存存储的分片数量，减少锁竞争
+	memoryShards = 64
+)
+
+// Bucket 是令牌桶的参数
+type Bucket struct {
+	// Rate 是每秒补充的令牌数
+	Rate float64
+	// Burst 是令牌桶的容量
+	Burst int
+}
+
+// Store 保存令牌桶的状态
+// 令牌桶不存在时视为满的，因此实现可以随时丢弃已经恢复到满的令牌桶
+type Store interface {
+	// Take 从 key 对应的令牌桶中消耗一个令牌，令牌不足时不消耗，返回是否允许和之后剩余的令牌数
+	Take(ctx context.Context, key string, b Bucket, now time.Time) (allowed bool, tokens float64, err error)
+	// Close 释放存储使用的资源
+	Close() error
+}
+
+// refill 返回上次更新时剩余 tokens 个令牌的令牌桶在 now 时的令牌数，时钟回拨时不补充
+func refill(tokens float64, last, now time.Time, b Bucket) float64 {
+	elapsed := max(now.Sub(last).Seconds(), 0)
+	return math.Min(float64(b.Burst), tokens+elapsed*b.Rate)
+}
+
+// MemoryStore 是在进程内保存令牌桶的存储
+// 按键的哈希分片，每个分片是有容量上限的 LRU，超出上限时丢弃最久未使用的令牌桶
+type MemoryStore struct {
+	shards [memoryShards]memoryShard
+}
+
+// memoryShard 是内存存储的一个分片
+type memoryShard struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	lru      *list.List
+}
+
+// memoryEntry 是一个令牌桶的状态
+type memoryEntry struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryStore 创建一个最多保存 maxKeys 个令牌桶的内存存储，maxKeys 不大于 0 时使用 DefaultMaxKeys
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	if maxKeys <= 0 {
+		maxKeys = DefaultMaxKeys
+	}
+	capacity := max((maxKeys+memoryShards-1)/memoryShards, 1)
+	s := &MemoryStore{}
+	for i := range s.shards {
+		s.shards[i] = memoryShard{capacity: capacity, items: make(map[string]*list.Element), lru: list.New()}
+	}
+	return s
+}
+
+// Take 实现 Store 接口
+func (s *MemoryStore) Take(_ context.Context, key string, b Bucket, now time.Time) (bool, float64, error) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	shard := &s.shards[h.Sum32()%memoryShards]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	var entry *memoryEntry
+	if elem, ok := shard.items[key]; ok {
+		shard.lru.MoveToFront(elem)
+		entry = elem.Value.(*memoryEntry)
+		entry.tokens = refill(entry.tokens, entry.last, now, b)
+		if now.After(entry.last) {
+			entry.last = now
+		}
+	} else {
+		if shard.lru.Len() >= shard.capacity {
+			oldest := shard.lru.Back()
+			shard.lru.Remove(oldest)
+			delete(shard.items, oldest.Value.(*memoryEntry).key)
+		}
+		entry = &memoryEntry{key: key, tokens: float64(b.Burst), last: now}
+		shard.items[key] = shard.lru.PushFront(entry)
+	}
+
+	allowed := entry.tokens >= 1
+	if allowed {
+		entry.tokens--
+	}
+	return allowed, entry.tokens, nil
+}
+
+// Len 返回保存的令牌桶数量
+func (s *MemoryStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += s.shards[i].lru.Len()
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
+
+// Close 实现 Store 接口
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	stores := map[string]Store{
+		"内存存储":     NewMemoryStore(0),
+		"Redis 存储": NewRedisStore(RedisOptions{Addrs: []string{mr.Addr()}}),
+	}
+
+	// 容量为 2、每秒补充 1 个令牌的令牌桶
+	b := Bucket{Rate: 1, Burst: 2}
+	start := time.Unix(1700000000, 0)
+	steps := []struct {
+		name    string
+		offset  time.Duration
+		allowed bool
+		tokens  float64
+	}{
+		{name: "新的令牌桶是满的", allowed: true, tokens: 1},
+		{name: "消耗最后一个令牌", allowed: true, tokens: 0},
+		{name: "令牌不足", allowed: false, tokens: 0},
+		{name: "补充半个令牌仍然不足", offset: 500 * time.Millisecond, allowed: false, tokens: 0.5},
+		{name: "补充一个令牌", offset: time.Second, allowed: true, tokens: 0},
+		{name: "时钟回拨不补充", offset: 200 * time.Millisecond, allowed: false, tokens: 0},
+		{name: "补充不超过容量", offset: time.Minute, allowed: true, tokens: 1},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			defer store.Close()
+			for _, s := range steps {
+				allowed, tokens, err := store.Take(context.Background(), "k", b, start.Add(s.offset))
+				if err != nil {
+					t.Fatalf("%s: %v", s.name, err)
+				}
+				if allowed != s.allowed || tokens != s.tokens {
+					t.Errorf("%s: allowed=%v tokens=%v，期望 allowed=%v tokens=%v", s.name, allowed, tokens, s.allowed, s.tokens)
+				}
+			}
+		})
+	}
+
+	if ttl := mr.TTL(DefaultRedisKeyPrefix + "k"); ttl <= 0 || ttl > 3*time.Second {
+		t.Errorf("Redis 键的过期时间 = %v，期望在令牌桶恢复到满之后过期", ttl)
+	}
+
+	// Redis 不可用时返回错误，由 Limiter 放行请求
+	addr := mr.Addr()
+	mr.Close()
+	l, err := NewLimiter(Options{Enable: true, Default: Policy{Limit: 1}}, NewRedisStore(RedisOptions{Addrs: []string{addr}}))
+	if err != nil {
+		t.Fatalf("创建限流器失败: %v", err)
+	}
+	defer l.Close()
+	if res := l.Allow(context.Background(), "", "", Request{ClientIP: "1.1.1.1"}); !res.Allowed || res.Headers() != nil {
+		t.Errorf("Redis 不可用时结果 = %+v，期望放行且不返回限流头", res)
+	}
+}
+
+func TestMemoryStoreBounded(t *testing.T) {
+	store := NewMemoryStore(memoryShards * 2)
+	b := Bucket{Rate: 1, Burst: 1}
+	now := time.Now()
+	for i := 0; i < 10000; i++ {
+		store.Take(context.Background(), fmt.Sprintf("k%d", i), b, now)
+	}
+	if n := store.Len(); n > memoryShards*2 {
+		t.Errorf("保存的令牌桶数量 = %d，期望不超过 %d", n, memoryShards*2)
+	}
+
+	// 最近使用的令牌桶不会被丢弃
+	if allowed, _, _ := store.Take(context.Background(), "k9999", b, now); allowed {
+		t.Error("最近使用的令牌桶不应该被丢弃")
+	}
+}
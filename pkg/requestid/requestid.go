@@ -0,0 +1,50 @@
+// Package requestid 生成和校验请求 ID。
+// 请求 ID 通过 X-Request-ID 请求头和 x-request-id gRPC 元数据传递，
+// 在服务内部通过 log.ContextWithRequestID 放入上下文，并出现在日志、追踪和错误响应中。
+package requestid
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	// Header 是携带请求 ID 的 HTTP 头
+	Header = "X-Request-ID"
+	// MetadataKey 是携带请求 ID 的 gRPC 元数据键
+	MetadataKey = "x-request-id"
+	// Attribute 是追踪 span 中记录请求 ID 的属性名
+	Attribute = "request_id"
+	// MaxLength 是客户端传入的请求 ID 的最大长度
+	MaxLength = 128
+)
+
+// New 生成一个新的请求 ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid 检查客户端传入的请求 ID 是否可以使用
+// 只接受不超过 MaxLength 的字母、数字和 - _ . : 字符，避免日志注入和超长的头
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Ensure 返回可以使用的请求 ID，id 不合法时生成一个新的
+func Ensure(id string) string {
+	if Valid(id) {
+		return id
+	}
+	return New()
+}
@@ -0,0 +1,38 @@
+package requestid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "UUID", id: "0b8e2c1e-6f3a-4b8e-9a51-3f1c2d4e5f60", want: true},
+		{name: "允许的符号", id: "svc.a:req_1", want: true},
+		{name: "空字符串", id: "", want: false},
+		{name: "包含空格", id: "req 1", want: false},
+		{name: "包含换行", id: "req\n1", want: false},
+		{name: "超长", id: strings.Repeat("a", MaxLength+1), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid(tt.id); got != tt.want {
+				t.Errorf("Valid(%q) = %v，期望 %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	if got := Ensure("req-1"); got != "req-1" {
+		t.Errorf("合法的请求 ID 应该保留，实际为 %q", got)
+	}
+	if got := Ensure("bad id"); !Valid(got) || got == "bad id" {
+		t.Errorf("不合法的请求 ID 应该被替换，实际为 %q", got)
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/requestid"
+	"github.com/costa92/go-protoc/pkg/validation"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -19,11 +23,28 @@ func CustomHTTPErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshale
 	// 根据gRPC错误代码映射HTTP状态码
 	httpStatus := HTTPStatusFromCode(s.Code())
 
-	// 创建错误响应
+	// 创建错误响应，附带 errdetails.BadRequest 中的字段级错误
 	errorResp := &Wrapper{
 		Status:  "error",
 		Code:    httpStatus,
 		Message: s.Message(),
+		Details: validation.FromStatus(s),
+	}
+
+	// 业务错误使用错误码对应的 HTTP 状态码，并返回业务错误码、原因、附加信息和详情
+	if apiErr, ok := errors.FromStatus(s); ok {
+		httpStatus = apiErr.HTTPStatusCode()
+		errorResp.Code = httpStatus
+		errorResp.ErrorCode = apiErr.Code
+		errorResp.Reason = apiErr.Reason
+		errorResp.Metadata = apiErr.Metadata
+		errorResp.Data = apiErr.Details
+	}
+
+	// 请求 ID 优先使用 RequestIDMiddleware 写入的响应头，其次使用上下文中的请求 ID
+	errorResp.RequestID = w.Header().Get(requestid.Header)
+	if errorResp.RequestID == "" {
+		errorResp.RequestID = log.RequestIDFromContext(ctx)
 	}
 
 	// 设置HTTP状态码和内容类型
@@ -0,0 +1,62 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCustomHTTPErrorHandlerDetails(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "参数验证失败").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "不能为空"},
+			{Field: "address.city", Description: "不能为空"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建状态失败: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/hello", nil)
+	CustomHTTPErrorHandler(context.Background(), runtime.NewServeMux(), &JSONMarshaler{}, rec, req, st.Err())
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("状态码不匹配: 期望=%d, 实际=%d", http.StatusBadRequest, rec.Code)
+	}
+
+	var resp Wrapper
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(resp.Details) != 2 || resp.Details[1].Field != "address.city" || resp.Details[1].Description != "不能为空" {
+		t.Errorf("字段错误不匹配: %+v", resp.Details)
+	}
+}
+
+func TestCustomHTTPErrorHandlerBusinessError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/1", nil)
+	err := errors.ErrUserNotFound.WithMetadata("user_id", "1")
+	CustomHTTPErrorHandler(context.Background(), runtime.NewServeMux(), &JSONMarshaler{}, rec, req, err)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("状态码不匹配: 期望=%d, 实际=%d", http.StatusNotFound, rec.Code)
+	}
+
+	var resp Wrapper
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.ErrorCode != 20100 || resp.Reason != "USER_NOT_FOUND" || resp.Metadata["user_id"] != "1" {
+		t.Errorf("业务错误信息不匹配: %+v", resp)
+	}
+}
@@ -3,6 +3,10 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/costa92/go-protoc/pkg/errors"
+	"github.com/costa92/go-protoc/pkg/requestid"
+	"github.com/costa92/go-protoc/pkg/validation"
 )
 
 // WriteJSON 将数据以JSON格式写入HTTP响应
@@ -27,19 +31,27 @@ func WriteJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 		}
 	}
 
-	// 设置响应头
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+	writeWrapper(w, resp)
+}
+
+// writeWrapper 序列化并写入统一格式的响应
+// 错误响应会带上 RequestIDMiddleware 写入响应头的请求 ID，便于按请求 ID 排查问题
+func writeWrapper(w http.ResponseWriter, resp *Wrapper) {
+	if resp.Status == "error" && resp.RequestID == "" {
+		resp.RequestID = w.Header().Get(requestid.Header)
+	}
 
-	// 序列化并写入响应
 	jsonBytes, err := json.Marshal(resp)
 	if err != nil {
 		// 序列化失败时的应急处理
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(`{"status":"error","code":500,"message":"序列化响应失败"}`))
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.Code)
 	w.Write(jsonBytes)
 }
 
@@ -52,33 +64,13 @@ func WriteRawData(w http.ResponseWriter, data []byte, contentType string) {
 // WriteSuccess 写入成功响应
 func WriteSuccess(w http.ResponseWriter, data interface{}, message string) {
 	resp := NewSuccessResponse(data, message)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(resp.Code)
-
-	jsonBytes, err := json.Marshal(resp)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"status":"error","code":500,"message":"序列化响应失败"}`))
-		return
-	}
-
-	w.Write(jsonBytes)
+	writeWrapper(w, resp)
 }
 
 // WriteError 写入错误响应
 func WriteError(w http.ResponseWriter, code int, message string, err error) {
 	resp := NewErrorResponse(code, message, err)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(resp.Code)
-
-	jsonBytes, err := json.Marshal(resp)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"status":"error","code":500,"message":"序列化响应失败"}`))
-		return
-	}
-
-	w.Write(jsonBytes)
+	writeWrapper(w, resp)
 }
 
 // WriteBadRequest 写入400错误响应
@@ -86,6 +78,12 @@ func WriteBadRequest(w http.ResponseWriter, message string, err error) {
 	WriteError(w, http.StatusBadRequest, message, err)
 }
 
+// WriteValidationError 写入包含字段校验错误的400错误响应
+func WriteValidationError(w http.ResponseWriter, violations []validation.Violation) {
+	resp := NewValidationErrorResponse(violations)
+	writeWrapper(w, resp)
+}
+
 // WriteUnauthorized 写入401错误响应
 func WriteUnauthorized(w http.ResponseWriter, message string, err error) {
 	WriteError(w, http.StatusUnauthorized, message, err)
@@ -105,3 +103,14 @@ func WriteNotFound(w http.ResponseWriter, message string, err error) {
 func WriteInternalServerError(w http.ResponseWriter, message string, err error) {
 	WriteError(w, http.StatusInternalServerError, message, err)
 }
+
+// WriteAPIError 以统一格式写入业务错误响应，状态码、业务错误码、原因、附加信息和详情都取自 err，
+// 与 gRPC-Gateway 返回的业务错误响应格式一致
+func WriteAPIError(w http.ResponseWriter, err *errors.Error) {
+	resp := NewErrorResponse(err.HTTPStatusCode(), err.Message, nil)
+	resp.ErrorCode = err.Code
+	resp.Reason = err.Reason
+	resp.Metadata = err.Metadata
+	resp.Data = err.Details
+	writeWrapper(w, resp)
+}
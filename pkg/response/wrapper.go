@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/costa92/go-protoc/pkg/errors"
 	"github.com/costa92/go-protoc/pkg/log"
+	"github.com/costa92/go-protoc/pkg/validation"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/protobuf/proto"
 )
@@ -21,8 +23,18 @@ type Wrapper struct {
 	Code int `json:"code"`
 	// Message 包含响应的消息说明
 	Message string `json:"message"`
+	// ErrorCode 是业务错误码，参见 pkg/errors
+	ErrorCode int `json:"error_code,omitempty"`
+	// Reason 是机器可读的错误原因
+	Reason string `json:"reason,omitempty"`
+	// Metadata 包含错误的附加信息
+	Metadata map[string]string `json:"metadata,omitempty"`
 	// Data 包含响应的具体数据
 	Data interface{} `json:"data,omitempty"`
+	// RequestID 是请求 ID，仅在错误响应中返回
+	RequestID string `json:"request_id,omitempty"`
+	// Details 包含字段级别的校验错误
+	Details []validation.Violation `json:"details,omitempty"`
 	// Error 包含详细错误信息，仅在开发环境中返回
 	Error interface{} `json:"error,omitempty"`
 }
@@ -120,6 +132,15 @@ func NewBadRequestResponse(message string, err error) *Wrapper {
 	return NewErrorResponse(http.StatusBadRequest, message, err)
 }
 
+// NewValidationErrorResponse 创建包含字段校验错误的400错误响应
+func NewValidationErrorResponse(violations []validation.Violation) *Wrapper {
+	resp := NewErrorResponse(http.StatusBadRequest, errors.ErrValidation.Message, nil)
+	resp.ErrorCode = errors.ErrValidation.Code
+	resp.Reason = errors.ErrValidation.Reason
+	resp.Details = violations
+	return resp
+}
+
 // NewUnauthorizedResponse 创建401错误响应
 func NewUnauthorizedResponse(message string, err error) *Wrapper {
 	return NewErrorResponse(http.StatusUnauthorized, message, err)
@@ -0,0 +1,77 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// Identity 表示经过验证的客户端证书身份
+type Identity struct {
+	// Subject 是证书主题的完整 DN
+	Subject string
+	// CommonName 是证书主题的 CN
+	CommonName string
+	// DNSNames 是证书中的 DNS 类型 SAN
+	DNSNames []string
+	// EmailAddresses 是证书中的邮箱类型 SAN
+	EmailAddresses []string
+	// URIs 是证书中的 URI 类型 SAN，例如 SPIFFE ID
+	URIs []string
+	// IPAddresses 是证书中的 IP 类型 SAN
+	IPAddresses []string
+	// SerialNumber 是证书序列号
+	SerialNumber string
+	// Certificate 是原始的客户端证书
+	Certificate *x509.Certificate
+}
+
+// Name 返回用于日志和审计的主体名称，优先使用 CN，没有 CN 时使用完整 DN
+func (id *Identity) Name() string {
+	if id.CommonName != "" {
+		return id.CommonName
+	}
+	return id.Subject
+}
+
+// identityKey 是存放 Identity 的上下文键
+type identityKey struct{}
+
+// NewContext 返回一个携带客户端身份的新上下文
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext 从上下文中获取客户端身份
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// IdentityFromConnectionState 从 TLS 连接状态中提取经过验证的客户端身份
+// 只有通过证书链校验的客户端证书才会被返回
+func IdentityFromConnectionState(state *tls.ConnectionState) (*Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return NewIdentity(state.VerifiedChains[0][0]), true
+}
+
+// NewIdentity 从证书创建 Identity
+func NewIdentity(cert *x509.Certificate) *Identity {
+	id := &Identity{
+		Subject:        cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		SerialNumber:   cert.SerialNumber.String(),
+		Certificate:    cert,
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		id.IPAddresses = append(id.IPAddresses, ip.String())
+	}
+	return id
+}
@@ -0,0 +1,162 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/log"
+)
+
+// DefaultReloadInterval 是检查证书文件变化的默认周期
+const DefaultReloadInterval = 30 * time.Second
+
+// Reloader 负责加载证书和 CA，并在文件变化时重新加载
+//
+// 它不启动后台协程，而是在 TLS 握手时按 interval 检查文件的修改时间和大小，
+// 加载失败时继续使用上一次成功加载的证书。
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	stamps    map[string]fileStamp
+	lastCheck time.Time
+}
+
+// fileStamp 记录文件的修改时间和大小，用于判断文件是否变化
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader 创建一个新的 Reloader 实例并立即加载一次证书
+func NewReloader(certFile, keyFile, caFile string, interval time.Duration) (*Reloader, error) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		interval: interval,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 可直接用作 tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, _ := r.current()
+	return cert, nil
+}
+
+// current 返回当前的证书和 CA 池，必要时先检查文件是否变化
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.maybeReload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+// maybeReload 在距离上次检查超过 interval 时检查文件变化并重新加载
+func (r *Reloader) maybeReload() {
+	r.mu.RLock()
+	due := time.Since(r.lastCheck) >= r.interval
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	r.mu.Lock()
+	// 双重检查，避免并发握手重复加载
+	if time.Since(r.lastCheck) < r.interval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCheck = time.Now()
+	changed := r.changedLocked()
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := r.load(); err != nil {
+		log.Errorw("重新加载 TLS 证书失败，继续使用旧证书", "cert_file", r.certFile, "error", err)
+		return
+	}
+	log.Infow("已重新加载 TLS 证书", "cert_file", r.certFile, "ca_file", r.caFile)
+}
+
+// changedLocked 判断任一文件是否发生变化，调用方必须持有锁
+func (r *Reloader) changedLocked() bool {
+	for path, old := range r.stamps {
+		stamp, err := statFile(path)
+		if err != nil {
+			// 文件在轮换过程中可能暂时不存在，等待下一次检查
+			continue
+		}
+		if stamp != old {
+			return true
+		}
+	}
+	return false
+}
+
+// load 从磁盘加载证书和 CA
+func (r *Reloader) load() error {
+	stamps := make(map[string]fileStamp)
+	for _, path := range []string{r.certFile, r.keyFile, r.caFile} {
+		if path == "" {
+			continue
+		}
+		stamp, err := statFile(path)
+		if err != nil {
+			return err
+		}
+		stamps[path] = stamp
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		caPEM, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("读取 CA 文件失败: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("CA 文件 %s 中没有有效的证书", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.pool = pool
+	r.stamps = stamps
+	r.lastCheck = time.Now()
+	return nil
+}
+
+// statFile 获取文件的修改时间和大小
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
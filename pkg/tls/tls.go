@@ -0,0 +1,60 @@
+// Package tls 根据配置构建服务端 TLS 配置，支持双向认证和证书热加载，
+// 并提供从连接状态中提取客户端证书身份的工具。
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/costa92/go-protoc/pkg/config"
+)
+
+// NewConfig 根据配置创建服务端 *tls.Config
+// 证书和 CA 文件在握手时按 ReloadInterval 检查变化并自动重新加载，无需重启服务
+func NewConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("TLS 配置缺少 cert_file 或 key_file")
+	}
+
+	minVersion, err := cfg.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := cfg.CipherSuiteIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	clientAuth, err := cfg.ClientAuthType()
+	if err != nil {
+		return nil, err
+	}
+	if clientAuth >= tls.VerifyClientCertIfGiven && cfg.CAFile == "" {
+		return nil, fmt.Errorf("client_auth 为 %s 时必须配置 ca_file", cfg.ClientAuth)
+	}
+
+	reloader, err := NewReloader(cfg.CertFile, cfg.KeyFile, cfg.CAFile, cfg.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	// template 是每次握手时生成配置的模板
+	template := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		ClientAuth:   clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	base := template.Clone()
+	base.GetCertificate = reloader.GetCertificate
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cert, pool := reloader.current()
+		c := template.Clone()
+		c.Certificates = []tls.Certificate{*cert}
+		c.ClientCAs = pool
+		return c, nil
+	}
+	return base, nil
+}
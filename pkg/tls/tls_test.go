@@ -0,0 +1,204 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/costa92/go-protoc/pkg/config"
+)
+
+// testCA 是测试用的证书颁发机构
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 CA 私钥失败: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成 CA 证书失败: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发一个证书，返回 PEM 格式的证书和私钥
+func (ca *testCA) issue(t *testing.T, serial int64, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	spiffe, _ := url.Parse("spiffe://example.org/" + cn)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"go-protoc"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		URIs:         []*url.URL{spiffe},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("签发证书失败: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("修改文件时间失败: %v", err)
+	}
+}
+
+func TestMutualTLSAndReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	now := time.Now()
+
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	serverCert, serverKey := ca.issue(t, 100, "server", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, serverCert, now)
+	writeFile(t, keyFile, serverKey, now)
+	writeFile(t, caFile, ca.pem, now)
+
+	serverConfig, err := NewConfig(&config.TLSConfig{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		CAFile:         caFile,
+		MinVersion:     "1.2",
+		ClientAuth:     "require_and_verify",
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("创建 TLS 配置失败: %v", err)
+	}
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	defer lis.Close()
+
+	identities := make(chan *Identity, 2)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := conn.(*tls.Conn)
+			if err := tlsConn.Handshake(); err == nil {
+				state := tlsConn.ConnectionState()
+				id, _ := IdentityFromConnectionState(&state)
+				identities <- id
+			}
+			conn.Close()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+	clientCertPEM, clientKeyPEM := ca.issue(t, 200, "client", x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	if err != nil {
+		t.Fatalf("加载客户端证书失败: %v", err)
+	}
+
+	// dial 建立一次 TLS 连接并返回服务端证书的序列号
+	dial := func() int64 {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{clientCert},
+			ServerName:   "localhost",
+		})
+		if err != nil {
+			t.Fatalf("TLS 连接失败: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if serial := dial(); serial != 100 {
+		t.Errorf("服务端证书序列号不匹配: 期望=100, 实际=%d", serial)
+	}
+	id := <-identities
+	if id == nil {
+		t.Fatal("没有提取到客户端身份")
+	}
+	if id.CommonName != "client" || len(id.URIs) != 1 || id.URIs[0] != "spiffe://example.org/client" {
+		t.Errorf("客户端身份不匹配: %+v", id)
+	}
+
+	// 轮换服务端证书
+	serverCert, serverKey = ca.issue(t, 101, "server", x509.ExtKeyUsageServerAuth)
+	later := now.Add(time.Minute)
+	writeFile(t, certFile, serverCert, later)
+	writeFile(t, keyFile, serverKey, later)
+	time.Sleep(20 * time.Millisecond)
+
+	if serial := dial(); serial != 101 {
+		t.Errorf("证书轮换后序列号不匹配: 期望=101, 实际=%d", serial)
+	}
+	<-identities
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	// 定义测试用例
+	testCases := []struct {
+		name string
+		cfg  config.TLSConfig
+	}{
+		{name: "缺少证书", cfg: config.TLSConfig{}},
+		{name: "无效版本", cfg: config.TLSConfig{CertFile: "a", KeyFile: "b", MinVersion: "2.0"}},
+		{name: "无效套件", cfg: config.TLSConfig{CertFile: "a", KeyFile: "b", CipherSuites: []string{"foo"}}},
+		{name: "双向认证缺少CA", cfg: config.TLSConfig{CertFile: "a", KeyFile: "b", ClientAuth: "require_and_verify"}},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewConfig(&tc.cfg); err == nil {
+				t.Error("期望返回错误")
+			}
+		})
+	}
+}
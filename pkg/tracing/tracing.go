@@ -30,18 +30,18 @@ func InitTracer(cfg *config.TracingConfig) (*trace.TracerProvider, error) {
 
 	// 根据配置选择导出器
 	switch cfg.Exporter {
-	case "stdout":
+	case config.TracingExporterStdout:
 		exporter, err = stdouttrace.New(
 			stdouttrace.WithWriter(os.Stdout),
 			stdouttrace.WithPrettyPrint(),
 		)
-	case "jaeger":
+	case config.TracingExporterJaeger:
 		// 这里使用的是Jaeger的gRPC导出器
 		// 您可以根据需要配置更多选项，例如使用HTTP导出器
 		exporter, err = jaeger.New(
 			jaeger.WithCollectorEndpoint(),
 		)
-	case "otlp":
+	case config.TracingExporterOTLP:
 		client := otlptracegrpc.NewClient(
 			otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint),
 			otlptracegrpc.WithInsecure(),
@@ -0,0 +1,229 @@
+// Package validation 统一 protoc-gen-validate 生成的 Validate/ValidateAll 方法和
+// go-playground/validator 的校验结果，将校验错误展开为字段级别的违规列表。
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "github.com/costa92/go-protoc/pkg/errors"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Violation 描述一个字段的校验错误
+type Violation struct {
+	// Field 是字段路径，如 name、items[0].id
+	Field string `json:"field"`
+	// Description 是校验失败的原因
+	Description string `json:"description"`
+}
+
+// allValidator 是 protoc-gen-validate 生成的返回全部错误的校验接口
+type allValidator interface {
+	ValidateAll() error
+}
+
+// firstValidator 是 protoc-gen-validate 生成的遇到第一个错误即返回的校验接口
+type firstValidator interface {
+	Validate() error
+}
+
+// fieldError 是 protoc-gen-validate 生成的单个字段校验错误
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// multiError 是 protoc-gen-validate 生成的多个校验错误的集合
+type multiError interface {
+	AllErrors() []error
+}
+
+// Validate 校验消息，优先调用 ValidateAll 以获得全部错误，不支持校验的消息返回 nil
+func Validate(msg any) error {
+	switch v := msg.(type) {
+	case allValidator:
+		return v.ValidateAll()
+	case firstValidator:
+		return v.Validate()
+	default:
+		return nil
+	}
+}
+
+// Violations 将校验错误展开为字段级别的违规列表
+// 支持 protoc-gen-validate 生成的错误（包括嵌套消息）和 validator.ValidationErrors，
+// 无法识别的错误作为一个没有字段路径的违规返回
+func Violations(err error) []Violation {
+	if err == nil {
+		return nil
+	}
+	return appendViolations(nil, "", err)
+}
+
+// ProtoViolations 将 proto 消息的校验错误展开为字段违规列表
+// protoc-gen-validate 使用 Go 字段名报告错误，这里按消息描述符转换为 JSON 字段名，如 UserName 转换为 userName
+func ProtoViolations(msg proto.Message, err error) []Violation {
+	violations := Violations(err)
+	desc := msg.ProtoReflect().Descriptor()
+	for i := range violations {
+		violations[i].Field = jsonFieldPath(desc, violations[i].Field)
+	}
+	return violations
+}
+
+// jsonFieldPath 将 Go 字段路径转换为 JSON 字段路径，无法识别的部分保持不变
+func jsonFieldPath(desc protoreflect.MessageDescriptor, path string) string {
+	if path == "" {
+		return path
+	}
+	segments := strings.Split(path, ".")
+	for i, seg := range segments {
+		if desc == nil {
+			break
+		}
+		name, index, _ := strings.Cut(seg, "[")
+		fd := findField(desc, name)
+		if fd == nil {
+			break
+		}
+		if index != "" {
+			index = "[" + index
+		}
+		segments[i] = fd.JSONName() + index
+
+		if fd.IsMap() {
+			desc = fd.MapValue().Message()
+		} else {
+			desc = fd.Message()
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// findField 按 Go 字段名查找字段描述符
+func findField(desc protoreflect.MessageDescriptor, goName string) protoreflect.FieldDescriptor {
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if strings.EqualFold(strings.ReplaceAll(string(fd.Name()), "_", ""), goName) {
+			return fd
+		}
+	}
+	return nil
+}
+
+// appendViolations 将 err 展开后追加到 violations 中，prefix 是外层消息的字段路径
+func appendViolations(violations []Violation, prefix string, err error) []Violation {
+	var multi multiError
+	if errors.As(err, &multi) {
+		for _, e := range multi.AllErrors() {
+			violations = appendViolations(violations, prefix, e)
+		}
+		return violations
+	}
+
+	var fe fieldError
+	if errors.As(err, &fe) {
+		path := joinPath(prefix, fe.Field())
+		// 嵌套消息校验失败时，展开内层消息的错误
+		if cause := fe.Cause(); cause != nil {
+			var (
+				innerMulti multiError
+				innerField fieldError
+			)
+			if errors.As(cause, &innerMulti) || errors.As(cause, &innerField) {
+				return appendViolations(violations, path, cause)
+			}
+		}
+		return append(violations, Violation{Field: path, Description: fe.Reason()})
+	}
+
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		for _, e := range verrs {
+			violations = append(violations, Violation{
+				Field:       joinPath(prefix, structFieldPath(e)),
+				Description: describe(e),
+			})
+		}
+		return violations
+	}
+
+	return append(violations, Violation{Field: prefix, Description: err.Error()})
+}
+
+// structFieldPath 返回去掉顶层结构体名称的字段路径，如 CreateUserRequest.name 返回 name
+func structFieldPath(e validator.FieldError) string {
+	if _, path, ok := strings.Cut(e.Namespace(), "."); ok {
+		return path
+	}
+	return e.Field()
+}
+
+// describe 返回 validator 字段错误的描述
+func describe(e validator.FieldError) string {
+	if e.Param() != "" {
+		return fmt.Sprintf("不满足校验规则 %s=%s", e.Tag(), e.Param())
+	}
+	return fmt.Sprintf("不满足校验规则 %s", e.Tag())
+}
+
+// joinPath 拼接字段路径
+func joinPath(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	default:
+		return prefix + "." + field
+	}
+}
+
+// Status 将校验错误转换为 InvalidArgument 状态，每个失败字段对应 errdetails.BadRequest 中的一个 FieldViolation
+// 状态中同时包含 errors.ErrValidation 的 errdetails.ErrorInfo
+// msg 为 proto 消息时字段路径使用 JSON 字段名
+func Status(msg any, err error) *status.Status {
+	var violations []Violation
+	if m, ok := msg.(proto.Message); ok {
+		violations = ProtoViolations(m, err)
+	} else {
+		violations = Violations(err)
+	}
+
+	br := &errdetails.BadRequest{}
+	for _, v := range violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	st := apierrors.ErrValidation.GRPCStatus()
+	if withDetails, detailErr := st.WithDetails(br); detailErr == nil {
+		return withDetails
+	}
+	return st
+}
+
+// FromStatus 从状态的 errdetails.BadRequest 详情中提取字段违规列表
+func FromStatus(st *status.Status) []Violation {
+	var violations []Violation
+	for _, detail := range st.Details() {
+		br, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, fv := range br.GetFieldViolations() {
+			violations = append(violations, Violation{Field: fv.GetField(), Description: fv.GetDescription()})
+		}
+	}
+	return violations
+}
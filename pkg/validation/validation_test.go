@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	helloworldv2 "github.com/costa92/go-protoc/pkg/api/helloworld/v2"
+	"github.com/go-playground/validator/v10"
+)
+
+type createUserRequest struct {
+	Name    string `validate:"required"`
+	Age     int    `validate:"gte=0,lte=150"`
+	Address struct {
+		City string `validate:"required"`
+	}
+}
+
+func TestViolations(t *testing.T) {
+	structErr := validator.New().Struct(&createUserRequest{Age: 200})
+
+	// 定义测试用例
+	testCases := []struct {
+		name     string
+		err      error
+		expected []Violation
+	}{
+		{
+			name: "proto消息",
+			err:  Validate(&helloworldv2.HelloRequest{}),
+			expected: []Violation{
+				{Field: "Name", Description: "value length must be between 1 and 100 runes, inclusive"},
+			},
+		},
+		{
+			name: "结构体",
+			err:  structErr,
+			expected: []Violation{
+				{Field: "Name", Description: "不满足校验规则 required"},
+				{Field: "Age", Description: "不满足校验规则 lte=150"},
+				{Field: "Address.City", Description: "不满足校验规则 required"},
+			},
+		},
+		{
+			name:     "普通错误",
+			err:      errors.New("boom"),
+			expected: []Violation{{Field: "", Description: "boom"}},
+		},
+		{
+			name: "无错误",
+		},
+	}
+
+	// 执行测试
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Violations(tc.err); !slices.Equal(got, tc.expected) {
+				t.Errorf("违规列表不匹配: 期望=%v, 实际=%v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestProtoViolations(t *testing.T) {
+	msg := &helloworldv2.HelloRequest{Name: strings.Repeat("a", 101)}
+	got := ProtoViolations(msg, Validate(msg))
+	if len(got) != 1 || got[0].Field != "name" {
+		t.Errorf("期望字段路径使用 JSON 字段名: %v", got)
+	}
+
+	// 不支持校验的消息
+	if err := Validate(struct{}{}); err != nil {
+		t.Errorf("期望返回 nil，实际: %v", err)
+	}
+}